@@ -7,6 +7,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
 	"pansou/config"
+	"pansou/plugin"
 	"pansou/service"
 	"pansou/util"
 )
@@ -239,10 +240,15 @@ type SystemInfoResponse struct {
 
 // PluginInfoResponse 插件信息响应
 type PluginInfoResponse struct {
-	Name        string `json:"name"`
-	Priority    int    `json:"priority"`
-	Status      string `json:"status"`
-	Description string `json:"description"`
+	Name            string `json:"name"`
+	Priority        int    `json:"priority"`
+	DefaultPriority int    `json:"default_priority"`
+	Status          string `json:"status"` // idle、healthy、degraded、failing 或 disabled
+	Description     string `json:"description"`
+
+	CircuitBreaker *plugin.CircuitBreakerStatus `json:"circuit_breaker,omitempty"` // 未启用熔断时为空
+	Capabilities   *plugin.Capabilities         `json:"capabilities,omitempty"`    // 插件未声明能力时为空
+	ExtSchema      []plugin.ExtParam            `json:"ext_schema,omitempty"`      // 插件未声明ext参数时为空
 }
 
 // SystemStatsResponse 系统统计响应
@@ -293,24 +299,19 @@ func GetSystemInfoHandler(searchService *service.SearchService) gin.HandlerFunc
 			return
 		}
 		
-		// 获取所有插件
-		plugins := pluginManager.GetPlugins()
+		// 获取所有插件（包含管理后台禁用的插件）
+		plugins := pluginManager.GetAllPlugins()
 		
 		// 构建插件信息列表
 		pluginInfos := make([]PluginInfoResponse, 0, len(plugins))
 		for _, p := range plugins {
-			pluginInfos = append(pluginInfos, PluginInfoResponse{
-				Name:        p.Name(),
-				Priority:    p.Priority(),
-				Status:      "active", // 所有已注册的插件都是活跃状态
-				Description: getPluginDescription(p.Name()),
-			})
+			pluginInfos = append(pluginInfos, buildPluginInfo(p))
 		}
 		
 		// 构建系统统计信息
 		stats := SystemStatsResponse{
 			PluginCount:       len(plugins),
-			ActivePluginCount: len(plugins), // 所有已注册的插件都是活跃的
+			ActivePluginCount: len(pluginManager.GetPlugins()),
 			ChannelCount:      len(config.AppConfig.DefaultChannels),
 			CacheEnabled:      config.AppConfig.CacheEnabled,
 			ProxyEnabled:      config.AppConfig.UseProxy,
@@ -342,6 +343,46 @@ func GetSystemInfoHandler(searchService *service.SearchService) gin.HandlerFunc
 	}
 }
 
+// GetTGMirrorsHandler 获取TG预览镜像健康状态
+func GetTGMirrorsHandler(c *gin.Context) {
+	mirrors := util.GetTGMirrorPool().Status()
+	c.JSON(200, gin.H{
+		"mirrors": mirrors,
+		"total":   len(mirrors),
+	})
+}
+
+// GetOutboundHostsHandler 获取出站请求按主机限流与排队统计
+func GetOutboundHostsHandler(c *gin.Context) {
+	limiter := util.GetHostLimiter()
+	hosts := limiter.Stats()
+	c.JSON(200, gin.H{
+		"enabled": limiter.Enabled(),
+		"hosts":   hosts,
+		"total":   len(hosts),
+	})
+}
+
+// GetProxiesHandler 获取代理池中各代理的健康状态与使用统计
+func GetProxiesHandler(c *gin.Context) {
+	pool := util.GetProxyPool()
+	if pool == nil {
+		c.JSON(200, gin.H{
+			"enabled": false,
+			"proxies": []util.ProxyStats{},
+			"total":   0,
+		})
+		return
+	}
+	proxies := pool.Stats()
+	c.JSON(200, gin.H{
+		"enabled": true,
+		"tg_tag":  pool.TGTag(),
+		"proxies": proxies,
+		"total":   len(proxies),
+	})
+}
+
 // getPluginDescription 获取插件描述（根据插件名称返回中文描述）
 func getPluginDescription(name string) string {
 	descriptions := map[string]string{
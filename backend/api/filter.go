@@ -1,13 +1,74 @@
 package api
 
 import (
+	"fmt"
 	"pansou/model"
+	"pansou/util"
 	"strings"
 )
 
+// metricFilter 基于浏览次数与文件大小的数值过滤条件
+type metricFilter struct {
+	minViews int
+	minSize  int64
+	maxSize  int64
+}
+
+// newMetricFilter 解析过滤器中的数值条件，文件大小无法解析或浏览次数为负数时返回错误
+func newMetricFilter(filter *model.FilterConfig) (metricFilter, error) {
+	if filter.MinViews < 0 {
+		return metricFilter{}, fmt.Errorf("min_views不能为负数")
+	}
+	minSize, err := parseSizeFilter("min_size", filter.MinSize)
+	if err != nil {
+		return metricFilter{}, err
+	}
+	maxSize, err := parseSizeFilter("max_size", filter.MaxSize)
+	if err != nil {
+		return metricFilter{}, err
+	}
+	return metricFilter{minViews: filter.MinViews, minSize: minSize, maxSize: maxSize}, nil
+}
+
+// parseSizeFilter 解析文件大小条件，空字符串表示不限制
+func parseSizeFilter(name, value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	size := util.ParseFileSize(value)
+	if size <= 0 {
+		return 0, fmt.Errorf("%s无效: %q（应为文件大小，如500MB、4GB）", name, value)
+	}
+	return size, nil
+}
+
+// active 是否设置了任一数值过滤条件
+func (m metricFilter) active() bool {
+	return m.minViews > 0 || m.minSize > 0 || m.maxSize > 0
+}
+
+// match 检查浏览次数与文件大小是否满足条件。
+// 浏览次数与文件大小只有TG结果才有，没有对应数据的结果（如插件结果）不按该条件过滤
+func (m metricFilter) match(views int, size int64) bool {
+	if m.minViews > 0 && views > 0 && views < m.minViews {
+		return false
+	}
+	if size > 0 && (m.minSize > 0 && size < m.minSize || m.maxSize > 0 && size > m.maxSize) {
+		return false
+	}
+	return true
+}
+
 // applyResultFilter 应用过滤器到搜索响应
 func applyResultFilter(response model.SearchResponse, filter *model.FilterConfig, resultType string) model.SearchResponse {
-	if filter == nil || (len(filter.Include) == 0 && len(filter.Exclude) == 0) {
+	if filter == nil {
+		return response
+	}
+
+	// 数值条件已在请求处理时校验，这里忽略错误
+	metrics, _ := newMetricFilter(filter)
+	if len(filter.Include) == 0 && len(filter.Exclude) == 0 && !metrics.active() {
 		return response
 	}
 
@@ -25,7 +86,7 @@ func applyResultFilter(response model.SearchResponse, filter *model.FilterConfig
 	// 根据结果类型决定过滤策略
 	if resultType == "merged_by_type" || resultType == "" {
 		// 过滤 merged_by_type 的 note 字段
-		response.MergedByType = filterMergedByType(response.MergedByType, includeKeywords, excludeKeywords)
+		response.MergedByType = filterMergedByType(response.MergedByType, includeKeywords, excludeKeywords, metrics)
 		
 		// 重新计算 total
 		total := 0
@@ -35,12 +96,12 @@ func applyResultFilter(response model.SearchResponse, filter *model.FilterConfig
 		response.Total = total
 	} else if resultType == "all" || resultType == "results" {
 		// 过滤 results 的 title 和 links 的 work_title
-		response.Results = filterResults(response.Results, includeKeywords, excludeKeywords)
+		response.Results = filterResults(response.Results, includeKeywords, excludeKeywords, metrics)
 		response.Total = len(response.Results)
 		
 		// 如果是 all 类型，也需要过滤 merged_by_type
 		if resultType == "all" {
-			response.MergedByType = filterMergedByType(response.MergedByType, includeKeywords, excludeKeywords)
+			response.MergedByType = filterMergedByType(response.MergedByType, includeKeywords, excludeKeywords, metrics)
 		}
 	}
 
@@ -48,7 +109,7 @@ func applyResultFilter(response model.SearchResponse, filter *model.FilterConfig
 }
 
 // filterMergedByType 过滤 merged_by_type 中的链接
-func filterMergedByType(mergedLinks model.MergedLinks, includeKeywords, excludeKeywords []string) model.MergedLinks {
+func filterMergedByType(mergedLinks model.MergedLinks, includeKeywords, excludeKeywords []string, metrics metricFilter) model.MergedLinks {
 	if mergedLinks == nil {
 		return nil
 	}
@@ -59,7 +120,7 @@ func filterMergedByType(mergedLinks model.MergedLinks, includeKeywords, excludeK
 		filteredLinks := make([]model.MergedLink, 0)
 		
 		for _, link := range links {
-			if matchFilter(link.Note, includeKeywords, excludeKeywords) {
+			if matchFilter(link.Note, includeKeywords, excludeKeywords) && metrics.match(link.Views, link.Size) {
 				filteredLinks = append(filteredLinks, link)
 			}
 		}
@@ -74,7 +135,7 @@ func filterMergedByType(mergedLinks model.MergedLinks, includeKeywords, excludeK
 }
 
 // filterResults 过滤 results 数组
-func filterResults(results []model.SearchResult, includeKeywords, excludeKeywords []string) []model.SearchResult {
+func filterResults(results []model.SearchResult, includeKeywords, excludeKeywords []string, metrics metricFilter) []model.SearchResult {
 	if results == nil {
 		return nil
 	}
@@ -86,6 +147,11 @@ func filterResults(results []model.SearchResult, includeKeywords, excludeKeyword
 		if !matchFilter(result.Title, includeKeywords, excludeKeywords) {
 			continue
 		}
+
+		// 检查浏览次数与文件大小
+		if !metrics.match(result.Views, result.MaxFileSize()) {
+			continue
+		}
 		
 		// title 匹配后，过滤 links 中的 work_title
 		filteredLinks := make([]model.Link, 0)
@@ -4,10 +4,13 @@ import (
 	// "fmt"
 	"net/http"
 	// "os"
+	"sort"
 	
 	"github.com/gin-gonic/gin"
 	"pansou/config"
 	"pansou/model"
+	"pansou/plugin"
+	"pansou/plugin/remote"
 	"pansou/service"
 	jsonutil "pansou/util/json"
 	"pansou/util"
@@ -142,6 +145,13 @@ func SearchHandler(c *gin.Context) {
 			}
 		}
 
+		// 处理搜索深度参数
+		depth := c.Query("depth")
+		maxPages := 0
+		if maxPagesStr := c.Query("max_pages"); maxPagesStr != "" && maxPagesStr != " " {
+			maxPages = util.StringToInt(maxPagesStr)
+		}
+
 		req = model.SearchRequest{
 			Keyword:      keyword,
 			Channels:     channels,
@@ -153,6 +163,8 @@ func SearchHandler(c *gin.Context) {
 			CloudTypes:   cloudTypes, // 添加cloud_types到请求中
 			Ext:          ext,
 			Filter:       filter,
+			Depth:        depth,
+			MaxPages:     maxPages,
 		}
 	} else {
 		// POST方式：从请求体获取
@@ -186,11 +198,14 @@ func SearchHandler(c *gin.Context) {
 		req.SourceType = "all"
 	}
 	
-	// 参数互斥逻辑：当src=tg时忽略plugins参数，当src=plugin时忽略channels参数
+	// 参数互斥逻辑：当src=tg时忽略plugins参数，当src=plugin时忽略channels参数，当src=index时两者都忽略
 	if req.SourceType == "tg" {
 		req.Plugins = nil // 忽略plugins参数
 	} else if req.SourceType == "plugin" {
 		req.Channels = nil // 忽略channels参数
+	} else if req.SourceType == "index" {
+		req.Plugins = nil
+		req.Channels = nil
 	} else if req.SourceType == "all" {
 		// 对于all类型，如果plugins为空或不存在，统一设为nil
 		if req.Plugins == nil || len(req.Plugins) == 0 {
@@ -198,6 +213,43 @@ func SearchHandler(c *gin.Context) {
 		}
 	}
 	
+	// 其他实例转发来的请求不再转发给远程实例，避免实例间循环转发
+	if c.GetHeader(remote.HopHeader) != "" && !excludeRemotePlugins(&req) {
+		response := model.NewSuccessResponse(model.SearchResponse{Results: []model.SearchResult{}})
+		jsonData, _ := jsonutil.Marshal(response)
+		c.Data(http.StatusOK, "application/json", jsonData)
+		return
+	}
+	
+	// 校验过滤器的数值条件
+	if req.Filter != nil {
+		if _, err := newMetricFilter(req.Filter); err != nil {
+			c.JSON(http.StatusBadRequest, model.NewErrorResponse(400, err.Error()))
+			return
+		}
+	}
+
+	// ext的保留键只能由服务端设置，丢弃客户端自行传入的值
+	delete(req.Ext, plugin.SearchOptionsKey)
+	delete(req.Ext, plugin.SearchDeadlineKey)
+
+	// 显式指定插件时按插件声明的ext参数校验
+	if err := validateExt(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	// 搜索深度通过ext的保留键传给插件
+	searchOptions := plugin.SearchOptions{Depth: req.Depth, MaxPages: req.MaxPages}
+	if err := searchOptions.Normalize(); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(400, err.Error()))
+		return
+	}
+	if req.Ext == nil {
+		req.Ext = make(map[string]interface{})
+	}
+	plugin.SetSearchOptions(req.Ext, searchOptions)
+	
 	// 可选：启用调试输出（生产环境建议注释掉）
 	// fmt.Printf("🔧 [调试] 搜索参数: keyword=%s, channels=%v, concurrency=%d, refresh=%v, resultType=%s, sourceType=%s, plugins=%v, cloudTypes=%v, ext=%v\n", 
 	//	req.Keyword, req.Channels, req.Concurrency, req.ForceRefresh, req.ResultType, req.SourceType, req.Plugins, req.CloudTypes, req.Ext)
@@ -221,4 +273,106 @@ func SearchHandler(c *gin.Context) {
 	response := model.NewSuccessResponse(result)
 	jsonData, _ := jsonutil.Marshal(response)
 	c.Data(http.StatusOK, "application/json", jsonData)
-} 
\ No newline at end of file
+} 
+
+// excludeRemotePlugins 从搜索请求中排除远程实例插件，返回false表示排除后没有可搜索的来源
+func excludeRemotePlugins(req *model.SearchRequest) bool {
+	if req.SourceType != "all" && req.SourceType != "plugin" {
+		return true
+	}
+
+	hasRemote := false
+	var local []string
+	if len(req.Plugins) == 0 {
+		for _, p := range plugin.GetRegisteredPlugins() {
+			if remote.IsRemote(p.Name()) {
+				hasRemote = true
+			} else {
+				local = append(local, p.Name())
+			}
+		}
+	} else {
+		for _, name := range req.Plugins {
+			if remote.IsRemote(name) {
+				hasRemote = true
+			} else {
+				local = append(local, name)
+			}
+		}
+	}
+	if !hasRemote {
+		return true
+	}
+
+	if len(local) > 0 {
+		req.Plugins = local
+		return true
+	}
+	// 只指定了远程实例插件时，全部来源降级为仅搜索TG
+	if req.SourceType == "plugin" {
+		return false
+	}
+	req.SourceType = "tg"
+	req.Plugins = nil
+	return true
+}
+
+// validateExt 按显式指定插件声明的ext参数校验请求，未指定插件时不校验
+func validateExt(req *model.SearchRequest) error {
+	if len(req.Plugins) == 0 || len(req.Ext) == 0 || searchService == nil || searchService.GetPluginManager() == nil {
+		return nil
+	}
+	if req.SourceType != "all" && req.SourceType != "plugin" {
+		return nil
+	}
+
+	requested := make(map[string]bool, len(req.Plugins))
+	for _, name := range req.Plugins {
+		requested[strings.ToLower(name)] = true
+	}
+	var selected []plugin.AsyncSearchPlugin
+	for _, p := range searchService.GetPluginManager().GetPlugins() {
+		if requested[strings.ToLower(p.Name())] {
+			selected = append(selected, p)
+		}
+	}
+	return plugin.ValidateExt(selected, req.Ext)
+}
+
+// PublicPluginInfo 对客户端公开的插件信息
+type PublicPluginInfo struct {
+	Name         string               `json:"name"`
+	Description  string               `json:"description"`
+	Priority     int                  `json:"priority"`
+	Capabilities *plugin.Capabilities `json:"capabilities,omitempty"` // 插件未声明能力时为空
+	ExtSchema    []plugin.ExtParam    `json:"ext_schema,omitempty"`   // 插件未声明ext参数时为空
+}
+
+// PluginsHandler 列出当前启用的插件及其描述、能力声明与ext参数
+func PluginsHandler(c *gin.Context) {
+	infos := make([]PublicPluginInfo, 0)
+	if config.AppConfig.AsyncPluginEnabled && searchService != nil && searchService.GetPluginManager() != nil {
+		for _, p := range searchService.GetPluginManager().GetPlugins() {
+			info := PublicPluginInfo{
+				Name:        p.Name(),
+				Description: getPluginDescription(p.Name()),
+				Priority:    p.Priority(),
+			}
+			if caps, ok := plugin.GetCapabilities(p); ok {
+				info.Capabilities = &caps
+			}
+			if schema, ok := plugin.GetExtSchema(p); ok {
+				info.ExtSchema = schema
+			}
+			infos = append(infos, info)
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+
+	c.JSON(200, gin.H{
+		"plugins": infos,
+		"total":   len(infos),
+	})
+}
@@ -227,6 +227,7 @@ func isPublicPath(path string) bool {
 		"/api/auth/logout",
 		"/api/health",
 		"/api/admin/login", // 管理员登录接口无需认证
+		"/api/tg/webhook",  // Telegram Webhook使用Secret Token校验
 	}
 
 	for _, p := range publicPaths {
@@ -0,0 +1,207 @@
+package api
+
+import (
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"pansou/plugin"
+	"pansou/service"
+)
+
+// UpdatePluginRequest 更新插件运行时状态请求
+type UpdatePluginRequest struct {
+	Enabled  *bool `json:"enabled"`  // 启用或禁用插件
+	Priority *int  `json:"priority"` // 优先级覆盖（1-4），0表示恢复默认优先级
+}
+
+// buildPluginInfo 构建插件信息（包含运行时状态）
+func buildPluginInfo(p plugin.AsyncSearchPlugin) PluginInfoResponse {
+	info := PluginInfoResponse{
+		Name:            p.Name(),
+		Priority:        p.Priority(),
+		DefaultPriority: p.Priority(),
+		Status:          plugin.GetPluginStatus(p.Name()),
+		Description:     getPluginDescription(p.Name()),
+		CircuitBreaker:  plugin.GetCircuitBreakerStatus(p.Name()),
+	}
+	if dp, ok := p.(interface{ DefaultPriority() int }); ok {
+		info.DefaultPriority = dp.DefaultPriority()
+	}
+	if caps, ok := plugin.GetCapabilities(p); ok {
+		info.Capabilities = &caps
+	}
+	if schema, ok := plugin.GetExtSchema(p); ok {
+		info.ExtSchema = schema
+	}
+	return info
+}
+
+// ListPluginsHandler 列出所有插件及其运行时状态
+func ListPluginsHandler(searchService *service.SearchService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pluginManager := searchService.GetPluginManager()
+		if pluginManager == nil {
+			c.JSON(500, gin.H{
+				"error": "插件管理器未初始化",
+				"code":  "PLUGIN_MANAGER_NOT_INITIALIZED",
+			})
+			return
+		}
+
+		plugins := pluginManager.GetAllPlugins()
+		infos := make([]PluginInfoResponse, 0, len(plugins))
+		for _, p := range plugins {
+			infos = append(infos, buildPluginInfo(p))
+		}
+		sort.Slice(infos, func(i, j int) bool {
+			return infos[i].Name < infos[j].Name
+		})
+
+		c.JSON(200, gin.H{
+			"plugins": infos,
+			"total":   len(infos),
+		})
+	}
+}
+
+// UpdatePluginHandler 启用/禁用插件或覆盖插件优先级（立即生效并持久化）
+func UpdatePluginHandler(searchService *service.SearchService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.Param("name")
+		p, exists := plugin.GetPluginByName(name)
+		if !exists {
+			c.JSON(404, gin.H{
+				"error": "插件不存在: " + name,
+				"code":  "PLUGIN_NOT_FOUND",
+			})
+			return
+		}
+
+		var req UpdatePluginRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, gin.H{
+				"error": "请求参数错误",
+				"code":  "INVALID_REQUEST",
+			})
+			return
+		}
+
+		if req.Enabled == nil && req.Priority == nil {
+			c.JSON(400, gin.H{
+				"error": "参数错误：必须提供 enabled 或 priority",
+				"code":  "INVALID_REQUEST",
+			})
+			return
+		}
+
+		if req.Priority != nil {
+			if err := plugin.SetPluginPriority(name, *req.Priority); err != nil {
+				c.JSON(400, gin.H{
+					"error": "更新插件优先级失败: " + err.Error(),
+					"code":  "PLUGIN_UPDATE_FAILED",
+				})
+				return
+			}
+		}
+
+		if req.Enabled != nil {
+			if err := plugin.SetPluginEnabled(name, *req.Enabled); err != nil {
+				c.JSON(500, gin.H{
+					"error": "更新插件状态失败: " + err.Error(),
+					"code":  "PLUGIN_UPDATE_FAILED",
+				})
+				return
+			}
+			// 重新启用的插件立即恢复调用，不再等待熔断期结束
+			if *req.Enabled {
+				plugin.ResetCircuitBreaker(name)
+			}
+		}
+
+		c.JSON(200, gin.H{
+			"plugin": buildPluginInfo(p),
+		})
+	}
+}
+
+// GetPluginStatsHandler 获取插件调用统计（调用次数、错误分类、延迟分位数等）
+func GetPluginStatsHandler(searchService *service.SearchService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.Param("name")
+		if _, exists := plugin.GetPluginByName(name); !exists {
+			c.JSON(404, gin.H{
+				"error": "插件不存在: " + name,
+				"code":  "PLUGIN_NOT_FOUND",
+			})
+			return
+		}
+
+		pluginManager := searchService.GetPluginManager()
+		if pluginManager == nil {
+			c.JSON(500, gin.H{
+				"error": "插件管理器未初始化",
+				"code":  "PLUGIN_MANAGER_NOT_INITIALIZED",
+			})
+			return
+		}
+
+		c.JSON(200, pluginManager.GetStats(name))
+	}
+}
+
+// ListSessionsHandler 列出插件会话状态（登录状态、Cookie数量、登录与过期次数，不返回Cookie值）
+func ListSessionsHandler(c *gin.Context) {
+	sessions := plugin.ListSessions()
+	c.JSON(200, gin.H{
+		"sessions": sessions,
+		"total":    len(sessions),
+	})
+}
+
+// ClearSessionHandler 清除插件会话的Cookie与登录状态，下次请求时重新登录
+func ClearSessionHandler(c *gin.Context) {
+	name := c.Param("name")
+	if err := plugin.ClearSession(name); err != nil {
+		c.JSON(404, gin.H{
+			"error": err.Error(),
+			"code":  "SESSION_NOT_FOUND",
+		})
+		return
+	}
+	c.JSON(200, gin.H{
+		"session": plugin.GetSession(name).Status(),
+	})
+}
+
+// LoginSessionHandler 立即执行插件的登录流程，用于检查登录配置
+func LoginSessionHandler(c *gin.Context) {
+	name := c.Param("name")
+	session := plugin.GetSession(name)
+	if session == nil {
+		c.JSON(404, gin.H{
+			"error": "插件 " + name + " 未配置会话",
+			"code":  "SESSION_NOT_FOUND",
+		})
+		return
+	}
+	if err := session.Login(c.Request.Context()); err != nil {
+		c.JSON(502, gin.H{
+			"error":   "登录失败: " + err.Error(),
+			"code":    "SESSION_LOGIN_FAILED",
+			"session": session.Status(),
+		})
+		return
+	}
+	c.JSON(200, gin.H{
+		"session": session.Status(),
+	})
+}
+
+// ListMirrorsHandler 列出插件镜像集合的状态（尝试顺序、健康状态、延迟与成功失败次数）
+func ListMirrorsHandler(c *gin.Context) {
+	mirrorSets := plugin.ListMirrorSets()
+	c.JSON(200, gin.H{
+		"mirror_sets": mirrorSets,
+		"total":       len(mirrorSets),
+	})
+}
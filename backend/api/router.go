@@ -58,12 +58,28 @@ func SetupRouter(searchService *service.SearchService, apiKeyService *service.AP
 			admin.POST("/keys/batch-create", BatchCreateAPIKeysHandler(apiKeyService)) // 新增：批量创建
 			admin.POST("/keys/batch-delete", BatchDeleteAPIKeysHandler(apiKeyService)) // 新增：批量删除
 			admin.GET("/system-info", GetSystemInfoHandler(searchService)) // 更新：获取系统信息（包含插件状态）
+			admin.GET("/tg-mirrors", GetTGMirrorsHandler)                   // TG预览镜像健康状态
+			admin.GET("/outbound-hosts", GetOutboundHostsHandler)           // 出站请求按主机限流统计
+			admin.GET("/proxies", GetProxiesHandler)                        // 代理池健康状态
+			admin.GET("/plugins", ListPluginsHandler(searchService))        // 插件运行时状态
+			admin.PATCH("/plugins/:name", UpdatePluginHandler(searchService)) // 启用/禁用插件、覆盖优先级
+			admin.GET("/plugins/:name/stats", GetPluginStatsHandler(searchService)) // 插件调用统计
+			admin.GET("/sessions", ListSessionsHandler)                     // 插件会话状态
+			admin.DELETE("/sessions/:name", ClearSessionHandler)            // 清除插件会话
+			admin.POST("/sessions/:name/login", LoginSessionHandler)        // 立即登录
+			admin.GET("/mirrors", ListMirrorsHandler)                       // 插件镜像健康状态
 		}
 		
 		// 搜索接口 - 支持POST和GET两种方式
 		api.POST("/search", SearchHandler)
 		api.GET("/search", SearchHandler) // 添加GET方式支持
 		
+		// 插件列表及能力声明
+		api.GET("/plugins", PluginsHandler)
+		
+		// Telegram Bot Webhook（通过Secret Token校验，无需登录认证）
+		api.POST("/tg/webhook", TelegramWebhookHandler)
+		
 		// 健康检查接口
 		api.GET("/health", func(c *gin.Context) {
 			// 根据配置决定是否返回插件信息
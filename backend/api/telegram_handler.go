@@ -0,0 +1,47 @@
+package api
+
+import (
+	"crypto/subtle"
+
+	"github.com/gin-gonic/gin"
+	"pansou/config"
+	"pansou/model"
+	"pansou/service"
+)
+
+// TelegramWebhookHandler 接收Telegram Bot推送的频道消息并实时收录到本地索引
+// 通过X-Telegram-Bot-Api-Secret-Token请求头校验来源（setWebhook时的secret_token）
+func TelegramWebhookHandler(c *gin.Context) {
+	secret := config.AppConfig.TGBotWebhookSecret
+	if secret == "" {
+		c.JSON(404, gin.H{
+			"error": "Telegram Webhook未启用",
+			"code":  "WEBHOOK_DISABLED",
+		})
+		return
+	}
+
+	token := c.GetHeader("X-Telegram-Bot-Api-Secret-Token")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+		c.JSON(401, gin.H{
+			"error": "Webhook密钥无效",
+			"code":  "WEBHOOK_UNAUTHORIZED",
+		})
+		return
+	}
+
+	var update model.TelegramUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(400, gin.H{
+			"error": "无效的更新数据: " + err.Error(),
+			"code":  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	// 本地索引未启用等无法收录的更新也返回200，避免Telegram反复重试
+	c.JSON(200, gin.H{
+		"ok":     true,
+		"result": service.IngestTelegramUpdate(&update),
+	})
+}
@@ -57,6 +57,51 @@ type Config struct {
 	APIKeyDefaultTTL   time.Duration // API Key 默认有效期
 	APIKeyStorePath    string        // API Key 存储路径
 	AdminPasswordHash  string        // 管理员密码哈希（bcrypt）
+	// TG预览镜像相关配置
+	TGMirrors        []string      // 预览镜像列表，格式：地址 或 地址|代理
+	TGMirrorCooldown time.Duration // 镜像失败后的冷却时间
+	ChannelRulesPath string        // 频道解析规则文件路径（空表示不启用）
+	// Telegram Bot Webhook相关配置
+	TGBotWebhookSecret string   // Webhook密钥（空表示不启用）
+	TGBotChannels      []string // 允许收录的频道用户名（空表示不限制）
+	// 全文索引相关配置
+	IndexEnabled      bool          // 是否启用本地全文索引
+	IndexPath         string        // 索引存储目录
+	IndexMaxDocs      int           // 索引最大文档数
+	IndexSaveInterval time.Duration // 索引定期保存间隔
+	// 插件扩展相关配置
+	ScraperSitesDir     string // 声明式抓取站点定义目录（空表示不启用）
+	MacCMSEndpointsPath string // MacCMS采集接口配置文件路径（空表示不启用）
+	ExternalPluginsPath string // 外部插件配置文件路径（空表示不启用）
+	RemotePeersPath     string // 远程实例配置文件路径（空表示不启用联邦搜索）
+	PluginStatePath     string // 插件运行时状态（启用/禁用、优先级覆盖）存储路径
+	PluginConfigPath    string // 插件配置文件路径（站点地址、代理、请求头、超时等，空表示不启用）
+	PluginSessionDir    string // 插件会话（Cookie、登录状态）持久化目录，空表示只保存在内存中
+	// 插件熔断相关配置
+	CircuitBreakerEnabled          bool          // 是否启用插件熔断
+	CircuitBreakerFailureThreshold int           // 连续失败多少次后熔断
+	CircuitBreakerOpenDuration     time.Duration // 熔断后多久允许探测请求
+	CircuitBreakerHalfOpenSuccess  int           // 半开状态下连续成功多少次后恢复
+	// 插件panic相关配置
+	PluginPanicDisableThreshold int           // 统计窗口内panic多少次后自动禁用插件（0表示不自动禁用）
+	PluginPanicWindow           time.Duration // panic次数统计窗口
+	// 出站请求限流相关配置
+	HostRateLimits []string // 按主机限流规则，格式：主机=每秒请求数:突发数:最大并发（空表示不限流）
+	// 代理池相关配置
+	ProxyPoolPath string // 代理池配置文件路径（空表示不启用代理池，使用PROXY）
+	// 自定义DNS解析相关配置
+	DNSResolvers []string      // 上游DNS解析器（DoH地址或UDP解析器IP，空表示使用系统解析）
+	DNSHosts     []string      // 静态解析，格式：主机=IP
+	DNSCacheTTL  time.Duration // 解析结果缓存时间
+	// 反爬验证页检测相关配置
+	BlockDetectionEnabled bool // 是否在出站请求中检测验证页/拦截页并返回blocked错误
+	// 插件请求重试相关配置（插件未自定义时的默认重试策略）
+	RetryMaxAttempts   int           // 最多尝试次数（含首次请求）
+	RetryBackoff       time.Duration // 首次重试前的等待时间，之后按指数增长
+	RetryMaxBackoff    time.Duration // 单次等待时间上限
+	RetryJitter        float64       // 等待时间随机抖动比例（0~1）
+	RetryStatus        []int         // 需要重试的HTTP状态码
+	RetryMaxRetryAfter time.Duration // 遵循Retry-After响应头的最长等待时间，超过时不再重试
 }
 
 // 全局配置实例
@@ -114,6 +159,51 @@ func Init() {
 		APIKeyDefaultTTL:  getAPIKeyDefaultTTL(),
 		APIKeyStorePath:   getAPIKeyStorePath(),
 		AdminPasswordHash: getAdminPasswordHash(),
+		// TG预览镜像相关配置
+		TGMirrors:        getTGMirrors(),
+		TGMirrorCooldown: getTGMirrorCooldown(),
+		ChannelRulesPath: getChannelRulesPath(),
+		// Telegram Bot Webhook相关配置
+		TGBotWebhookSecret: getTGBotWebhookSecret(),
+		TGBotChannels:      getTGBotChannels(),
+		// 全文索引相关配置
+		IndexEnabled:      getIndexEnabled(),
+		IndexPath:         getIndexPath(),
+		IndexMaxDocs:      getIndexMaxDocs(),
+		IndexSaveInterval: getIndexSaveInterval(),
+		// 插件扩展相关配置
+		ScraperSitesDir:     getScraperSitesDir(),
+		MacCMSEndpointsPath: getMacCMSEndpointsPath(),
+		ExternalPluginsPath: getExternalPluginsPath(),
+		RemotePeersPath:     getRemotePeersPath(),
+		PluginStatePath:     getPluginStatePath(),
+		PluginConfigPath:    getPluginConfigPath(),
+		PluginSessionDir:    getPluginSessionDir(),
+		// 插件熔断相关配置
+		CircuitBreakerEnabled:          getCircuitBreakerEnabled(),
+		CircuitBreakerFailureThreshold: getCircuitBreakerFailureThreshold(),
+		CircuitBreakerOpenDuration:     getCircuitBreakerOpenDuration(),
+		CircuitBreakerHalfOpenSuccess:  getCircuitBreakerHalfOpenSuccess(),
+		// 插件panic相关配置
+		PluginPanicDisableThreshold: getPluginPanicDisableThreshold(),
+		PluginPanicWindow:           getPluginPanicWindow(),
+		// 出站请求限流相关配置
+		HostRateLimits: getHostRateLimits(),
+		// 代理池相关配置
+		ProxyPoolPath: getProxyPoolPath(),
+		// 自定义DNS解析相关配置
+		DNSResolvers: getDNSResolvers(),
+		DNSHosts:     getDNSHosts(),
+		DNSCacheTTL:  getDNSCacheTTL(),
+		// 反爬验证页检测相关配置
+		BlockDetectionEnabled: getBlockDetectionEnabled(),
+		// 插件请求重试相关配置
+		RetryMaxAttempts:   getRetryMaxAttempts(),
+		RetryBackoff:       getRetryBackoff(),
+		RetryMaxBackoff:    getRetryMaxBackoff(),
+		RetryJitter:        getRetryJitter(),
+		RetryStatus:        getRetryStatus(),
+		RetryMaxRetryAfter: getRetryMaxRetryAfter(),
 	}
 	
 	// 应用GC配置
@@ -637,6 +727,364 @@ func getAdminPasswordHash() string {
 	return hash
 }
 
+// 从环境变量获取TG预览镜像列表，格式：url1|proxy1,url2，如果未设置则只使用官方地址
+func getTGMirrors() []string {
+	mirrorsEnv := os.Getenv("TG_MIRRORS")
+	if mirrorsEnv == "" {
+		return []string{"https://t.me/s/"}
+	}
+
+	var mirrors []string
+	for _, mirror := range strings.Split(mirrorsEnv, ",") {
+		mirror = strings.TrimSpace(mirror)
+		if mirror != "" {
+			mirrors = append(mirrors, mirror)
+		}
+	}
+	if len(mirrors) == 0 {
+		return []string{"https://t.me/s/"}
+	}
+	return mirrors
+}
+
+// 从环境变量获取TG镜像失败冷却时间（秒），如果未设置则使用默认值
+func getTGMirrorCooldown() time.Duration {
+	cooldownEnv := os.Getenv("TG_MIRROR_COOLDOWN")
+	if cooldownEnv == "" {
+		return 60 * time.Second // 默认60秒
+	}
+	cooldown, err := strconv.Atoi(cooldownEnv)
+	if err != nil || cooldown <= 0 {
+		return 60 * time.Second
+	}
+	return time.Duration(cooldown) * time.Second
+}
+
+// 从环境变量获取频道解析规则文件路径，如果未设置则不启用频道规则
+func getChannelRulesPath() string {
+	return os.Getenv("CHANNEL_RULES_PATH")
+}
+
+// 从环境变量获取Bot Webhook密钥，如果未设置则不启用Webhook收录
+func getTGBotWebhookSecret() string {
+	return os.Getenv("TG_BOT_WEBHOOK_SECRET")
+}
+
+// 从环境变量获取允许Bot收录的频道列表，如果未设置则不限制
+func getTGBotChannels() []string {
+	channelsEnv := os.Getenv("TG_BOT_CHANNELS")
+	if channelsEnv == "" {
+		return nil
+	}
+
+	var channels []string
+	for _, channel := range strings.Split(channelsEnv, ",") {
+		channel = strings.TrimPrefix(strings.TrimSpace(channel), "@")
+		if channel != "" {
+			channels = append(channels, channel)
+		}
+	}
+	return channels
+}
+
+// 从环境变量获取是否启用全文索引，如果未设置则默认关闭
+func getIndexEnabled() bool {
+	enabled := os.Getenv("INDEX_ENABLED")
+	return enabled == "true" || enabled == "1"
+}
+
+// 从环境变量获取索引存储目录，如果未设置则使用默认路径
+func getIndexPath() string {
+	path := os.Getenv("INDEX_PATH")
+	if path == "" {
+		// 默认在当前目录下创建index文件夹
+		defaultPath, err := filepath.Abs("./index")
+		if err != nil {
+			return "./index"
+		}
+		return defaultPath
+	}
+	return path
+}
+
+// 从环境变量获取索引最大文档数，如果未设置则使用默认值
+func getIndexMaxDocs() int {
+	sizeEnv := os.Getenv("INDEX_MAX_DOCS")
+	if sizeEnv == "" {
+		return 200000 // 默认20万条
+	}
+	size, err := strconv.Atoi(sizeEnv)
+	if err != nil || size <= 0 {
+		return 200000
+	}
+	return size
+}
+
+// 从环境变量获取索引保存间隔（分钟），如果未设置则使用默认值
+func getIndexSaveInterval() time.Duration {
+	intervalEnv := os.Getenv("INDEX_SAVE_INTERVAL")
+	if intervalEnv == "" {
+		return 5 * time.Minute // 默认5分钟
+	}
+	interval, err := strconv.Atoi(intervalEnv)
+	if err != nil || interval <= 0 {
+		return 5 * time.Minute
+	}
+	return time.Duration(interval) * time.Minute
+}
+
+// 从环境变量获取声明式抓取站点定义目录，如果未设置则不启用
+func getScraperSitesDir() string {
+	return os.Getenv("SCRAPER_SITES_DIR")
+}
+
+// 从环境变量获取MacCMS采集接口配置文件路径，如果未设置则不启用
+func getMacCMSEndpointsPath() string {
+	return os.Getenv("MACCMS_ENDPOINTS_PATH")
+}
+
+// 从环境变量获取外部插件配置文件路径，如果未设置则不启用
+func getExternalPluginsPath() string {
+	return os.Getenv("EXTERNAL_PLUGINS_PATH")
+}
+
+// 从环境变量获取远程实例配置文件路径，如果未设置则不启用联邦搜索
+func getRemotePeersPath() string {
+	return os.Getenv("REMOTE_PEERS_PATH")
+}
+
+// 从环境变量获取插件配置文件路径，如果未设置则不启用
+func getPluginConfigPath() string {
+	return os.Getenv("PLUGIN_CONFIG_PATH")
+}
+
+// 从环境变量获取插件会话持久化目录，如果未设置则会话只保存在内存中
+func getPluginSessionDir() string {
+	return os.Getenv("PLUGIN_SESSION_DIR")
+}
+
+// 从环境变量获取插件运行时状态存储路径，如果未设置则使用默认路径
+func getPluginStatePath() string {
+	path := os.Getenv("PLUGIN_STATE_PATH")
+	if path == "" {
+		// 默认在当前目录下创建 plugin_state.json 文件
+		defaultPath, err := filepath.Abs("./plugin_state.json")
+		if err != nil {
+			return "./plugin_state.json"
+		}
+		return defaultPath
+	}
+	return path
+}
+
+// 从环境变量获取是否启用插件熔断，默认不启用
+func getCircuitBreakerEnabled() bool {
+	enabled := os.Getenv("CIRCUIT_BREAKER_ENABLED")
+	return enabled == "true" || enabled == "1"
+}
+
+// 从环境变量获取熔断的连续失败次数阈值，如果未设置则使用默认值5
+func getCircuitBreakerFailureThreshold() int {
+	thresholdEnv := os.Getenv("CIRCUIT_BREAKER_FAILURE_THRESHOLD")
+	if thresholdEnv == "" {
+		return 5
+	}
+	threshold, err := strconv.Atoi(thresholdEnv)
+	if err != nil || threshold <= 0 {
+		return 5
+	}
+	return threshold
+}
+
+// 从环境变量获取熔断持续时间（秒），如果未设置则使用默认值60秒
+func getCircuitBreakerOpenDuration() time.Duration {
+	durationEnv := os.Getenv("CIRCUIT_BREAKER_OPEN_DURATION")
+	if durationEnv == "" {
+		return 60 * time.Second
+	}
+	duration, err := strconv.Atoi(durationEnv)
+	if err != nil || duration <= 0 {
+		return 60 * time.Second
+	}
+	return time.Duration(duration) * time.Second
+}
+
+// 从环境变量获取半开状态恢复所需的连续成功次数，如果未设置则使用默认值1
+func getCircuitBreakerHalfOpenSuccess() int {
+	successEnv := os.Getenv("CIRCUIT_BREAKER_HALF_OPEN_SUCCESS")
+	if successEnv == "" {
+		return 1
+	}
+	success, err := strconv.Atoi(successEnv)
+	if err != nil || success <= 0 {
+		return 1
+	}
+	return success
+}
+
+// 从环境变量获取自动禁用插件的panic次数阈值，如果未设置则不自动禁用
+func getPluginPanicDisableThreshold() int {
+	thresholdEnv := os.Getenv("PLUGIN_PANIC_DISABLE_THRESHOLD")
+	if thresholdEnv == "" {
+		return 0
+	}
+	threshold, err := strconv.Atoi(thresholdEnv)
+	if err != nil || threshold < 0 {
+		return 0
+	}
+	return threshold
+}
+
+// 从环境变量获取panic次数统计窗口（秒），如果未设置则使用默认值600秒
+func getPluginPanicWindow() time.Duration {
+	windowEnv := os.Getenv("PLUGIN_PANIC_WINDOW")
+	if windowEnv == "" {
+		return 600 * time.Second
+	}
+	window, err := strconv.Atoi(windowEnv)
+	if err != nil || window <= 0 {
+		return 600 * time.Second
+	}
+	return time.Duration(window) * time.Second
+}
+
+// 从环境变量获取按主机限流规则，多条规则用逗号分隔，如果未设置则不限流
+func getHostRateLimits() []string {
+	limitsEnv := os.Getenv("HOST_RATE_LIMITS")
+	if limitsEnv == "" {
+		return nil
+	}
+
+	var limits []string
+	for _, limit := range strings.Split(limitsEnv, ",") {
+		limit = strings.TrimSpace(limit)
+		if limit != "" {
+			limits = append(limits, limit)
+		}
+	}
+	return limits
+}
+
+// 从环境变量获取代理池配置文件路径，如果未设置则不启用代理池
+func getProxyPoolPath() string {
+	return os.Getenv("PROXY_POOL_PATH")
+}
+
+// 从环境变量获取逗号分隔的列表，忽略空项
+func getCommaList(name string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(name), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// 从环境变量获取上游DNS解析器列表，如果未设置则使用系统解析
+func getDNSResolvers() []string {
+	return getCommaList("DNS_RESOLVERS")
+}
+
+// 从环境变量获取静态解析列表（主机=IP），如果未设置则不启用
+func getDNSHosts() []string {
+	return getCommaList("DNS_HOSTS")
+}
+
+// 从环境变量获取DNS解析结果缓存时间（秒），如果未设置则默认300秒
+func getDNSCacheTTL() time.Duration {
+	ttlEnv := os.Getenv("DNS_CACHE_TTL")
+	if ttlEnv == "" {
+		return 300 * time.Second
+	}
+	ttl, err := strconv.Atoi(ttlEnv)
+	if err != nil || ttl <= 0 {
+		return 300 * time.Second
+	}
+	return time.Duration(ttl) * time.Second
+}
+
+// 从环境变量获取是否在出站请求中检测验证页，如果未设置则默认开启
+func getBlockDetectionEnabled() bool {
+	enabled := os.Getenv("BLOCK_DETECTION_ENABLED")
+	return enabled != "false" && enabled != "0"
+}
+
+// 从环境变量获取插件请求的最多尝试次数，如果未设置则默认3次
+func getRetryMaxAttempts() int {
+	attemptsEnv := os.Getenv("RETRY_MAX_ATTEMPTS")
+	if attemptsEnv == "" {
+		return 3
+	}
+	attempts, err := strconv.Atoi(attemptsEnv)
+	if err != nil || attempts <= 0 {
+		return 3
+	}
+	return attempts
+}
+
+// 从环境变量获取首次重试前的等待时间（毫秒），如果未设置则默认200毫秒
+func getRetryBackoff() time.Duration {
+	backoffEnv := os.Getenv("RETRY_BACKOFF_MS")
+	if backoffEnv == "" {
+		return 200 * time.Millisecond
+	}
+	millis, err := strconv.Atoi(backoffEnv)
+	if err != nil || millis < 0 {
+		return 200 * time.Millisecond
+	}
+	return time.Duration(millis) * time.Millisecond
+}
+
+// 从环境变量获取重试等待时间上限（毫秒），如果未设置则默认5000毫秒
+func getRetryMaxBackoff() time.Duration {
+	maxBackoffEnv := os.Getenv("RETRY_MAX_BACKOFF_MS")
+	if maxBackoffEnv == "" {
+		return 5000 * time.Millisecond
+	}
+	millis, err := strconv.Atoi(maxBackoffEnv)
+	if err != nil || millis < 0 {
+		return 5000 * time.Millisecond
+	}
+	return time.Duration(millis) * time.Millisecond
+}
+
+// 从环境变量获取重试等待时间的抖动比例，如果未设置则默认0.2
+func getRetryJitter() float64 {
+	jitter, err := strconv.ParseFloat(os.Getenv("RETRY_JITTER"), 64)
+	if err != nil || jitter < 0 || jitter > 1 {
+		return 0.2
+	}
+	return jitter
+}
+
+// 从环境变量获取需要重试的HTTP状态码，如果未设置则默认429与502/503/504等服务端错误
+func getRetryStatus() []int {
+	var statuses []int
+	for _, item := range getCommaList("RETRY_STATUS") {
+		if status, err := strconv.Atoi(item); err == nil && status >= 100 && status <= 599 {
+			statuses = append(statuses, status)
+		}
+	}
+	if len(statuses) == 0 {
+		return []int{429, 500, 502, 503, 504}
+	}
+	return statuses
+}
+
+// 从环境变量获取遵循Retry-After的最长等待时间，如果未设置则默认10秒
+func getRetryMaxRetryAfter() time.Duration {
+	secondsEnv := os.Getenv("RETRY_MAX_RETRY_AFTER")
+	if secondsEnv == "" {
+		return 10 * time.Second
+	}
+	seconds, err := strconv.Atoi(secondsEnv)
+	if err != nil || seconds < 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(seconds) * time.Second
+}
+
 // 应用GC设置
 func applyGCSettings() {
 	// 设置GC百分比
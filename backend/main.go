@@ -19,9 +19,14 @@ import (
 	"pansou/api"
 	"pansou/config"
 	"pansou/plugin"
+	"pansou/plugin/external"
+	"pansou/plugin/maccms"
+	"pansou/plugin/remote"
+	"pansou/plugin/scraper"
 	"pansou/service"
 	"pansou/util"
 	"pansou/util/cache"
+	"pansou/util/index"
 
 	// 以下是插件的空导入，用于触发各插件的init函数，实现自动注册
 	// 添加新插件时，只需在此处添加对应的导入语句即可
@@ -38,11 +43,7 @@ import (
 	_ "pansou/plugin/xuexizhinan"
 	_ "pansou/plugin/panyq"
 	_ "pansou/plugin/zhizhen"
-	_ "pansou/plugin/labi"
-	_ "pansou/plugin/muou"
 	_ "pansou/plugin/ouge"
-	_ "pansou/plugin/shandian"
-	_ "pansou/plugin/duoduo"
 	_ "pansou/plugin/huban"
 	_ "pansou/plugin/fox4k"
 	_ "pansou/plugin/cyg"
@@ -74,6 +75,11 @@ func initApp() {
 	// 初始化HTTP客户端
 	util.InitHTTPClient()
 
+	// 加载频道解析规则
+	if err := util.LoadChannelRules(config.AppConfig.ChannelRulesPath); err != nil {
+		log.Printf("警告: 频道解析规则加载失败: %v", err)
+	}
+
 	// 🔥 初始化缓存写入管理器
 	var err error
 	globalCacheWriteManager, err = cache.NewDelayedBatchWriteManager()
@@ -99,6 +105,49 @@ func initApp() {
 
 	// 确保异步插件系统初始化
 	plugin.InitAsyncPluginSystem()
+
+	// 加载声明式抓取站点（需在插件管理器注册全局插件之前完成）
+	if config.AppConfig.ScraperSitesDir != "" {
+		if count, err := scraper.LoadSites(config.AppConfig.ScraperSitesDir); err != nil {
+			log.Printf("警告: 抓取站点定义加载失败: %v", err)
+		} else {
+			log.Printf("已加载 %d 个声明式抓取站点", count)
+		}
+	}
+
+	// 加载MacCMS采集接口
+	if config.AppConfig.MacCMSEndpointsPath != "" {
+		if count, err := maccms.LoadEndpoints(config.AppConfig.MacCMSEndpointsPath); err != nil {
+			log.Printf("警告: MacCMS采集接口配置加载失败: %v", err)
+		} else {
+			log.Printf("已加载 %d 个MacCMS采集接口", count)
+		}
+	}
+
+	// 加载外部插件（独立进程或HTTP服务）
+	if config.AppConfig.ExternalPluginsPath != "" {
+		if count, err := external.LoadPlugins(config.AppConfig.ExternalPluginsPath); err != nil {
+			log.Printf("警告: 外部插件配置加载失败: %v", err)
+		} else {
+			log.Printf("已加载 %d 个外部插件", count)
+		}
+	}
+
+	// 加载远程实例（联邦搜索）
+	if config.AppConfig.RemotePeersPath != "" {
+		if count, err := remote.LoadPeers(config.AppConfig.RemotePeersPath); err != nil {
+			log.Printf("警告: 远程实例配置加载失败: %v", err)
+		} else {
+			log.Printf("已加载 %d 个远程实例", count)
+		}
+	}
+
+	// 初始化本地全文索引
+	if config.AppConfig.IndexEnabled {
+		if _, err := index.Init(config.AppConfig.IndexPath, config.AppConfig.IndexMaxDocs, config.AppConfig.IndexSaveInterval); err != nil {
+			log.Printf("警告: 本地索引初始化失败: %v", err)
+		}
+	}
 }
 
 // startServer 启动Web服务器
@@ -109,6 +158,16 @@ func startServer() {
 	// 注册所有全局插件（通过init函数自动注册到全局注册表）
 	pluginManager.RegisterAllGlobalPlugins()
 
+	// 加载插件配置（站点地址、代理、请求头、超时等）
+	if err := plugin.LoadPluginConfigs(config.AppConfig.PluginConfigPath); err != nil {
+		log.Printf("警告: 插件配置加载失败: %v", err)
+	}
+
+	// 加载插件运行时状态（管理后台设置的启用/禁用与优先级覆盖）
+	if err := plugin.LoadPluginStates(config.AppConfig.PluginStatePath); err != nil {
+		log.Printf("警告: 插件状态加载失败: %v", err)
+	}
+
 	// 更新默认并发数（使用实际插件数）
 	config.UpdateDefaultConcurrency(len(pluginManager.GetPlugins()))
 
@@ -203,6 +262,17 @@ func startServer() {
 		}
 	}
 
+	// 保存本地全文索引
+	if index.Default() != nil {
+		fmt.Println("💾 正在保存本地索引...")
+		if err := index.Close(); err != nil {
+			log.Printf("❌ 本地索引保存失败: %v", err)
+		}
+	}
+
+	// 结束外部插件进程
+	external.Shutdown()
+
 	// 设置关闭超时时间
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
@@ -221,7 +291,9 @@ func printServiceInfo(port string, pluginManager *plugin.PluginManager) {
 	fmt.Printf("服务器启动在 http://localhost:%s\n", port)
 
 	// 输出代理信息
-	if config.AppConfig.UseProxy {
+	if pool := util.GetProxyPool(); pool != nil {
+		fmt.Printf("使用代理池: %d 个代理\n", len(pool.Stats()))
+	} else if config.AppConfig.UseProxy {
 		fmt.Printf("使用SOCKS5代理: %s\n", config.AppConfig.ProxyURL)
 	} else {
 		fmt.Println("未使用代理")
@@ -250,6 +322,16 @@ func printServiceInfo(port string, pluginManager *plugin.PluginManager) {
 		fmt.Println("缓存已禁用")
 	}
 
+	// 输出索引信息
+	if idx := index.Default(); idx != nil {
+		fmt.Printf("本地索引已启用: 路径=%s, 文档数=%d, 最大文档数=%d\n",
+			config.AppConfig.IndexPath,
+			idx.Len(),
+			config.AppConfig.IndexMaxDocs)
+	} else {
+		fmt.Println("本地索引已禁用")
+	}
+
 	// 输出压缩信息
 	if config.AppConfig.EnableCompression {
 		fmt.Printf("响应压缩已启用: 最小压缩大小=%d字节\n",
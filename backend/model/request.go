@@ -2,8 +2,11 @@ package model
 
 // FilterConfig 过滤配置
 type FilterConfig struct {
-	Include []string `json:"include,omitempty"` // 包含关键词列表（OR关系）
-	Exclude []string `json:"exclude,omitempty"` // 排除关键词列表（AND关系）
+	Include  []string `json:"include,omitempty"`   // 包含关键词列表（OR关系）
+	Exclude  []string `json:"exclude,omitempty"`   // 排除关键词列表（AND关系）
+	MinViews int      `json:"min_views,omitempty"` // 最小浏览次数（仅对有浏览数据的TG结果生效）
+	MinSize  string   `json:"min_size,omitempty"`  // 最小文件大小，如"500MB"（仅对有文件附件的TG结果生效）
+	MaxSize  string   `json:"max_size,omitempty"`  // 最大文件大小，如"4GB"（仅对有文件附件的TG结果生效）
 }
 
 // SearchRequest 搜索请求参数
@@ -13,9 +16,11 @@ type SearchRequest struct {
 	Concurrency  int                    `json:"conc"`                        // 并发搜索数量
 	ForceRefresh bool                   `json:"refresh"`                     // 强制刷新，不使用缓存
 	ResultType   string                 `json:"res"`                         // 结果类型：all(返回所有结果)、results(仅返回results)、merge(仅返回merged_by_type)
-	SourceType   string                 `json:"src"`                         // 数据来源类型：all(默认，全部来源)、tg(仅Telegram)、plugin(仅插件)
+	SourceType   string                 `json:"src"`                         // 数据来源类型：all(默认，全部来源)、tg(仅Telegram)、plugin(仅插件)、index(仅本地索引)
 	Plugins      []string               `json:"plugins"`                     // 指定搜索的插件列表，不指定则搜索全部插件
 	Ext          map[string]interface{} `json:"ext"`                         // 扩展参数，用于传递给插件的自定义参数
 	CloudTypes   []string               `json:"cloud_types"`                 // 指定返回的网盘类型列表，不指定则返回所有类型
 	Filter       *FilterConfig          `json:"filter,omitempty"`            // 过滤配置，用于过滤返回结果
+	Depth        string                 `json:"depth,omitempty"`             // 插件搜索深度：quick、normal（默认）、deep
+	MaxPages     int                    `json:"max_pages,omitempty"`         // 插件最大抓取页数，优先于depth
 } 
\ No newline at end of file
@@ -22,6 +22,37 @@ type SearchResult struct {
 	Links     []Link    `json:"links" sonic:"links"`
 	Tags      []string  `json:"tags,omitempty" sonic:"tags,omitempty"`
 	Images    []string  `json:"images,omitempty" sonic:"images,omitempty"` // TG消息中的图片链接
+	// 以下字段仅TG消息可用
+	Views         int        `json:"views,omitempty" sonic:"views,omitempty"`                   // 浏览次数
+	ForwardedFrom string     `json:"forwarded_from,omitempty" sonic:"forwarded_from,omitempty"` // 转发来源
+	Files         []FileInfo `json:"files,omitempty" sonic:"files,omitempty"`                   // 文件附件
+	ReplyTo       *ReplyInfo `json:"reply_to,omitempty" sonic:"reply_to,omitempty"`             // 回复的原消息预览
+	Edited        bool       `json:"edited,omitempty" sonic:"edited,omitempty"`                 // 是否被编辑过
+}
+
+// FileInfo TG消息中的文件附件
+type FileInfo struct {
+	Name     string `json:"name" sonic:"name"`
+	Size     int64  `json:"size,omitempty" sonic:"size,omitempty"`           // 文件大小（字节）
+	SizeText string `json:"size_text,omitempty" sonic:"size_text,omitempty"` // 页面上的原始大小文本，如"1.5 GB"
+}
+
+// ReplyInfo TG消息回复的原消息预览
+type ReplyInfo struct {
+	MessageID string `json:"message_id,omitempty" sonic:"message_id,omitempty"`
+	Author    string `json:"author,omitempty" sonic:"author,omitempty"`
+	Text      string `json:"text,omitempty" sonic:"text,omitempty"`
+}
+
+// MaxFileSize 返回消息中最大文件附件的大小（字节），没有附件时返回0
+func (r SearchResult) MaxFileSize() int64 {
+	var maxSize int64
+	for _, f := range r.Files {
+		if f.Size > maxSize {
+			maxSize = f.Size
+		}
+	}
+	return maxSize
 }
 
 // MergedLink 合并后的网盘链接
@@ -30,8 +61,10 @@ type MergedLink struct {
 	Password string    `json:"password" sonic:"password"`
 	Note     string    `json:"note" sonic:"note"`
 	Datetime time.Time `json:"datetime" sonic:"datetime"`
-	Source   string    `json:"source,omitempty" sonic:"source,omitempty"` // 数据来源：tg:频道名 或 plugin:插件名
+	Source   string    `json:"source,omitempty" sonic:"source,omitempty"` // 数据来源：tg:频道名、plugin:插件名 或 remote:实例名
 	Images   []string  `json:"images,omitempty" sonic:"images,omitempty"`   // TG消息中的图片链接
+	Views    int       `json:"views,omitempty" sonic:"views,omitempty"`     // 来源消息浏览次数
+	Size     int64     `json:"size,omitempty" sonic:"size,omitempty"`       // 来源消息最大文件附件大小（字节）
 }
 
 // MergedLinks 按网盘类型分组的合并链接
@@ -39,9 +72,29 @@ type MergedLinks map[string][]MergedLink
 
 // SearchResponse 搜索响应
 type SearchResponse struct {
-	Total        int           `json:"total" sonic:"total"`
+	Total        int            `json:"total" sonic:"total"`
 	Results      []SearchResult `json:"results,omitempty" sonic:"results,omitempty"`
-	MergedByType MergedLinks   `json:"merged_by_type,omitempty" sonic:"merged_by_type,omitempty"`
+	MergedByType MergedLinks    `json:"merged_by_type,omitempty" sonic:"merged_by_type,omitempty"`
+	Sources      []SourceStatus `json:"sources,omitempty" sonic:"sources,omitempty"` // 本次搜索各插件的执行情况（命中缓存时为空）
+}
+
+// 插件来源在本次搜索中的执行状态
+const (
+	SourceStatusOK      = "ok"      // 返回了结果
+	SourceStatusEmpty   = "empty"   // 成功但没有结果
+	SourceStatusError   = "error"   // 调用失败
+	SourceStatusTimeout = "timeout" // 超时
+	SourceStatusSkipped = "skipped" // 熔断中或不支持请求的网盘类型，未调用
+)
+
+// SourceStatus 单个插件来源在本次搜索中的执行情况
+type SourceStatus struct {
+	Name      string `json:"name" sonic:"name"`
+	Status    string `json:"status" sonic:"status"`
+	Results   int    `json:"results" sonic:"results"`
+	LatencyMs int64  `json:"latency_ms" sonic:"latency_ms"`
+	Breaker   string `json:"breaker,omitempty" sonic:"breaker,omitempty"` // 熔断器状态：closed、open、half_open（未启用熔断时为空）
+	Error     string `json:"error,omitempty" sonic:"error,omitempty"`
 }
 
 // Response API通用响应
@@ -0,0 +1,53 @@
+package model
+
+// TelegramUpdate Bot API推送的更新（只保留频道消息相关字段）
+type TelegramUpdate struct {
+	UpdateID          int64            `json:"update_id"`
+	ChannelPost       *TelegramMessage `json:"channel_post,omitempty"`
+	EditedChannelPost *TelegramMessage `json:"edited_channel_post,omitempty"`
+}
+
+// TelegramMessage Bot API消息
+type TelegramMessage struct {
+	MessageID       int64                  `json:"message_id"`
+	Chat            TelegramChat           `json:"chat"`
+	Date            int64                  `json:"date"`
+	EditDate        int64                  `json:"edit_date,omitempty"`
+	Text            string                 `json:"text,omitempty"`
+	Caption         string                 `json:"caption,omitempty"`
+	Entities        []TelegramEntity       `json:"entities,omitempty"`
+	CaptionEntities []TelegramEntity       `json:"caption_entities,omitempty"`
+	Document        *TelegramDocument      `json:"document,omitempty"`
+	ForwardOrigin   *TelegramForwardOrigin `json:"forward_origin,omitempty"`
+	ForwardFromChat *TelegramChat          `json:"forward_from_chat,omitempty"` // 旧版Bot API字段
+	ReplyToMessage  *TelegramMessage       `json:"reply_to_message,omitempty"`
+}
+
+// TelegramChat 消息所在的会话（频道）
+type TelegramChat struct {
+	ID       int64  `json:"id"`
+	Type     string `json:"type"`
+	Title    string `json:"title,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+// TelegramEntity 消息实体，Offset与Length以UTF-16码元计
+type TelegramEntity struct {
+	Type   string `json:"type"`
+	Offset int    `json:"offset"`
+	Length int    `json:"length"`
+	URL    string `json:"url,omitempty"` // 仅text_link类型
+}
+
+// TelegramDocument 文件附件
+type TelegramDocument struct {
+	FileName string `json:"file_name,omitempty"`
+	FileSize int64  `json:"file_size,omitempty"`
+}
+
+// TelegramForwardOrigin 转发来源
+type TelegramForwardOrigin struct {
+	Type           string        `json:"type"`
+	Chat           *TelegramChat `json:"chat,omitempty"`
+	SenderUserName string        `json:"sender_user_name,omitempty"`
+}
@@ -10,6 +10,7 @@ import (
 
 	"pansou/config"
 	"pansou/model"
+	"pansou/util"
 )
 
 // 工作池和统计相关变量
@@ -56,6 +57,7 @@ type cachedResponse struct {
 
 // 🔥 新增：清理过期API缓存的函数
 func cleanupExpiredApiCache() {
+	defer util.RecoverBackground("插件API缓存清理")
 	cleanupMutex.Lock()
 	defer cleanupMutex.Unlock()
 	
@@ -98,6 +100,18 @@ func cleanupExpiredApiCache() {
 	}
 }
 
+// ClearPluginResponseCache 清除插件的响应缓存，使下一次搜索重新请求上游（用于测试回放）
+func ClearPluginResponseCache(pluginName string) {
+	prefix := pluginName + ":"
+	apiResponseCache.Range(func(key, value interface{}) bool {
+		if keyStr, ok := key.(string); ok && strings.HasPrefix(keyStr, prefix) {
+			apiResponseCache.Delete(key)
+			cacheAccessCount.Delete(key)
+		}
+		return true
+	})
+}
+
 // initAsyncPlugin 初始化异步插件配置
 func initAsyncPlugin() {
 	initLock.Lock()
@@ -234,10 +248,12 @@ func NewBaseAsyncPlugin(name string, priority int) *BaseAsyncPlugin {
 		name:     name,
 		priority: priority,
 		client: &http.Client{
-			Timeout: responseTimeout,
+			Transport: util.NewOutboundTransport(nil),
+			Timeout:   responseTimeout,
 		},
 		backgroundClient: &http.Client{
-			Timeout: processingTimeout,
+			Transport: util.NewOutboundTransport(nil),
+			Timeout:   processingTimeout,
 		},
 		cacheTTL:           cacheTTL,
 		finalUpdateTracker: make(map[string]bool), // 初始化缓存更新追踪器
@@ -268,10 +284,12 @@ func NewBaseAsyncPluginWithFilter(name string, priority int, skipServiceFilter b
 		name:     name,
 		priority: priority,
 		client: &http.Client{
-			Timeout: responseTimeout,
+			Transport: util.NewOutboundTransport(nil),
+			Timeout:   responseTimeout,
 		},
 		backgroundClient: &http.Client{
-			Timeout: processingTimeout,
+			Transport: util.NewOutboundTransport(nil),
+			Timeout:   processingTimeout,
 		},
 		cacheTTL:           cacheTTL,
 		finalUpdateTracker: make(map[string]bool), // 初始化缓存更新追踪器
@@ -299,8 +317,16 @@ func (p *BaseAsyncPlugin) Name() string {
 	return p.name
 }
 
-// Priority 返回插件优先级
+// Priority 返回插件优先级（管理后台设置了覆盖值时优先使用覆盖值）
 func (p *BaseAsyncPlugin) Priority() int {
+	if priority, ok := GetPriorityOverride(p.name); ok {
+		return priority
+	}
+	return p.priority
+}
+
+// DefaultPriority 返回插件自身声明的优先级
+func (p *BaseAsyncPlugin) DefaultPriority() int {
 	return p.priority
 }
 
@@ -323,8 +349,8 @@ func (p *BaseAsyncPlugin) AsyncSearch(
 	
 	now := time.Now()
 	
-	// 修改缓存键，确保包含插件名称
-	pluginSpecificCacheKey := fmt.Sprintf("%s:%s", p.name, keyword)
+	// 修改缓存键，确保包含插件名称与搜索选项
+	pluginSpecificCacheKey := p.responseCacheKey(keyword, ext)
 	
 	// 检查缓存
 	if cachedItems, ok := apiResponseCache.Load(pluginSpecificCacheKey); ok {
@@ -371,10 +397,12 @@ func (p *BaseAsyncPlugin) AsyncSearch(
 	
 	// 启动后台处理
 	go func() {
+		defer RecoverPanic(p.name)
+		
 		// 尝试获取工作槽
 		if !acquireWorkerSlot() {
 			// 工作池已满，使用快速响应客户端直接处理
-			results, err := searchFunc(p.client, keyword, ext)
+			results, err := p.safeSearch(searchFunc, p.client, keyword, ext)
 			if err != nil {
 				select {
 				case errorChan <- err:
@@ -405,7 +433,7 @@ func (p *BaseAsyncPlugin) AsyncSearch(
 		defer releaseWorkerSlot()
 		
 		// 执行搜索
-		results, err := searchFunc(p.backgroundClient, keyword, ext)
+		results, err := p.safeSearch(searchFunc, p.backgroundClient, keyword, ext)
 		
 		// 检查是否已经响应
 		select {
@@ -580,8 +608,8 @@ func (p *BaseAsyncPlugin) AsyncSearchWithResult(
 	
 	now := time.Now()
 	
-	// 修改缓存键，确保包含插件名称
-	pluginSpecificCacheKey := fmt.Sprintf("%s:%s", p.name, keyword)
+	// 修改缓存键，确保包含插件名称与搜索选项
+	pluginSpecificCacheKey := p.responseCacheKey(keyword, ext)
 	
 	// 检查缓存
 	if cachedItems, ok := apiResponseCache.Load(pluginSpecificCacheKey); ok {
@@ -636,6 +664,7 @@ func (p *BaseAsyncPlugin) AsyncSearchWithResult(
 	
 	// 启动后台处理
 	go func() {
+		defer RecoverPanic(p.name)
 		defer func() {
 			select {
 			case <-doneChan:
@@ -647,7 +676,7 @@ func (p *BaseAsyncPlugin) AsyncSearchWithResult(
 		// 尝试获取工作槽
 		if !acquireWorkerSlot() {
 			// 工作池已满，使用快速响应客户端直接处理
-			results, err := searchFunc(p.client, keyword, ext)
+			results, err := p.safeSearch(searchFunc, p.client, keyword, ext)
 			if err != nil {
 				select {
 				case errorChan <- err:
@@ -665,7 +694,7 @@ func (p *BaseAsyncPlugin) AsyncSearchWithResult(
 		defer releaseWorkerSlot()
 		
 		// 使用长超时客户端进行搜索
-		results, err := searchFunc(p.backgroundClient, keyword, ext)
+		results, err := p.safeSearch(searchFunc, p.backgroundClient, keyword, ext)
 		if err != nil {
 			select {
 			case errorChan <- err:
@@ -751,6 +780,7 @@ func (p *BaseAsyncPlugin) completeSearchInBackground(
 	doneChan chan struct{},
 	ext map[string]interface{},
 ) {
+	defer RecoverPanic(p.name)
 	defer func() {
 		select {
 		case <-doneChan:
@@ -760,7 +790,7 @@ func (p *BaseAsyncPlugin) completeSearchInBackground(
 	}()
 	
 	// 执行完整搜索
-	results, err := searchFunc(p.backgroundClient, keyword, ext)
+	results, err := p.safeSearch(searchFunc, p.backgroundClient, keyword, ext)
 	if err != nil {
 		return
 	}
@@ -794,6 +824,8 @@ func (p *BaseAsyncPlugin) refreshCacheInBackground(
 	originalCacheKey string,
 	ext map[string]interface{},
 ) {
+	defer RecoverPanic(p.name)
+	
 	// 确保ext不为nil
 	if ext == nil {
 		ext = make(map[string]interface{})
@@ -811,7 +843,7 @@ func (p *BaseAsyncPlugin) refreshCacheInBackground(
 	refreshStart := time.Now()
 	
 	// 执行搜索
-	results, err := searchFunc(p.backgroundClient, keyword, ext)
+	results, err := p.safeSearch(searchFunc, p.backgroundClient, keyword, ext)
 	if err != nil || len(results) == 0 {
 		return
 	}
@@ -853,6 +885,27 @@ func (p *BaseAsyncPlugin) refreshCacheInBackground(
 	// 异步插件本地缓存系统已移除
 } 
 
+// safeSearch 执行搜索函数，插件代码panic时转换为PanicError返回，避免整个进程退出。
+// 所有实际请求（包括响应超时后在后台完成的搜索与缓存刷新）都经过这里，调用统计与熔断器在这里更新，命中插件缓存不计入；
+// 熔断中时不发送请求，直接返回ErrCircuitOpen
+func (p *BaseAsyncPlugin) safeSearch(
+	searchFunc func(*http.Client, string, map[string]interface{}) ([]model.SearchResult, error),
+	client *http.Client,
+	keyword string,
+	ext map[string]interface{},
+) ([]model.SearchResult, error) {
+	if !AllowPluginCall(p.name) {
+		return nil, ErrCircuitOpen
+	}
+	start := time.Now()
+	results, err := SafeCall(p.name, func() ([]model.SearchResult, error) {
+		return searchFunc(client, keyword, ext)
+	})
+	RecordPluginCall(p.name, time.Since(start), len(results), err)
+	ReportPluginCallResult(p.name, err)
+	return results, err
+}
+
 // updateMainCache 更新主缓存系统（兼容性方法，默认IsFinal=true）
 func (p *BaseAsyncPlugin) updateMainCache(cacheKey string, results []model.SearchResult) {
 	p.updateMainCacheWithFinal(cacheKey, results, true)
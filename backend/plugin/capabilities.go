@@ -0,0 +1,66 @@
+package plugin
+
+import "strings"
+
+// 内容分类
+const (
+	CategoryFilm     = "film"     // 影视
+	CategoryAnime    = "anime"    // 动漫
+	CategoryBooks    = "books"    // 书籍
+	CategorySoftware = "software" // 软件
+	CategoryMusic    = "music"    // 音乐
+)
+
+// Capabilities 插件能力声明，用于按请求条件选择插件与向客户端展示
+type Capabilities struct {
+	CloudTypes       []string `json:"cloud_types,omitempty" yaml:"cloud_types,omitempty"`               // 可能返回的链接类型（如 quark、baidu、magnet），为空表示未声明
+	Categories       []string `json:"categories,omitempty" yaml:"categories,omitempty"`                 // 内容分类：film、anime、books、software、music
+	Languages        []string `json:"languages,omitempty" yaml:"languages,omitempty"`                   // 内容语言，如 zh、en
+	Magnet           bool     `json:"magnet" yaml:"magnet"`                                             // 是否返回磁力/电驴链接
+	TypicalLatencyMs int      `json:"typical_latency_ms,omitempty" yaml:"typical_latency_ms,omitempty"` // 典型响应耗时（毫秒）
+}
+
+// CapabilityProvider 可选接口：声明了能力的插件
+type CapabilityProvider interface {
+	Capabilities() Capabilities
+}
+
+// GetCapabilities 获取插件声明的能力，未实现CapabilityProvider或未声明任何能力时返回false
+func GetCapabilities(p AsyncSearchPlugin) (Capabilities, bool) {
+	if provider, ok := p.(CapabilityProvider); ok {
+		caps := provider.Capabilities()
+		return caps, !caps.isZero()
+	}
+	return Capabilities{}, false
+}
+
+// isZero 是否未声明任何能力
+func (c Capabilities) isZero() bool {
+	return len(c.CloudTypes) == 0 && len(c.Categories) == 0 && len(c.Languages) == 0 && !c.Magnet && c.TypicalLatencyMs == 0
+}
+
+// SupportsAnyCloudType 判断插件是否可能返回指定类型中的任意一种。
+// 未声明链接类型时视为可以返回任意类型；Magnet为true时视为支持magnet与ed2k
+func (c Capabilities) SupportsAnyCloudType(cloudTypes []string) bool {
+	if len(cloudTypes) == 0 || len(c.CloudTypes) == 0 {
+		return true
+	}
+	for _, requested := range cloudTypes {
+		requested = strings.ToLower(strings.TrimSpace(requested))
+		if c.Magnet && (requested == "magnet" || requested == "ed2k") {
+			return true
+		}
+		for _, supported := range c.CloudTypes {
+			if strings.EqualFold(supported, requested) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CanProduceCloudTypes 判断插件是否可能返回指定类型中的任意一种，未声明能力的插件总是返回true
+func CanProduceCloudTypes(p AsyncSearchPlugin, cloudTypes []string) bool {
+	caps, ok := GetCapabilities(p)
+	return !ok || caps.SupportsAnyCloudType(cloudTypes)
+}
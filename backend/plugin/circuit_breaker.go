@@ -0,0 +1,200 @@
+package plugin
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"pansou/config"
+)
+
+// 熔断器状态
+const (
+	BreakerClosed   = "closed"    // 正常调用
+	BreakerOpen     = "open"      // 熔断中，调用直接跳过
+	BreakerHalfOpen = "half_open" // 熔断期已过，允许单个探测请求
+)
+
+// ErrCircuitOpen 插件熔断中，没有发送请求
+var ErrCircuitOpen = errors.New("插件熔断中")
+
+// CircuitBreakerStatus 熔断器状态
+type CircuitBreakerStatus struct {
+	State               string    `json:"state"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	OpenedAt            time.Time `json:"opened_at,omitempty"`
+	NextProbeAt         time.Time `json:"next_probe_at,omitempty"` // 熔断中时下一次允许探测的时间
+	Trips               int64     `json:"trips"`                   // 累计熔断次数
+	Rejected            int64     `json:"rejected"`                // 累计因熔断跳过的调用次数
+}
+
+// circuitBreaker 单个插件的熔断器
+type circuitBreaker struct {
+	mu                  sync.Mutex
+	state               string
+	consecutiveFailures int
+	halfOpenSuccesses   int
+	probeInFlight       bool
+	openedAt            time.Time
+	trips               int64
+	rejected            int64
+}
+
+// 全局熔断器
+var (
+	circuitBreakers     = make(map[string]*circuitBreaker)
+	circuitBreakersLock sync.RWMutex
+)
+
+// IsCircuitBreakerEnabled 是否启用插件熔断
+func IsCircuitBreakerEnabled() bool {
+	return config.AppConfig != nil && config.AppConfig.CircuitBreakerEnabled
+}
+
+// getCircuitBreaker 获取插件熔断器，不存在时创建
+func getCircuitBreaker(name string) *circuitBreaker {
+	circuitBreakersLock.RLock()
+	cb, ok := circuitBreakers[name]
+	circuitBreakersLock.RUnlock()
+	if ok {
+		return cb
+	}
+
+	circuitBreakersLock.Lock()
+	defer circuitBreakersLock.Unlock()
+	if cb, ok = circuitBreakers[name]; !ok {
+		cb = &circuitBreaker{state: BreakerClosed}
+		circuitBreakers[name] = cb
+	}
+	return cb
+}
+
+// AllowPluginCall 检查熔断器是否允许调用插件，未启用熔断时总是允许。
+// 熔断期过后转为半开状态，同一时间只放行一个探测请求
+func AllowPluginCall(name string) bool {
+	if !IsCircuitBreakerEnabled() {
+		return true
+	}
+
+	cb := getCircuitBreaker(name)
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case BreakerOpen:
+		if time.Since(cb.openedAt) < config.AppConfig.CircuitBreakerOpenDuration {
+			cb.rejected++
+			return false
+		}
+		cb.state = BreakerHalfOpen
+		cb.halfOpenSuccesses = 0
+		cb.probeInFlight = true
+		return true
+	case BreakerHalfOpen:
+		if cb.probeInFlight {
+			cb.rejected++
+			return false
+		}
+		cb.probeInFlight = true
+		return true
+	default:
+		return true
+	}
+}
+
+// RejectIfCircuitOpen 插件熔断中且未到探测时间时记一次跳过并返回true。
+// 只做检查，不占用半开状态的探测名额，用于在提交搜索任务前过滤插件
+func RejectIfCircuitOpen(name string) bool {
+	if !IsCircuitBreakerEnabled() {
+		return false
+	}
+
+	cb := getCircuitBreaker(name)
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == BreakerOpen && time.Since(cb.openedAt) < config.AppConfig.CircuitBreakerOpenDuration {
+		cb.rejected++
+		return true
+	}
+	return false
+}
+
+// ReportPluginCallResult 向熔断器报告插件调用结果，err为nil表示成功
+func ReportPluginCallResult(name string, err error) {
+	if !IsCircuitBreakerEnabled() {
+		return
+	}
+
+	cb := getCircuitBreaker(name)
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if err == nil {
+		cb.consecutiveFailures = 0
+		if cb.state == BreakerHalfOpen {
+			cb.probeInFlight = false
+			cb.halfOpenSuccesses++
+			if cb.halfOpenSuccesses >= config.AppConfig.CircuitBreakerHalfOpenSuccess {
+				cb.state = BreakerClosed
+				cb.openedAt = time.Time{}
+			}
+		}
+		return
+	}
+
+	cb.consecutiveFailures++
+	switch cb.state {
+	case BreakerHalfOpen:
+		// 探测失败，重新熔断
+		cb.probeInFlight = false
+		cb.trip()
+	case BreakerClosed:
+		if cb.consecutiveFailures >= config.AppConfig.CircuitBreakerFailureThreshold {
+			cb.trip()
+		}
+	}
+}
+
+// trip 进入熔断状态（调用方持有锁）
+func (cb *circuitBreaker) trip() {
+	cb.state = BreakerOpen
+	cb.openedAt = time.Now()
+	cb.trips++
+}
+
+// GetCircuitBreakerStatus 获取插件熔断器状态，未启用熔断时返回nil
+func GetCircuitBreakerStatus(name string) *CircuitBreakerStatus {
+	if !IsCircuitBreakerEnabled() {
+		return nil
+	}
+
+	cb := getCircuitBreaker(name)
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	status := &CircuitBreakerStatus{
+		State:               cb.state,
+		ConsecutiveFailures: cb.consecutiveFailures,
+		OpenedAt:            cb.openedAt,
+		Trips:               cb.trips,
+		Rejected:            cb.rejected,
+	}
+	if cb.state == BreakerOpen {
+		status.NextProbeAt = cb.openedAt.Add(config.AppConfig.CircuitBreakerOpenDuration)
+	}
+	return status
+}
+
+// ResetCircuitBreaker 手动关闭插件熔断器
+func ResetCircuitBreaker(name string) {
+	cb := getCircuitBreaker(name)
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.state = BreakerClosed
+	cb.consecutiveFailures = 0
+	cb.halfOpenSuccesses = 0
+	cb.probeInFlight = false
+	cb.openedAt = time.Time{}
+}
@@ -40,6 +40,15 @@ var (
 	htmlTagRegex = regexp.MustCompile(`<[^>]*>`)
 )
 
+const (
+	// BaseURL 默认接口地址（可通过插件配置的base_url覆盖）
+	BaseURL = "https://cyg.app"
+	// DefaultReferer 默认Referer（可通过插件配置的headers覆盖）
+	DefaultReferer = "https://h5.acgn.my/"
+	// DefaultTimeout 默认请求超时
+	DefaultTimeout = 30 * time.Second
+)
+
 // CygPlugin CYG插件结构体
 type CygPlugin struct {
 	*plugin.BaseAsyncPlugin
@@ -87,6 +96,16 @@ func init() {
 	plugin.RegisterGlobalPlugin(p)
 }
 
+// ExtSchema 声明支持的ext参数
+func (p *CygPlugin) ExtSchema() []plugin.ExtParam {
+	return []plugin.ExtParam{
+		{Name: "per_page", Type: plugin.ExtTypeInt, Description: "每页结果数", Default: 20},
+		{Name: "page", Type: plugin.ExtTypeInt, Description: "页码", Default: 1},
+		{Name: "order_by", Type: plugin.ExtTypeString, Description: "排序字段，如 date、title", Default: "date"},
+		{Name: "order", Type: plugin.ExtTypeString, Description: "排序方向：asc 或 desc", Default: "desc"},
+	}
+}
+
 // Search 执行搜索并返回结果（兼容性方法）
 func (p *CygPlugin) Search(keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
 	result, err := p.SearchWithResult(keyword, ext)
@@ -107,8 +126,8 @@ func (p *CygPlugin) searchImpl(client *http.Client, keyword string, ext map[stri
 	opts := p.parseExtOptions(ext)
 
 	// 1. 构建搜索URL
-	searchURL := fmt.Sprintf("https://cyg.app/wp-json/wp/v2/posts?per_page=%d&orderby=%s&order=%s&page=%d&search=%s",
-		opts.PerPage, opts.OrderBy, opts.Order, opts.Page, url.QueryEscape(keyword))
+	searchURL := fmt.Sprintf("%s/wp-json/wp/v2/posts?per_page=%d&orderby=%s&order=%s&page=%d&search=%s",
+		p.Config().BaseURLOr(BaseURL), opts.PerPage, opts.OrderBy, opts.Order, opts.Page, url.QueryEscape(keyword))
 
 	// 2. 发送搜索请求
 	posts, err := p.fetchSearchResults(client, searchURL)
@@ -132,7 +151,7 @@ func (p *CygPlugin) searchImpl(client *http.Client, keyword string, ext map[stri
 // fetchSearchResults 获取搜索结果列表
 func (p *CygPlugin) fetchSearchResults(client *http.Client, searchURL string) ([]CygPost, error) {
 	// 创建带超时的上下文
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), p.Config().TimeoutOr(DefaultTimeout))
 	defer cancel()
 
 	// 创建请求对象
@@ -145,7 +164,7 @@ func (p *CygPlugin) fetchSearchResults(client *http.Client, searchURL string) ([
 	p.setRequestHeaders(req)
 
 	// 发送请求
-	resp, err := p.doRequestWithRetry(req, client)
+	resp, err := p.DoWithRetry(req, client)
 	if err != nil {
 		return nil, fmt.Errorf("HTTP请求失败: %w", err)
 	}
@@ -182,6 +201,7 @@ func (p *CygPlugin) fetchDownloadLinksAsync(client *http.Client, posts []CygPost
 		wg.Add(1)
 		go func(p *CygPlugin, post CygPost) {
 			defer wg.Done()
+			defer plugin.RecoverPanic(p.Name())
 
 			// 获取信号量
 			semaphore <- struct{}{}
@@ -204,6 +224,7 @@ func (p *CygPlugin) fetchDownloadLinksAsync(client *http.Client, posts []CygPost
 
 	// 等待所有goroutine完成
 	go func() {
+		defer plugin.RecoverPanic(p.Name())
 		wg.Wait()
 		close(resultChan)
 	}()
@@ -220,10 +241,10 @@ func (p *CygPlugin) fetchDownloadLinksAsync(client *http.Client, posts []CygPost
 // getDownloadLinks 获取指定帖子的下载链接
 func (p *CygPlugin) getDownloadLinks(client *http.Client, postID int) ([]model.Link, error) {
 	// 构建下载链接获取URL
-	downloadURL := fmt.Sprintf("https://cyg.app/wp-json/acg-studio/v1/download?id=%d", postID)
+	downloadURL := fmt.Sprintf("%s/wp-json/acg-studio/v1/download?id=%d", p.Config().BaseURLOr(BaseURL), postID)
 
 	// 创建带超时的上下文
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), p.Config().TimeoutOr(DefaultTimeout))
 	defer cancel()
 
 	// 创建请求对象
@@ -236,7 +257,7 @@ func (p *CygPlugin) getDownloadLinks(client *http.Client, postID int) ([]model.L
 	p.setRequestHeaders(req)
 
 	// 发送请求
-	resp, err := p.doRequestWithRetry(req, client)
+	resp, err := p.DoWithRetry(req, client)
 	if err != nil {
 		return nil, fmt.Errorf("下载链接请求失败: %w", err)
 	}
@@ -373,42 +394,13 @@ func (p *CygPlugin) determineCloudType(name string) string {
 
 // setRequestHeaders 设置请求头
 func (p *CygPlugin) setRequestHeaders(req *http.Request) {
-	req.Header.Set("Referer", "https://h5.acgn.my/")
+	req.Header.Set("Referer", DefaultReferer)
 	req.Header.Set("User-Agent", "Mozilla/5.0 (iPhone; CPU iPhone OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.6 Mobile/15E148 Safari/604.1")
 	req.Header.Set("Accept", "application/json, text/plain, */*")
 	req.Header.Set("Accept-Language", "zh-CN,zh;q=0.9,en;q=0.8")
 	req.Header.Set("Connection", "keep-alive")
 }
 
-// doRequestWithRetry 带重试机制的HTTP请求
-func (p *CygPlugin) doRequestWithRetry(req *http.Request, client *http.Client) (*http.Response, error) {
-	maxRetries := 3
-	var lastErr error
-
-	for i := 0; i < maxRetries; i++ {
-		if i > 0 {
-			// 指数退避重试
-			backoff := time.Duration(1<<uint(i-1)) * 200 * time.Millisecond
-			time.Sleep(backoff)
-		}
-
-		// 克隆请求避免并发问题
-		reqClone := req.Clone(req.Context())
-
-		resp, err := client.Do(reqClone)
-		if err == nil && resp.StatusCode == 200 {
-			return resp, nil
-		}
-
-		if resp != nil {
-			resp.Body.Close()
-		}
-		lastErr = err
-	}
-
-	return nil, fmt.Errorf("重试 %d 次后仍然失败: %w", maxRetries, lastErr)
-}
-
 // parseExtOptions 从ext参数中解析搜索选项
 func (p *CygPlugin) parseExtOptions(ext map[string]interface{}) CygSearchOptions {
 	opts := CygSearchOptions{
@@ -0,0 +1,165 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ext参数类型
+const (
+	ExtTypeString = "string"
+	ExtTypeBool   = "bool"
+	ExtTypeInt    = "int"
+	ExtTypeNumber = "number"
+	ExtTypeObject = "object"
+	ExtTypeArray  = "array"
+)
+
+// ExtParam 插件支持的单个ext参数
+type ExtParam struct {
+	Name        string      `json:"name" yaml:"name"`                           // 参数名
+	Type        string      `json:"type" yaml:"type"`                           // 参数类型：string、bool、int、number、object、array
+	Description string      `json:"description" yaml:"description"`             // 参数说明
+	Default     interface{} `json:"default,omitempty" yaml:"default,omitempty"` // 默认值
+}
+
+// ExtSchemaProvider 可选接口：声明了ext参数的插件。
+// 返回nil表示未声明，返回空切片表示不接受任何ext参数
+type ExtSchemaProvider interface {
+	ExtSchema() []ExtParam
+}
+
+// GetExtSchema 获取插件声明的ext参数，未实现ExtSchemaProvider或未声明时返回false
+func GetExtSchema(p AsyncSearchPlugin) ([]ExtParam, bool) {
+	if provider, ok := p.(ExtSchemaProvider); ok {
+		schema := provider.ExtSchema()
+		return schema, schema != nil
+	}
+	return nil, false
+}
+
+// ExtError ext参数校验错误
+type ExtError struct {
+	Key    string // 参数名
+	Plugin string // 声明该参数的插件，未知参数时为空
+	Reason string // 错误原因
+}
+
+// Error 实现error接口
+func (e *ExtError) Error() string {
+	if e.Plugin != "" {
+		return fmt.Sprintf("ext参数 %s 无效（插件 %s）: %s", e.Key, e.Plugin, e.Reason)
+	}
+	return fmt.Sprintf("ext参数 %s 无效: %s", e.Key, e.Reason)
+}
+
+// ValidateExt 按指定插件声明的ext参数校验请求的ext：
+// 参数类型与任一声明不符时报错；所有插件都声明了ext参数且没有插件声明该参数时按未知参数报错，
+// 存在未声明ext参数的插件时无法判断参数是否有效，不检查未知参数。
+// 校验通过后，声明为int的整数值会统一转换为int（JSON解析得到的数字为float64）
+func ValidateExt(plugins []AsyncSearchPlugin, ext map[string]interface{}) error {
+	if len(plugins) == 0 || len(ext) == 0 {
+		return nil
+	}
+
+	type declared struct {
+		plugin string
+		param  ExtParam
+	}
+	params := make(map[string][]declared)
+	strict := true
+	for _, p := range plugins {
+		schema, ok := GetExtSchema(p)
+		if !ok {
+			strict = false
+			continue
+		}
+		for _, param := range schema {
+			params[param.Name] = append(params[param.Name], declared{plugin: p.Name(), param: param})
+		}
+	}
+
+	// 按参数名排序，保证错误信息稳定
+	keys := make([]string, 0, len(ext))
+	for key := range ext {
+		if !IsReservedExtKey(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		decls, ok := params[key]
+		if !ok {
+			if strict {
+				return &ExtError{Key: key, Reason: "插件不支持该参数"}
+			}
+			continue
+		}
+		value := ext[key]
+		for _, d := range decls {
+			converted, ok := convertExtValue(value, d.param.Type)
+			if !ok {
+				return &ExtError{Key: key, Plugin: d.plugin, Reason: fmt.Sprintf("应为%s类型", d.param.Type)}
+			}
+			value = converted
+		}
+		ext[key] = value
+	}
+	return nil
+}
+
+// convertExtValue 检查参数值是否符合声明的类型，并返回规范化后的值
+func convertExtValue(value interface{}, typ string) (interface{}, bool) {
+	if value == nil {
+		return nil, true
+	}
+	switch strings.ToLower(typ) {
+	case ExtTypeString:
+		_, ok := value.(string)
+		return value, ok
+	case ExtTypeBool:
+		_, ok := value.(bool)
+		return value, ok
+	case ExtTypeInt:
+		f, ok := toFloat64(value)
+		if !ok || f != math.Trunc(f) {
+			return value, false
+		}
+		return int(f), true
+	case ExtTypeNumber:
+		_, ok := toFloat64(value)
+		return value, ok
+	case ExtTypeObject:
+		_, ok := value.(map[string]interface{})
+		return value, ok
+	case ExtTypeArray:
+		_, ok := value.([]interface{})
+		return value, ok
+	}
+	// 未知类型不做检查
+	return value, true
+}
+
+// toFloat64 把数字类型的参数值转换为float64
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
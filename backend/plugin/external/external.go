@@ -0,0 +1,324 @@
+// Package external 支持以独立进程（stdio）或HTTP服务形式提供的外部插件，
+// 无需修改代码即可接入私有搜索源
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"pansou/model"
+	"pansou/plugin"
+)
+
+// 通信方式
+const (
+	TypeStdio = "stdio"
+	TypeHTTP  = "http"
+)
+
+// 默认参数
+const (
+	defaultTimeout             = 10 // 秒
+	defaultHealthCheckInterval = 30 // 秒
+	healthCheckTimeout         = 5 * time.Second
+)
+
+// Definition 外部插件配置，每项注册为一个独立插件
+type Definition struct {
+	Name                string              `json:"name" yaml:"name"`                                                       // 插件名称
+	Type                string              `json:"type" yaml:"type"`                                                       // 通信方式：stdio 或 http
+	Priority            int                 `json:"priority" yaml:"priority"`                                               // 插件优先级（1-4，越小越优先）
+	Command             string              `json:"command,omitempty" yaml:"command,omitempty"`                             // stdio：可执行文件
+	Args                []string            `json:"args,omitempty" yaml:"args,omitempty"`                                   // stdio：命令行参数
+	Env                 map[string]string   `json:"env,omitempty" yaml:"env,omitempty"`                                     // stdio：额外环境变量
+	WorkDir             string              `json:"work_dir,omitempty" yaml:"work_dir,omitempty"`                           // stdio：工作目录
+	URL                 string              `json:"url,omitempty" yaml:"url,omitempty"`                                     // http：服务地址
+	Headers             map[string]string   `json:"headers,omitempty" yaml:"headers,omitempty"`                             // http：额外请求头
+	Timeout             int                 `json:"timeout,omitempty" yaml:"timeout,omitempty"`                             // 单次搜索超时（秒），默认10
+	HealthCheckInterval int                 `json:"health_check_interval,omitempty" yaml:"health_check_interval,omitempty"` // 健康检查间隔（秒），默认30，负数表示不检查
+	SkipServiceFilter   bool                `json:"skip_service_filter,omitempty" yaml:"skip_service_filter,omitempty"`     // 是否跳过Service层关键词过滤
+	Capabilities        plugin.Capabilities `json:"capabilities,omitempty" yaml:"capabilities,omitempty"`                   // 插件能力声明
+	ExtSchema           []plugin.ExtParam   `json:"ext_schema,omitempty" yaml:"ext_schema,omitempty"`                       // 支持的ext参数，不配置表示未声明
+}
+
+// definitionsFile 外部插件配置文件结构
+type definitionsFile struct {
+	Plugins []*Definition `json:"plugins" yaml:"plugins"`
+}
+
+var (
+	requestID int64
+
+	loadedMu sync.Mutex
+	loaded   []*ExternalPlugin
+)
+
+// nextRequestID 生成请求id
+func nextRequestID() int64 {
+	return atomic.AddInt64(&requestID, 1)
+}
+
+// LoadPlugins 从JSON/YAML文件加载外部插件配置并注册为全局插件，返回成功注册的数量
+// 单个插件配置无效时记录日志并跳过，不影响其他插件
+func LoadPlugins(path string) (int, error) {
+	if path == "" {
+		return 0, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("读取外部插件配置失败: %w", err)
+	}
+
+	var file definitionsFile
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &file)
+	default:
+		err = json.Unmarshal(data, &file)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("解析外部插件配置失败: %w", err)
+	}
+
+	// stdio命令与工作目录的相对路径以配置文件所在目录为基准
+	baseDir := filepath.Dir(path)
+
+	count := 0
+	for i, def := range file.Plugins {
+		if def == nil {
+			continue
+		}
+		if err := def.normalize(baseDir); err != nil {
+			log.Printf("[external] 跳过第 %d 个插件配置: %v", i+1, err)
+			continue
+		}
+		if _, exists := plugin.GetPluginByName(def.Name); exists {
+			log.Printf("[external] 插件 %s 与已注册插件同名，将覆盖原插件", def.Name)
+		}
+		p := NewExternalPlugin(def)
+		plugin.RegisterGlobalPlugin(p)
+		p.startHealthCheck()
+
+		loadedMu.Lock()
+		loaded = append(loaded, p)
+		loadedMu.Unlock()
+		count++
+	}
+	return count, nil
+}
+
+// Shutdown 停止所有外部插件的健康检查并结束子进程
+func Shutdown() {
+	loadedMu.Lock()
+	plugins := loaded
+	loaded = nil
+	loadedMu.Unlock()
+
+	for _, p := range plugins {
+		p.Close()
+	}
+}
+
+// normalize 校验插件配置并补全默认值
+func (d *Definition) normalize(baseDir string) error {
+	if d.Name == "" {
+		return fmt.Errorf("缺少name")
+	}
+	// 来源识别依赖 "插件名-ID" 格式的UniqueID
+	if strings.ContainsAny(d.Name, "-:") {
+		return fmt.Errorf("插件名称 %s 不能包含 - 或 :", d.Name)
+	}
+
+	d.Type = strings.ToLower(strings.TrimSpace(d.Type))
+	switch d.Type {
+	case TypeStdio:
+		if d.Command == "" {
+			return fmt.Errorf("插件 %s 缺少command", d.Name)
+		}
+		// 只有包含路径分隔符的命令才按相对路径处理，其余交给PATH查找
+		if strings.ContainsRune(d.Command, filepath.Separator) && !filepath.IsAbs(d.Command) {
+			d.Command = filepath.Join(baseDir, d.Command)
+		}
+		if d.WorkDir != "" && !filepath.IsAbs(d.WorkDir) {
+			d.WorkDir = filepath.Join(baseDir, d.WorkDir)
+		}
+	case TypeHTTP:
+		if !strings.HasPrefix(d.URL, "http://") && !strings.HasPrefix(d.URL, "https://") {
+			return fmt.Errorf("插件 %s 的url无效: %q", d.Name, d.URL)
+		}
+	default:
+		return fmt.Errorf("插件 %s 的type无效: %q（应为stdio或http）", d.Name, d.Type)
+	}
+
+	if d.Priority < 1 || d.Priority > 4 {
+		d.Priority = 3
+	}
+	if d.Timeout <= 0 {
+		d.Timeout = defaultTimeout
+	}
+	if d.HealthCheckInterval == 0 {
+		d.HealthCheckInterval = defaultHealthCheckInterval
+	}
+	return nil
+}
+
+// ExternalPlugin 外部插件适配器，把搜索请求转发给外部进程或HTTP服务
+type ExternalPlugin struct {
+	*plugin.BaseAsyncPlugin
+	def     *Definition
+	backend backend
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewExternalPlugin 根据配置创建外部插件
+func NewExternalPlugin(def *Definition) *ExternalPlugin {
+	p := &ExternalPlugin{
+		BaseAsyncPlugin: plugin.NewBaseAsyncPluginWithFilter(def.Name, def.Priority, def.SkipServiceFilter),
+		def:             def,
+		stop:            make(chan struct{}),
+	}
+	if def.Type == TypeHTTP {
+		p.backend = newHTTPBackend(def)
+	} else {
+		p.backend = newStdioBackend(def)
+	}
+	return p
+}
+
+// Definition 返回插件配置
+func (p *ExternalPlugin) Definition() *Definition {
+	return p.def
+}
+
+// Capabilities 返回配置中声明的插件能力
+func (p *ExternalPlugin) Capabilities() plugin.Capabilities {
+	return p.def.Capabilities
+}
+
+// ExtSchema 返回配置中声明的ext参数
+func (p *ExternalPlugin) ExtSchema() []plugin.ExtParam {
+	return p.def.ExtSchema
+}
+
+// Search 同步搜索接口
+func (p *ExternalPlugin) Search(keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
+	result, err := p.SearchWithResult(keyword, ext)
+	if err != nil {
+		return nil, err
+	}
+	return result.Results, nil
+}
+
+// SearchWithResult 带结果统计的搜索接口
+func (p *ExternalPlugin) SearchWithResult(keyword string, ext map[string]interface{}) (model.PluginSearchResult, error) {
+	return p.AsyncSearchWithResult(keyword, p.searchImpl, p.MainCacheKey, ext)
+}
+
+// searchImpl 向外部插件发送搜索请求
+func (p *ExternalPlugin) searchImpl(_ *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
+	deadline := time.Now().Add(time.Duration(p.def.Timeout) * time.Second)
+	// 调用方的截止时间更早时只使用剩余时间，调用方不会再等待之后返回的结果
+	if callerDeadline, ok := plugin.GetSearchDeadline(ext); ok && callerDeadline.Before(deadline) {
+		deadline = callerDeadline
+	}
+	timeout := time.Until(deadline)
+	if timeout <= 0 {
+		return nil, fmt.Errorf("[%s] 外部插件响应超时: %w", p.Name(), context.DeadlineExceeded)
+	}
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	req := &Request{
+		ID:        nextRequestID(),
+		Type:      RequestTypeSearch,
+		Keyword:   keyword,
+		Ext:       plugin.WithoutSearchOptions(ext),
+		Deadline:  deadline.Format(time.RFC3339Nano),
+		TimeoutMs: timeout.Milliseconds(),
+	}
+	if opts := plugin.GetSearchOptions(ext); !opts.IsDefault() {
+		req.Options = &opts
+	}
+
+	resp, err := p.backend.call(ctx, req)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("[%s] 外部插件响应超时（%v）: %w", p.Name(), timeout.Round(time.Millisecond), err)
+		}
+		return nil, fmt.Errorf("[%s] 外部插件请求失败: %w", p.Name(), err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("[%s] 外部插件返回错误: %s", p.Name(), resp.Error)
+	}
+
+	results := make([]model.SearchResult, 0, len(resp.Results))
+	for i, result := range resp.Results {
+		if strings.TrimSpace(result.Title) == "" {
+			continue
+		}
+		if result.UniqueID == "" {
+			result.UniqueID = fmt.Sprintf("%s-%d", p.Name(), i)
+		} else if !strings.HasPrefix(result.UniqueID, p.Name()+"-") {
+			result.UniqueID = p.Name() + "-" + result.UniqueID
+		}
+		result.Channel = "" // 插件搜索结果Channel为空
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// startHealthCheck 启动定期健康检查，失败时重启stdio进程
+func (p *ExternalPlugin) startHealthCheck() {
+	if p.def.HealthCheckInterval < 0 {
+		return
+	}
+
+	go func() {
+		defer plugin.RecoverPanic(p.Name())
+		ticker := time.NewTicker(time.Duration(p.def.HealthCheckInterval) * time.Second)
+		defer ticker.Stop()
+
+		healthy := true
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+			err := p.backend.health(ctx)
+			cancel()
+
+			if err != nil {
+				log.Printf("[external] 插件 %s 健康检查失败: %v", p.Name(), err)
+				p.backend.restart("健康检查失败")
+			} else if !healthy {
+				log.Printf("[external] 插件 %s 已恢复", p.Name())
+			}
+			healthy = err == nil
+		}
+	}()
+}
+
+// Close 停止健康检查并释放外部插件资源
+func (p *ExternalPlugin) Close() {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+		p.backend.close()
+	})
+}
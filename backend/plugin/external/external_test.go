@@ -0,0 +1,17 @@
+package external
+
+import "testing"
+
+func TestDefinitionRejectsReservedNameChars(t *testing.T) {
+	// 来源识别按第一个"-"拆分UniqueID，名称中不能出现-或:
+	for _, name := range []string{"my-plugin", "my:plugin"} {
+		def := &Definition{Name: name, Type: TypeHTTP, URL: "http://127.0.0.1:1/"}
+		if err := def.normalize(t.TempDir()); err == nil {
+			t.Errorf("名称 %q 应被拒绝", name)
+		}
+	}
+	def := &Definition{Name: "my_plugin", Type: TypeHTTP, URL: "http://127.0.0.1:1/"}
+	if err := def.normalize(t.TempDir()); err != nil {
+		t.Errorf("合法名称被拒绝: %v", err)
+	}
+}
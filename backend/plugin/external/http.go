@@ -0,0 +1,107 @@
+package external
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"pansou/util"
+)
+
+// maxHTTPResponseSize HTTP插件响应体的最大长度
+const maxHTTPResponseSize = 16 * 1024 * 1024
+
+// httpBackend 通过HTTP与外部服务通信：POST <url>/search 提交Request，
+// 返回Response；GET <url>/health 返回2xx表示可用
+type httpBackend struct {
+	def    *Definition
+	client *http.Client
+}
+
+// newHTTPBackend 创建HTTP通信方式
+func newHTTPBackend(def *Definition) *httpBackend {
+	return &httpBackend{
+		def:    def,
+		client: &http.Client{Transport: util.NewOutboundTransport(nil)},
+	}
+}
+
+// call 提交搜索请求，超时由ctx控制
+func (b *httpBackend) call(ctx context.Context, req *Request) (*Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, b.endpoint("/search"), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	b.setHeaders(httpReq)
+
+	resp, err := b.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("请求失败，状态码: %d", resp.StatusCode)
+	}
+
+	var result Response
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	return &result, nil
+}
+
+// health 请求健康检查地址
+func (b *httpBackend) health(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.endpoint("/health"), nil)
+	if err != nil {
+		return err
+	}
+	b.setHeaders(req)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("健康检查失败，状态码: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// restart HTTP服务由外部管理，无需重启
+func (b *httpBackend) restart(reason string) {}
+
+// close 关闭空闲连接
+func (b *httpBackend) close() {
+	b.client.CloseIdleConnections()
+}
+
+// endpoint 拼接服务地址
+func (b *httpBackend) endpoint(path string) string {
+	return strings.TrimSuffix(b.def.URL, "/") + path
+}
+
+// setHeaders 设置配置的额外请求头
+func (b *httpBackend) setHeaders(req *http.Request) {
+	for key, value := range b.def.Headers {
+		req.Header.Set(key, value)
+	}
+}
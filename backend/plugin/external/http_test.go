@@ -0,0 +1,149 @@
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"pansou/model"
+	"pansou/plugin"
+)
+
+// newHTTPPlugin 创建指向测试服务的HTTP外部插件
+func newHTTPPlugin(t *testing.T, handler http.HandlerFunc, timeout int) *ExternalPlugin {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	def := &Definition{
+		Name:                "httptest",
+		Type:                TypeHTTP,
+		URL:                 server.URL + "/",
+		Headers:             map[string]string{"Authorization": "Bearer token"},
+		Timeout:             timeout,
+		HealthCheckInterval: -1,
+	}
+	if err := def.normalize(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	p := NewExternalPlugin(def)
+	t.Cleanup(p.Close)
+	return p
+}
+
+func TestHTTPSearch(t *testing.T) {
+	var mu sync.Mutex
+	var got Request
+	p := newHTTPPlugin(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if r.Method != http.MethodPost || r.URL.Path != "/search" || r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		json.NewDecoder(r.Body).Decode(&got)
+		mu.Unlock()
+		json.NewEncoder(w).Encode(Response{Results: []model.SearchResult{
+			{UniqueID: "httptest-7", Title: "凡人修仙传"},
+			{UniqueID: "8", Title: "凡人修仙传 第二季"},
+		}})
+	}, 5)
+
+	ext := map[string]interface{}{"region": "cn"}
+	plugin.SetSearchOptions(ext, plugin.SearchOptions{Depth: plugin.DepthDeep})
+	results, err := p.searchImpl(nil, "凡人修仙传", ext)
+	if err != nil {
+		t.Fatalf("搜索失败: %v", err)
+	}
+	if len(results) != 2 || results[0].UniqueID != "httptest-7" || results[1].UniqueID != "httptest-8" {
+		t.Errorf("unique_id应只加一次插件名前缀: %+v", results)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got.Type != RequestTypeSearch || got.Keyword != "凡人修仙传" || got.Ext["region"] != "cn" {
+		t.Errorf("请求内容不正确: %+v", got)
+	}
+	if _, ok := got.Ext[plugin.SearchOptionsKey]; ok {
+		t.Error("ext中的保留键不应转发给外部插件")
+	}
+	if got.Options == nil || got.Options.Depth != plugin.DepthDeep {
+		t.Errorf("搜索选项应通过options转发: %+v", got.Options)
+	}
+
+	if err := p.backend.health(context.Background()); err != nil {
+		t.Errorf("健康检查失败: %v", err)
+	}
+}
+
+func TestHTTPSearchErrors(t *testing.T) {
+	p := newHTTPPlugin(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "health") {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		var req Request
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Keyword == "error" {
+			json.NewEncoder(w).Encode(Response{Error: "上游不可用"})
+			return
+		}
+		http.Error(w, "oops", http.StatusInternalServerError)
+	}, 5)
+
+	if _, err := p.searchImpl(nil, "error", nil); err == nil || !strings.Contains(err.Error(), "上游不可用") {
+		t.Errorf("应返回外部插件的错误: %v", err)
+	}
+	if _, err := p.searchImpl(nil, "凡人修仙传", nil); err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("非200状态码应返回错误: %v", err)
+	}
+	if err := p.backend.health(context.Background()); err == nil {
+		t.Error("健康检查应失败")
+	}
+}
+
+func TestHTTPSearchUsesCallerDeadline(t *testing.T) {
+	var mu sync.Mutex
+	var got Request
+	p := newHTTPPlugin(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		json.NewDecoder(r.Body).Decode(&got)
+		mu.Unlock()
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}, 10)
+
+	ext := plugin.WithSearchDeadline(nil, time.Now().Add(200*time.Millisecond))
+	start := time.Now()
+	_, err := p.searchImpl(nil, "凡人修仙传", ext)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("超过调用方截止时间应超时: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("应按调用方截止时间超时，实际等待 %v", elapsed)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got.TimeoutMs <= 0 || got.TimeoutMs > 200 {
+		t.Errorf("timeout_ms应为调用方的剩余时间，实际 %d", got.TimeoutMs)
+	}
+	if _, ok := got.Ext[plugin.SearchDeadlineKey]; ok {
+		t.Error("截止时间不应作为ext转发")
+	}
+
+	if _, err := p.searchImpl(nil, "凡人修仙传", plugin.WithSearchDeadline(nil, time.Now().Add(-time.Second))); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("已过截止时间时应直接返回超时: %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package external
+
+import (
+	"context"
+
+	"pansou/model"
+	"pansou/plugin"
+)
+
+// 请求类型
+const (
+	RequestTypeSearch = "search" // 搜索
+	RequestTypeHealth = "health" // 健康检查
+)
+
+// Request 发送给外部插件的请求。stdio插件每行一个JSON请求，HTTP插件POST到 <url>/search
+type Request struct {
+	ID        int64                  `json:"id"`
+	Type      string                 `json:"type"`
+	Keyword   string                 `json:"keyword,omitempty"`
+	Ext       map[string]interface{} `json:"ext,omitempty"`
+	Deadline  string                 `json:"deadline,omitempty"`   // RFC3339格式，超过该时间的结果会被丢弃
+	TimeoutMs int64                  `json:"timeout_ms,omitempty"` // 剩余时间（毫秒），便于插件直接设置超时
+	Options   *plugin.SearchOptions  `json:"options,omitempty"`    // 搜索深度等标准选项，默认选项时省略
+}
+
+// Response 外部插件返回的响应，stdio插件需原样带回请求的id
+type Response struct {
+	ID      int64                `json:"id"`
+	Results []model.SearchResult `json:"results,omitempty"`
+	Error   string               `json:"error,omitempty"`
+}
+
+// backend 外部插件的通信方式
+type backend interface {
+	// call 发送请求并等待响应，ctx到期时返回超时错误
+	call(ctx context.Context, req *Request) (*Response, error)
+	// health 检查外部插件是否可用
+	health(ctx context.Context) error
+	// restart 结束当前进程，下次请求时重新启动（HTTP插件无需重启）
+	restart(reason string)
+	// close 释放资源
+	close()
+}
@@ -0,0 +1,291 @@
+package external
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"pansou/plugin"
+)
+
+// 进程管理参数
+const (
+	maxResponseLineSize = 16 * 1024 * 1024 // 单行响应的最大长度
+	minRestartInterval  = 5 * time.Second  // 两次启动之间的最小间隔，避免崩溃的进程被频繁拉起
+)
+
+// errProcessExited 外部进程在返回响应前退出
+var errProcessExited = errors.New("外部插件进程已退出")
+
+// stdioBackend 通过标准输入输出与子进程通信：每行一个JSON请求/响应，按id对应，
+// 同一进程可以同时处理多个请求。进程退出或健康检查失败后在下次请求时重新启动
+type stdioBackend struct {
+	def *Definition
+
+	mu        sync.Mutex
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	pending   map[int64]chan *Response
+	exited    chan struct{}
+	lastStart time.Time
+	restarts  int
+	closed    bool
+
+	writeMu sync.Mutex
+}
+
+// newStdioBackend 创建stdio通信方式
+func newStdioBackend(def *Definition) *stdioBackend {
+	return &stdioBackend{def: def}
+}
+
+// ensureStarted 确保进程正在运行，返回进程的输入管道与退出通道
+func (b *stdioBackend) ensureStarted() (io.WriteCloser, chan struct{}, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, nil, fmt.Errorf("外部插件 %s 已关闭", b.def.Name)
+	}
+	if b.cmd != nil {
+		return b.stdin, b.exited, nil
+	}
+	if wait := minRestartInterval - time.Since(b.lastStart); !b.lastStart.IsZero() && wait > 0 {
+		return nil, nil, fmt.Errorf("外部插件 %s 重启过于频繁，%v 后重试", b.def.Name, wait.Round(time.Second))
+	}
+
+	cmd := exec.Command(b.def.Command, b.def.Args...)
+	cmd.Dir = b.def.WorkDir
+	cmd.Env = os.Environ()
+	for key, value := range b.def.Env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	restarting := !b.lastStart.IsZero()
+	b.lastStart = time.Now()
+	if err := cmd.Start(); err != nil {
+		return nil, nil, fmt.Errorf("启动外部插件 %s 失败: %w", b.def.Name, err)
+	}
+	if restarting {
+		b.restarts++
+		log.Printf("[external] 插件 %s 已重新启动（第 %d 次）", b.def.Name, b.restarts)
+	}
+
+	exited := make(chan struct{})
+	b.cmd = cmd
+	b.stdin = stdin
+	b.exited = exited
+	b.pending = make(map[int64]chan *Response)
+
+	// 输出读取完毕后才能调用Wait，否则Wait会提前关闭管道
+	var readers sync.WaitGroup
+	readers.Add(2)
+	go func() {
+		defer readers.Done()
+		defer plugin.RecoverPanic(b.def.Name)
+		b.readResponses(cmd, stdout)
+	}()
+	go func() {
+		defer readers.Done()
+		defer plugin.RecoverPanic(b.def.Name)
+		b.logStderr(stderr)
+	}()
+	go func() {
+		defer plugin.RecoverPanic(b.def.Name)
+		b.wait(cmd, exited, &readers)
+	}()
+
+	return stdin, exited, nil
+}
+
+// readResponses 读取进程输出的响应并分发给等待中的请求
+func (b *stdioBackend) readResponses(cmd *exec.Cmd, stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), maxResponseLineSize)
+	for scanner.Scan() {
+		var resp Response
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+			log.Printf("[external] 插件 %s 输出了无法解析的响应: %v", b.def.Name, err)
+			continue
+		}
+
+		b.mu.Lock()
+		var ch chan *Response
+		if b.cmd == cmd {
+			ch = b.pending[resp.ID]
+			delete(b.pending, resp.ID)
+		}
+		b.mu.Unlock()
+
+		if ch != nil {
+			ch <- &resp
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Printf("[external] 读取插件 %s 输出失败: %v", b.def.Name, err)
+	}
+}
+
+// logStderr 把进程的标准错误输出转发到日志
+func (b *stdioBackend) logStderr(stderr io.Reader) {
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		log.Printf("[external:%s] %s", b.def.Name, scanner.Text())
+	}
+}
+
+// wait 等待进程退出并清理状态
+func (b *stdioBackend) wait(cmd *exec.Cmd, exited chan struct{}, readers *sync.WaitGroup) {
+	readers.Wait()
+	err := cmd.Wait()
+
+	b.mu.Lock()
+	if b.cmd == cmd {
+		b.cmd = nil
+		b.stdin = nil
+		b.pending = map[int64]chan *Response{}
+	}
+	closed := b.closed
+	b.mu.Unlock()
+	close(exited)
+
+	if !closed {
+		log.Printf("[external] 插件 %s 进程已退出: %v", b.def.Name, err)
+	}
+}
+
+// call 发送请求并等待响应
+func (b *stdioBackend) call(ctx context.Context, req *Request) (*Response, error) {
+	stdin, exited, err := b.ensureStarted()
+	if err != nil {
+		return nil, err
+	}
+
+	line, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan *Response, 1)
+	b.mu.Lock()
+	if b.exited != exited {
+		b.mu.Unlock()
+		return nil, errProcessExited
+	}
+	b.pending[req.ID] = ch
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		if b.exited == exited {
+			delete(b.pending, req.ID)
+		}
+		b.mu.Unlock()
+	}()
+
+	// 进程不读取输入时写入会一直阻塞，写入放在单独的goroutine中，由ctx控制等待时间
+	written := make(chan error, 1)
+	go func() {
+		defer plugin.RecoverPanic(b.def.Name)
+		b.writeMu.Lock()
+		defer b.writeMu.Unlock()
+		_, err := stdin.Write(append(line, '\n'))
+		written <- err
+	}()
+
+	select {
+	case err := <-written:
+		if err != nil {
+			return nil, fmt.Errorf("发送请求失败: %w", err)
+		}
+	case <-exited:
+		return nil, errProcessExited
+	case <-ctx.Done():
+		// 结束进程让阻塞的写入返回，否则后续请求都会等待writeMu；下次请求时重新启动
+		b.kill(exited, "发送请求超时")
+		return nil, fmt.Errorf("发送请求失败: %w", ctx.Err())
+	}
+
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-exited:
+		return nil, errProcessExited
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// health 发送health请求，进程需在超时前返回不带error的响应
+func (b *stdioBackend) health(ctx context.Context) error {
+	resp, err := b.call(ctx, &Request{ID: nextRequestID(), Type: RequestTypeHealth})
+	if err != nil {
+		return err
+	}
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+	return nil
+}
+
+// restart 结束当前进程，下次请求时重新启动
+func (b *stdioBackend) restart(reason string) {
+	b.mu.Lock()
+	exited := b.exited
+	b.mu.Unlock()
+
+	b.kill(exited, reason)
+}
+
+// kill 结束exited对应的进程，进程已经退出或被替换时不做处理
+func (b *stdioBackend) kill(exited chan struct{}, reason string) {
+	b.mu.Lock()
+	cmd := b.cmd
+	current := b.exited == exited
+	b.mu.Unlock()
+
+	if current && cmd != nil && cmd.Process != nil {
+		log.Printf("[external] 结束插件 %s 进程: %s", b.def.Name, reason)
+		cmd.Process.Kill()
+	}
+}
+
+// close 结束进程，之后不再启动
+func (b *stdioBackend) close() {
+	b.mu.Lock()
+	b.closed = true
+	cmd, stdin, exited := b.cmd, b.stdin, b.exited
+	b.mu.Unlock()
+
+	if cmd == nil {
+		return
+	}
+	// 先关闭输入让进程自行退出，超时后强制结束
+	stdin.Close()
+	select {
+	case <-exited:
+	case <-time.After(2 * time.Second):
+		cmd.Process.Kill()
+	}
+}
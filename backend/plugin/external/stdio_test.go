@@ -0,0 +1,175 @@
+package external
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"pansou/model"
+)
+
+// helperEnv 设置后测试二进制作为外部插件子进程运行，取值为子进程的行为
+const helperEnv = "PANSOU_EXTERNAL_HELPER"
+
+// TestHelperProcess 不是真正的测试：由stdio测试以子进程方式启动，模拟外部插件
+func TestHelperProcess(t *testing.T) {
+	mode := os.Getenv(helperEnv)
+	if mode == "" {
+		return
+	}
+	defer os.Exit(0)
+
+	switch mode {
+	case "hang":
+		// 不读取输入，模拟卡死的插件
+		time.Sleep(time.Minute)
+		return
+	case "crash":
+		fmt.Fprintln(os.Stderr, "崩溃")
+		os.Exit(2)
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 64*1024), maxResponseLineSize)
+	for scanner.Scan() {
+		var req Request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			continue
+		}
+		resp := Response{ID: req.ID}
+		switch {
+		case req.Type == RequestTypeHealth:
+		case req.Keyword == "error":
+			resp.Error = "上游不可用"
+		case req.Keyword == "slow":
+			continue
+		default:
+			resp.Results = []model.SearchResult{
+				{UniqueID: "1", Title: req.Keyword, Links: []model.Link{{Type: "quark", URL: "https://pan.quark.cn/s/abc"}}},
+				{Title: "  "},
+			}
+		}
+		line, _ := json.Marshal(resp)
+		fmt.Println(string(line))
+	}
+}
+
+// newHelperPlugin 创建以测试二进制为子进程的stdio外部插件
+func newHelperPlugin(t *testing.T, mode string, timeout int) *ExternalPlugin {
+	t.Helper()
+	def := &Definition{
+		Name:                "stdiotest",
+		Type:                TypeStdio,
+		Command:             os.Args[0],
+		Args:                []string{"-test.run=^TestHelperProcess$"},
+		Env:                 map[string]string{helperEnv: mode},
+		Timeout:             timeout,
+		HealthCheckInterval: -1,
+	}
+	if err := def.normalize(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	p := NewExternalPlugin(def)
+	t.Cleanup(p.Close)
+	return p
+}
+
+func TestStdioSearch(t *testing.T) {
+	p := newHelperPlugin(t, "echo", 5)
+
+	results, err := p.searchImpl(nil, "凡人修仙传", nil)
+	if err != nil {
+		t.Fatalf("搜索失败: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("缺少标题的结果应被丢弃，实际 %d 条", len(results))
+	}
+	if results[0].UniqueID != "stdiotest-1" || results[0].Title != "凡人修仙传" {
+		t.Errorf("结果不正确: %+v", results[0])
+	}
+
+	if _, err := p.searchImpl(nil, "error", nil); err == nil || !strings.Contains(err.Error(), "上游不可用") {
+		t.Errorf("应返回外部插件的错误: %v", err)
+	}
+	if err := p.backend.health(context.Background()); err != nil {
+		t.Errorf("健康检查失败: %v", err)
+	}
+}
+
+func TestStdioResponseTimeout(t *testing.T) {
+	p := newHelperPlugin(t, "echo", 1)
+
+	start := time.Now()
+	_, err := p.searchImpl(nil, "slow", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("未返回响应时应超时: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("超时等待过长: %v", elapsed)
+	}
+
+	// 进程仍然可用，后续请求不受影响
+	if _, err := p.searchImpl(nil, "凡人修仙传", nil); err != nil {
+		t.Errorf("超时后进程应继续可用: %v", err)
+	}
+}
+
+func TestStdioProcessExit(t *testing.T) {
+	p := newHelperPlugin(t, "crash", 5)
+
+	_, err := p.searchImpl(nil, "凡人修仙传", nil)
+	if err == nil {
+		t.Fatal("进程退出时应返回错误")
+	}
+}
+
+func TestStdioWriteTimeoutKillsProcess(t *testing.T) {
+	p := newHelperPlugin(t, "hang", 1)
+	b := p.backend.(*stdioBackend)
+
+	// 超过管道缓冲区的请求在进程不读取输入时会阻塞写入
+	keyword := strings.Repeat("凡", 1<<20)
+	start := time.Now()
+	_, err := p.searchImpl(nil, keyword, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("写入阻塞时应在超时后返回: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("写入阻塞时等待过长: %v", elapsed)
+	}
+
+	b.mu.Lock()
+	exited := b.exited
+	b.mu.Unlock()
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("写入超时后应结束进程")
+	}
+
+	// 写入goroutine随进程结束返回，不再占用writeMu
+	locked := make(chan struct{})
+	go func() {
+		b.writeMu.Lock()
+		b.writeMu.Unlock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("进程结束后写入仍在阻塞")
+	}
+
+	b.mu.Lock()
+	running := b.cmd != nil
+	b.mu.Unlock()
+	if running {
+		t.Error("进程结束后应在下次请求时重新启动")
+	}
+}
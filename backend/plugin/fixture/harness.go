@@ -0,0 +1,209 @@
+package fixture
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"pansou/model"
+	"pansou/plugin"
+	"pansou/util"
+)
+
+// 控制录制与金标准更新的环境变量
+const (
+	EnvRecord       = "FIXTURE_RECORD" // 设为1时访问真实上游并重新录制fixture
+	EnvUpdateGolden = "UPDATE_GOLDEN"  // 设为1时用本次结果覆盖金标准文件
+)
+
+// Case 插件回放测试用例，对应 <Dir>/<Name>.fixture.json 与 <Dir>/<Name>.golden.json
+type Case struct {
+	Name    string
+	Keyword string
+	Ext     map[string]interface{}
+	Dir     string // 默认为testdata
+}
+
+// GoldenLink 金标准中的链接
+type GoldenLink struct {
+	Type     string `json:"type"`
+	URL      string `json:"url"`
+	Password string `json:"password,omitempty"`
+}
+
+// GoldenResult 金标准中的搜索结果，只保留解析器负责提取的字段
+type GoldenResult struct {
+	UniqueID string       `json:"unique_id"`
+	Title    string       `json:"title"`
+	Datetime string       `json:"datetime,omitempty"` // RFC3339（UTC），零值时为空
+	Links    []GoldenLink `json:"links"`
+}
+
+// Run 使用fixture回放上游响应运行插件搜索，并与金标准对比标题、链接、提取码与时间。
+// 回放期间会替换全局出站请求拦截器，使用该函数的测试不能并行执行
+func Run(t testing.TB, p plugin.AsyncSearchPlugin, c Case) []model.SearchResult {
+	t.Helper()
+
+	dir := c.Dir
+	if dir == "" {
+		dir = "testdata"
+	}
+	fixturePath := filepath.Join(dir, c.Name+".fixture.json")
+	goldenPath := filepath.Join(dir, c.Name+".golden.json")
+
+	mode := ModeReplay
+	if os.Getenv(EnvRecord) == "1" {
+		mode = ModeRecord
+	}
+	recorder, err := NewRecorder(fixturePath, mode)
+	if err != nil {
+		t.Fatalf("加载fixture失败: %v", err)
+	}
+
+	util.SetOutboundInterceptor(recorder.Intercept)
+	defer util.SetOutboundInterceptor(nil)
+
+	// 插件响应缓存是全局的，清空后才能保证请求真正经过回放
+	plugin.ClearPluginResponseCache(p.Name())
+	defer plugin.ClearPluginResponseCache(p.Name())
+
+	results, err := p.Search(c.Keyword, c.Ext)
+	if err != nil {
+		t.Fatalf("插件 %s 搜索失败: %v", p.Name(), err)
+	}
+	for _, req := range recorder.Unmatched() {
+		t.Errorf("fixture中没有录制该请求: %s", req)
+	}
+
+	if mode == ModeRecord {
+		if err := recorder.Save(); err != nil {
+			t.Fatalf("保存fixture失败: %v", err)
+		}
+	}
+
+	got := ToGolden(results)
+	if mode == ModeRecord || os.Getenv(EnvUpdateGolden) == "1" {
+		if err := writeGolden(goldenPath, got); err != nil {
+			t.Fatalf("写入金标准失败: %v", err)
+		}
+		return results
+	}
+
+	want, err := readGolden(goldenPath)
+	if err != nil {
+		t.Fatalf("读取金标准失败: %v（首次运行请设置%s=1生成）", err, EnvUpdateGolden)
+	}
+	CompareGolden(t, want, got)
+	return results
+}
+
+// RunRegistered 按名称查找已注册的插件并运行回放测试
+func RunRegistered(t testing.TB, name string, c Case) []model.SearchResult {
+	t.Helper()
+
+	p, ok := plugin.GetPluginByName(name)
+	if !ok {
+		t.Fatalf("插件未注册: %s", name)
+	}
+	return Run(t, p, c)
+}
+
+// ToGolden 将搜索结果转换为金标准格式（按UniqueID、标题排序）
+func ToGolden(results []model.SearchResult) []GoldenResult {
+	golden := make([]GoldenResult, 0, len(results))
+	for _, r := range results {
+		g := GoldenResult{
+			UniqueID: r.UniqueID,
+			Title:    r.Title,
+			Links:    make([]GoldenLink, 0, len(r.Links)),
+		}
+		if !r.Datetime.IsZero() {
+			g.Datetime = r.Datetime.UTC().Format(time.RFC3339)
+		}
+		for _, link := range r.Links {
+			g.Links = append(g.Links, GoldenLink{Type: link.Type, URL: link.URL, Password: link.Password})
+		}
+		golden = append(golden, g)
+	}
+	sort.SliceStable(golden, func(i, j int) bool {
+		if golden[i].UniqueID != golden[j].UniqueID {
+			return golden[i].UniqueID < golden[j].UniqueID
+		}
+		return golden[i].Title < golden[j].Title
+	})
+	return golden
+}
+
+// CompareGolden 逐条对比金标准与实际结果，报告标题、时间、链接与提取码的差异
+func CompareGolden(t testing.TB, want, got []GoldenResult) {
+	t.Helper()
+
+	if len(want) != len(got) {
+		t.Errorf("结果数不一致: 期望 %d，实际 %d", len(want), len(got))
+	}
+
+	gotByID := make(map[string]GoldenResult, len(got))
+	for _, g := range got {
+		gotByID[g.UniqueID] = g
+	}
+	for _, w := range want {
+		g, ok := gotByID[w.UniqueID]
+		if !ok {
+			t.Errorf("缺少结果 %s（%s）", w.UniqueID, w.Title)
+			continue
+		}
+		if g.Title != w.Title {
+			t.Errorf("%s 标题不一致: 期望 %q，实际 %q", w.UniqueID, w.Title, g.Title)
+		}
+		if g.Datetime != w.Datetime {
+			t.Errorf("%s 时间不一致: 期望 %q，实际 %q", w.UniqueID, w.Datetime, g.Datetime)
+		}
+		if len(g.Links) != len(w.Links) {
+			t.Errorf("%s 链接数不一致: 期望 %d，实际 %d", w.UniqueID, len(w.Links), len(g.Links))
+			continue
+		}
+		for i := range w.Links {
+			if g.Links[i] != w.Links[i] {
+				t.Errorf("%s 第%d个链接不一致: 期望 %+v，实际 %+v", w.UniqueID, i+1, w.Links[i], g.Links[i])
+			}
+		}
+	}
+
+	wantIDs := make(map[string]bool, len(want))
+	for _, w := range want {
+		wantIDs[w.UniqueID] = true
+	}
+	for _, g := range got {
+		if !wantIDs[g.UniqueID] {
+			t.Errorf("多出结果 %s（%s）", g.UniqueID, g.Title)
+		}
+	}
+}
+
+// readGolden 读取金标准文件
+func readGolden(path string) ([]GoldenResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var golden []GoldenResult
+	if err := json.Unmarshal(data, &golden); err != nil {
+		return nil, err
+	}
+	return golden, nil
+}
+
+// writeGolden 写入金标准文件
+func writeGolden(path string, golden []GoldenResult) error {
+	data, err := json.MarshalIndent(golden, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
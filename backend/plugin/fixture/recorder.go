@@ -0,0 +1,255 @@
+// Package fixture 提供插件解析器的录制/回放测试工具：
+// 录制模式下把真实的上游响应保存为fixture文件，回放模式下离线返回这些响应
+package fixture
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"unicode/utf8"
+)
+
+// Mode 录制/回放模式
+type Mode int
+
+const (
+	ModeReplay Mode = iota // 只从fixture文件返回响应，不访问网络
+	ModeRecord             // 访问真实上游并把响应保存到fixture文件
+)
+
+// ErrNoInteraction 回放模式下fixture中没有匹配的请求
+var ErrNoInteraction = errors.New("fixture中没有匹配的请求")
+
+// Request 录制的请求
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+// Response 录制的响应，非UTF-8响应体以base64保存
+type Response struct {
+	Status     int         `json:"status"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 string      `json:"body_base64,omitempty"`
+}
+
+// Interaction 一次请求与响应
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// File fixture文件结构
+type File struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorder 录制/回放上游HTTP响应，可作为util.OutboundInterceptor或http.RoundTripper使用。
+// 请求按方法、URL与请求体匹配（JSON请求体按规范化后的内容匹配，与键顺序无关），
+// 同一请求录制了多次时按顺序返回，用完后重复最后一次
+type Recorder struct {
+	path string
+	mode Mode
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         map[string]int
+	unmatched    []string
+}
+
+// NewRecorder 创建录制器，回放模式下从path加载fixture
+func NewRecorder(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{path: path, mode: mode, used: make(map[string]int)}
+	if mode == ModeRecord {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取fixture文件失败: %w", err)
+	}
+	var file File
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("解析fixture文件失败: %w", err)
+	}
+	r.interactions = file.Interactions
+	return r, nil
+}
+
+// Intercept 实现util.OutboundInterceptor
+func (r *Recorder) Intercept(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	key := interactionKey(req.Method, req.URL.String(), reqBody)
+
+	if r.mode == ModeReplay {
+		return r.replay(req, key)
+	}
+
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := Response{Status: resp.StatusCode, Headers: resp.Header.Clone()}
+	if utf8.Valid(body) {
+		recorded.Body = string(body)
+	} else {
+		recorded.BodyBase64 = base64.StdEncoding.EncodeToString(body)
+	}
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, Interaction{
+		Request:  Request{Method: req.Method, URL: req.URL.String(), Body: string(canonicalBody(reqBody))},
+		Response: recorded,
+	})
+	r.mu.Unlock()
+
+	return recorded.toHTTP(req)
+}
+
+// RoundTrip 实现http.RoundTripper（录制模式下使用http.DefaultTransport访问上游）
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	return r.Intercept(req, http.DefaultTransport)
+}
+
+// replay 返回匹配的录制响应
+func (r *Recorder) replay(req *http.Request, key string) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matches []Interaction
+	for _, it := range r.interactions {
+		if interactionKey(it.Request.Method, it.Request.URL, []byte(it.Request.Body)) == key {
+			matches = append(matches, it)
+		}
+	}
+	if len(matches) == 0 {
+		r.unmatched = append(r.unmatched, req.Method+" "+req.URL.String())
+		return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, req.Method, req.URL.String())
+	}
+
+	idx := r.used[key]
+	if idx >= len(matches) {
+		idx = len(matches) - 1
+	}
+	r.used[key]++
+	return matches[idx].Response.toHTTP(req)
+}
+
+// Unmatched 回放模式下没有匹配到录制响应的请求
+func (r *Recorder) Unmatched() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.unmatched...)
+}
+
+// Save 把录制的请求与响应写入fixture文件
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	file := File{Interactions: r.interactions}
+	r.mu.Unlock()
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0644)
+}
+
+// toHTTP 构建回放的HTTP响应
+func (resp Response) toHTTP(req *http.Request) (*http.Response, error) {
+	body := []byte(resp.Body)
+	if resp.BodyBase64 != "" {
+		decoded, err := base64.StdEncoding.DecodeString(resp.BodyBase64)
+		if err != nil {
+			return nil, fmt.Errorf("解码fixture响应体失败: %w", err)
+		}
+		body = decoded
+	}
+
+	header := resp.Headers.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	// 手工编辑过的fixture响应体长度可能与录制时不同
+	header.Del("Content-Length")
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", resp.Status, http.StatusText(resp.Status)),
+		StatusCode:    resp.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// readRequestBody 读取请求体并恢复，以便继续发送
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("读取请求体失败: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// interactionKey 请求匹配键：方法 + URL + 请求体摘要
+func interactionKey(method, rawURL string, body []byte) string {
+	if len(body) == 0 {
+		return method + " " + rawURL
+	}
+	sum := sha256.Sum256(canonicalBody(body))
+	return method + " " + rawURL + " " + hex.EncodeToString(sum[:8])
+}
+
+// canonicalBody 规范化JSON请求体（按键排序、去除空白），插件用map构造的请求体每次序列化的键顺序可能不同；
+// 非JSON请求体原样返回
+func canonicalBody(body []byte) []byte {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return body
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(trimmed))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return body
+	}
+	canonical, err := json.Marshal(value)
+	if err != nil {
+		return body
+	}
+	return canonical
+}
@@ -0,0 +1,121 @@
+package fixture
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecorderRecordThenReplay(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"q":"`+r.URL.Query().Get("q")+`"}`)
+	}))
+	path := filepath.Join(t.TempDir(), "case.fixture.json")
+
+	recorder, err := NewRecorder(path, ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: recorder}
+	if body := get(t, client, server.URL+"/search?q=abc"); body != `{"q":"abc"}` {
+		t.Fatalf("录制响应不正确: %s", body)
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+
+	replayer, err := NewRecorder(path, ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client = &http.Client{Transport: replayer}
+	if body := get(t, client, server.URL+"/search?q=abc"); body != `{"q":"abc"}` {
+		t.Fatalf("回放响应不正确: %s", body)
+	}
+	if calls != 1 {
+		t.Fatalf("回放不应访问上游，实际请求 %d 次", calls)
+	}
+	if len(replayer.Unmatched()) != 0 {
+		t.Fatalf("不应有未匹配的请求: %v", replayer.Unmatched())
+	}
+}
+
+func TestRecorderReplayUnmatched(t *testing.T) {
+	replayer := &Recorder{mode: ModeReplay, used: make(map[string]int)}
+	replayer.interactions = []Interaction{{
+		Request:  Request{Method: http.MethodGet, URL: "https://example.com/a"},
+		Response: Response{Status: http.StatusOK, Body: "a"},
+	}}
+
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com/b", nil)
+	if _, err := replayer.RoundTrip(req); !errors.Is(err, ErrNoInteraction) {
+		t.Fatalf("期望ErrNoInteraction，实际 %v", err)
+	}
+	if got := replayer.Unmatched(); len(got) != 1 || got[0] != "GET https://example.com/b" {
+		t.Fatalf("未匹配请求记录不正确: %v", got)
+	}
+}
+
+func TestRecorderReplayRepeatedRequestsInOrder(t *testing.T) {
+	replayer := &Recorder{mode: ModeReplay, used: make(map[string]int)}
+	for _, body := range []string{"first", "second"} {
+		replayer.interactions = append(replayer.interactions, Interaction{
+			Request:  Request{Method: http.MethodPost, URL: "https://example.com/api", Body: "page=1"},
+			Response: Response{Status: http.StatusOK, Body: body},
+		})
+	}
+
+	client := &http.Client{Transport: replayer}
+	for _, want := range []string{"first", "second", "second"} {
+		resp, err := client.Post("https://example.com/api", "text/plain", strings.NewReader("page=1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != want {
+			t.Fatalf("期望 %q，实际 %q", want, body)
+		}
+	}
+}
+
+func TestRecorderMatchesJSONBodyRegardlessOfKeyOrder(t *testing.T) {
+	replayer := &Recorder{mode: ModeReplay, used: make(map[string]int)}
+	replayer.interactions = []Interaction{{
+		Request:  Request{Method: http.MethodPost, URL: "https://example.com/api", Body: `{"page":1,"q":"凡人修仙传","size":30}`},
+		Response: Response{Status: http.StatusOK, Body: "ok"},
+	}}
+
+	client := &http.Client{Transport: replayer}
+	resp, err := client.Post("https://example.com/api", "application/json", strings.NewReader(`{"size": 30, "q": "凡人修仙传", "page": 1}`))
+	if err != nil {
+		t.Fatalf("键顺序不同的JSON请求体应匹配: %v", err)
+	}
+	resp.Body.Close()
+
+	if _, err := client.Post("https://example.com/api", "application/json", strings.NewReader(`{"page":2,"q":"凡人修仙传","size":30}`)); !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("内容不同的JSON请求体不应匹配: %v", err)
+	}
+}
+
+func get(t *testing.T, client *http.Client, url string) string {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
@@ -17,46 +17,39 @@ import (
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
-	"golang.org/x/net/proxy"
 	"pansou/model"
 	"pansou/plugin"
 )
 
 // 常量定义
 const (
-	// 基础URL
+	// 默认站点地址（站点迁移时通过插件配置的base_url覆盖，如 https://btnull.pro、https://www.4kdy.vip）
 	BaseURL = "https://4kfox.com"
-	// BaseURL = "https://btnull.pro/"
-	// BaseURL = "https://www.4kdy.vip/"
 	
-	// 搜索URL格式
-	SearchURL = BaseURL + "/search/%s-------------.html"
+	// 搜索路径格式
+	SearchPath = "/search/%s-------------.html"
 	
-	// 分页搜索URL格式
-	SearchPageURL = BaseURL + "/search/%s----------%d---.html"
+	// 分页搜索路径格式
+	SearchPagePath = "/search/%s----------%d---.html"
 	
-	// 详情页URL格式
-	DetailURL = BaseURL + "/video/%s.html"
+	// 详情页路径格式
+	DetailPath = "/video/%s.html"
 	
 	// 默认超时时间 - 增加超时时间避免网络慢的问题
 	DefaultTimeout = 15 * time.Second
 	
-	// 代理配置
-	DefaultHTTPProxy  = "http://154.219.110.34:51422"
-	DefaultSocks5Proxy = "socks5://154.219.110.34:51423"
-	
 	// 调试开关 - 默认关闭
 	DebugMode = false
 	
-	// 代理开关 - 默认关闭
-	ProxyEnabled = false
-	
 	// 并发数限制 - 大幅提高并发数
 	MaxConcurrency = 50
 	
 	// 最大分页数（避免无限请求）
 	MaxPages = 10
 	
+	// 深度搜索时的最大分页数
+	DeepMaxPages = 30
+	
 	// HTTP连接池配置
 	MaxIdleConns        = 200
 	MaxIdleConnsPerHost = 50
@@ -133,8 +126,8 @@ type Fox4kPlugin struct {
 	optimizedClient *http.Client
 }
 
-// createProxyTransport 创建支持代理的传输层
-func createProxyTransport(proxyURL string) (*http.Transport, error) {
+// createOptimizedHTTPClient 创建优化的HTTP客户端（使用插件配置的代理或代理池标签与超时，未配置代理时直连）
+func createOptimizedHTTPClient(cfg plugin.PluginConfig) *http.Client {
 	transport := &http.Transport{
 		MaxIdleConns:        MaxIdleConns,
 		MaxIdleConnsPerHost: MaxIdleConnsPerHost,
@@ -145,67 +138,39 @@ func createProxyTransport(proxyURL string) (*http.Transport, error) {
 		WriteBufferSize:     16 * 1024,
 		ReadBufferSize:      16 * 1024,
 	}
+	// 请求头与Cookie在构建请求时设置，这里只应用代理与超时
+	return cfg.NewHTTPClient(transport, DefaultTimeout)
+}
 
-	if proxyURL == "" {
-		return transport, nil
+// NewFox4kPlugin 创建新的极狐4K搜索异步插件
+func NewFox4kPlugin() *Fox4kPlugin {
+	return &Fox4kPlugin{
+		BaseAsyncPlugin: plugin.NewBaseAsyncPlugin("fox4k", 3), 
+		optimizedClient: createOptimizedHTTPClient(plugin.PluginConfig{}),
 	}
+}
 
-	if strings.HasPrefix(proxyURL, "socks5://") {
-		// SOCKS5代理
-		dialer, err := proxy.SOCKS5("tcp", strings.TrimPrefix(proxyURL, "socks5://"), nil, proxy.Direct)
-		if err != nil {
-			return nil, fmt.Errorf("创建SOCKS5代理失败: %w", err)
-		}
-		transport.Dial = dialer.Dial
-		debugPrintf("🔧 [Fox4k DEBUG] 使用SOCKS5代理: %s\n", proxyURL)
-	} else {
-		// HTTP代理
-		parsedURL, err := url.Parse(proxyURL)
-		if err != nil {
-			return nil, fmt.Errorf("解析代理URL失败: %w", err)
-		}
-		transport.Proxy = http.ProxyURL(parsedURL)
-		debugPrintf("🔧 [Fox4k DEBUG] 使用HTTP代理: %s\n", proxyURL)
+// ApplyConfig 按插件配置重建HTTP客户端，配置了会话时让客户端使用会话
+func (p *Fox4kPlugin) ApplyConfig(cfg plugin.PluginConfig) {
+	p.optimizedClient = createOptimizedHTTPClient(cfg)
+	if session := p.Session(); session != nil {
+		session.Attach(p.optimizedClient)
 	}
-
-	return transport, nil
 }
 
-// createOptimizedHTTPClient 创建优化的HTTP客户端（支持代理）
-func createOptimizedHTTPClient() *http.Client {
-	var selectedProxy string
-	
-	if ProxyEnabled {
-		// 随机选择代理类型
-		proxyTypes := []string{"", DefaultHTTPProxy, DefaultSocks5Proxy}
-		selectedProxy = proxyTypes[rand.Intn(len(proxyTypes))]
-	} else {
-		// 代理未启用，使用直连
-		selectedProxy = ""
-		debugPrintf("🔧 [Fox4k DEBUG] 代理功能已禁用，使用直连模式\n")
-	}
-	
-	transport, err := createProxyTransport(selectedProxy)
-	if err != nil {
-		debugPrintf("❌ [Fox4k DEBUG] 创建代理传输层失败: %v，使用直连\n", err)
-		transport, _ = createProxyTransport("")
-	}
-	
-	if selectedProxy == "" && ProxyEnabled {
-		debugPrintf("🔧 [Fox4k DEBUG] 使用直连模式\n")
-	}
-	
-	return &http.Client{
-		Transport: transport,
-		Timeout:   DefaultTimeout,
-	}
+// baseURL 返回站点地址（插件配置优先）
+func (p *Fox4kPlugin) baseURL() string {
+	return p.Config().BaseURLOr(BaseURL)
 }
 
-// NewFox4kPlugin 创建新的极狐4K搜索异步插件
-func NewFox4kPlugin() *Fox4kPlugin {
-	return &Fox4kPlugin{
-		BaseAsyncPlugin: plugin.NewBaseAsyncPlugin("fox4k", 3), 
-		optimizedClient: createOptimizedHTTPClient(),
+// Capabilities 声明插件能力：详情页的网盘链接不含夸克
+func (p *Fox4kPlugin) Capabilities() plugin.Capabilities {
+	return plugin.Capabilities{
+		CloudTypes:       []string{"baidu", "aliyun", "tianyi", "uc", "mobile", "115", "pikpak", "xunlei", "123", "magnet", "ed2k"},
+		Categories:       []string{plugin.CategoryFilm, plugin.CategoryAnime},
+		Languages:        []string{"zh"},
+		Magnet:           true,
+		TypicalLatencyMs: 5000,
 	}
 }
 
@@ -226,6 +191,8 @@ func init() {
 
 // startCacheCleaner 定期清理缓存
 func startCacheCleaner() {
+	defer plugin.RecoverPanic("fox4k")
+	
 	ticker := time.NewTicker(30 * time.Minute)
 	defer ticker.Stop()
 	
@@ -288,8 +255,8 @@ func (p *Fox4kPlugin) searchImpl(client *http.Client, keyword string, ext map[st
 	
 	// 2. 如果有多页，继续搜索其他页面（限制最大页数）
 	maxPagesToSearch := totalPages
-	if maxPagesToSearch > MaxPages {
-		maxPagesToSearch = MaxPages
+	if maxPages := p.PageLimit(ext, MaxPages, DeepMaxPages); maxPagesToSearch > maxPages {
+		maxPagesToSearch = maxPages
 	}
 	
 	if totalPages > 1 && maxPagesToSearch > 1 {
@@ -302,6 +269,7 @@ func (p *Fox4kPlugin) searchImpl(client *http.Client, keyword string, ext map[st
 			wg.Add(1)
 			go func(pageNum int) {
 				defer wg.Done()
+				defer plugin.RecoverPanic(p.Name())
 				pageResults, _, err := p.searchPage(client, encodedKeyword, pageNum)
 				if err == nil {
 					mu.Lock()
@@ -344,15 +312,15 @@ func (p *Fox4kPlugin) searchPage(client *http.Client, encodedKeyword string, pag
 	// 1. 构建搜索URL
 	var searchURL string
 	if page == 1 {
-		searchURL = fmt.Sprintf(SearchURL, encodedKeyword)
+		searchURL = p.baseURL() + fmt.Sprintf(SearchPath, encodedKeyword)
 	} else {
-		searchURL = fmt.Sprintf(SearchPageURL, encodedKeyword, page)
+		searchURL = p.baseURL() + fmt.Sprintf(SearchPagePath, encodedKeyword, page)
 	}
 	
 	debugPrintf("🔧 [Fox4k DEBUG] 构建的URL: %s\n", searchURL)
 	
 	// 2. 创建带超时的上下文
-	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), p.Config().TimeoutOr(DefaultTimeout))
 	defer cancel()
 	
 	// 3. 创建请求
@@ -371,10 +339,11 @@ func (p *Fox4kPlugin) searchPage(client *http.Client, encodedKeyword string, pag
 	req.Header.Set("Connection", "keep-alive")
 	req.Header.Set("Upgrade-Insecure-Requests", "1")
 	req.Header.Set("Cache-Control", "max-age=0")
-	req.Header.Set("Referer", BaseURL+"/")
+	req.Header.Set("Referer", p.baseURL()+"/")
 	req.Header.Set("X-Forwarded-For", randomIP)
 	req.Header.Set("X-Real-IP", randomIP)
 	req.Header.Set("sec-ch-ua-platform", "macOS")
+	p.Config().ApplyHeaders(req)
 	
 	debugPrintf("🔧 [Fox4k DEBUG] 使用随机UA: %s\n", randomUA)
 	debugPrintf("🔧 [Fox4k DEBUG] 使用随机IP: %s\n", randomIP)
@@ -391,7 +360,7 @@ func (p *Fox4kPlugin) searchPage(client *http.Client, encodedKeyword string, pag
 	}
 	
 	startTime := time.Now()
-	resp, err := p.doRequestWithRetry(req, client)
+	resp, err := p.DoWithRetry(req, client)
 	requestDuration := time.Since(startTime)
 	
 	if err != nil {
@@ -499,7 +468,7 @@ func (p *Fox4kPlugin) parseSearchResultItem(s *goquery.Selection) *model.SearchR
 	
 	// 补全URL
 	if strings.HasPrefix(href, "/") {
-		href = BaseURL + href
+		href = p.baseURL() + href
 	}
 	
 	// 提取ID
@@ -520,7 +489,7 @@ func (p *Fox4kPlugin) parseSearchResultItem(s *goquery.Selection) *model.SearchR
 	imgElement := s.Find(".hl-item-thumb")
 	imageURL, _ := imgElement.Attr("data-original")
 	if imageURL != "" && strings.HasPrefix(imageURL, "/") {
-		imageURL = BaseURL + imageURL
+		imageURL = p.baseURL() + imageURL
 	}
 	
 	// 获取资源状态
@@ -618,6 +587,7 @@ func (p *Fox4kPlugin) enrichWithDetailInfo(results []model.SearchResult, client
 		wg.Add(1)
 		go func(index int) {
 			defer wg.Done()
+			defer plugin.RecoverPanic(p.Name())
 			
 			// 获取信号量
 			semaphore <- struct{}{}
@@ -688,10 +658,10 @@ func (p *Fox4kPlugin) getDetailInfo(id string, client *http.Client) *detailPageR
 	atomic.AddInt64(&cacheMisses, 1)
 	
 	// 构建详情页URL
-	detailURL := fmt.Sprintf(DetailURL, id)
+	detailURL := p.baseURL() + fmt.Sprintf(DetailPath, id)
 	
 	// 创建带超时的上下文
-	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), p.Config().TimeoutOr(DefaultTimeout))
 	defer cancel()
 	
 	// 创建请求
@@ -705,7 +675,8 @@ func (p *Fox4kPlugin) getDetailInfo(id string, client *http.Client) *detailPageR
 	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8")
 	req.Header.Set("Accept-Language", "zh-CN,zh;q=0.9,en;q=0.8")
 	req.Header.Set("Connection", "keep-alive")
-	req.Header.Set("Referer", BaseURL+"/")
+	req.Header.Set("Referer", p.baseURL()+"/")
+	p.Config().ApplyHeaders(req)
 	
 	// 发送请求
 	resp, err := client.Do(req)
@@ -738,7 +709,7 @@ func (p *Fox4kPlugin) getDetailInfo(id string, client *http.Client) *detailPageR
 	imgElement := doc.Find(".hl-dc-pic .hl-item-thumb")
 	if imageURL, exists := imgElement.Attr("data-original"); exists && imageURL != "" {
 		if strings.HasPrefix(imageURL, "/") {
-			imageURL = BaseURL + imageURL
+			imageURL = p.baseURL() + imageURL
 		}
 		detail.ImageURL = imageURL
 	}
@@ -987,67 +958,6 @@ func (p *Fox4kPlugin) addDownloadLink(detail *detailPageResponse, linkType, link
 	detail.Downloads = append(detail.Downloads, link)
 }
 
-// doRequestWithRetry 带重试机制的HTTP请求
-func (p *Fox4kPlugin) doRequestWithRetry(req *http.Request, client *http.Client) (*http.Response, error) {
-	maxRetries := 3
-	var lastErr error
-	
-	debugPrintf("🔄 [Fox4k DEBUG] 开始重试机制 - 最大重试次数: %d\n", maxRetries)
-	
-	for i := 0; i < maxRetries; i++ {
-		debugPrintf("🔄 [Fox4k DEBUG] 第 %d/%d 次尝试\n", i+1, maxRetries)
-		
-		if i > 0 {
-			// 指数退避重试
-			backoff := time.Duration(1<<uint(i-1)) * 200 * time.Millisecond
-			debugPrintf("⏳ [Fox4k DEBUG] 等待 %v 后重试\n", backoff)
-			time.Sleep(backoff)
-		}
-		
-		// 克隆请求避免并发问题
-		reqClone := req.Clone(req.Context())
-		
-		attemptStart := time.Now()
-		resp, err := client.Do(reqClone)
-		attemptDuration := time.Since(attemptStart)
-		
-		debugPrintf("🔧 [Fox4k DEBUG] 第 %d 次尝试耗时: %v\n", i+1, attemptDuration)
-		
-		if err != nil {
-			debugPrintf("❌ [Fox4k DEBUG] 第 %d 次尝试失败: %v\n", i+1, err)
-			lastErr = err
-			continue
-		}
-		
-		debugPrintf("🔧 [Fox4k DEBUG] 第 %d 次尝试获得响应 - 状态码: %d\n", i+1, resp.StatusCode)
-		
-		if resp.StatusCode == 200 {
-			debugPrintf("✅ [Fox4k DEBUG] 第 %d 次尝试成功!\n", i+1)
-			return resp, nil
-		}
-		
-		debugPrintf("❌ [Fox4k DEBUG] 第 %d 次尝试状态码异常: %d\n", i+1, resp.StatusCode)
-		
-		// 读取响应体以便调试
-		if resp.Body != nil {
-			bodyBytes, readErr := io.ReadAll(resp.Body)
-			resp.Body.Close()
-			if readErr == nil && len(bodyBytes) > 0 {
-				bodyPreview := string(bodyBytes)
-				if len(bodyPreview) > 200 {
-					bodyPreview = bodyPreview[:200] + "..."
-				}
-				debugPrintf("🔧 [Fox4k DEBUG] 响应体预览: %s\n", bodyPreview)
-			}
-		}
-		
-		lastErr = fmt.Errorf("状态码 %d", resp.StatusCode)
-	}
-	
-	debugPrintf("❌ [Fox4k DEBUG] 所有重试都失败了!\n")
-	return nil, fmt.Errorf("重试 %d 次后仍然失败: %w", maxRetries, lastErr)
-}
-
 // getRandomUA 获取随机User-Agent
 func getRandomUA() string {
 	userAgents := []string{
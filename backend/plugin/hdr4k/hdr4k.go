@@ -3,7 +3,6 @@ package hdr4k
 import (
 	"fmt"
 	"math/rand"
-	"net"
 	"net/http"
 	"net/url"
 	"regexp"
@@ -14,6 +13,7 @@ import (
 	"github.com/PuerkitoBio/goquery"
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 )
 
 // 缓存相关变量
@@ -63,6 +63,8 @@ func init() {
 
 // startCacheCleaner 启动一个定期清理缓存的goroutine
 func startCacheCleaner() {
+	defer plugin.RecoverPanic("hdr4k")
+	
 	// 每小时清理一次缓存
 	ticker := time.NewTicker(1 * time.Hour)
 	defer ticker.Stop()
@@ -82,10 +84,12 @@ func getRandomUA() string {
 }
 
 const (
-	// 搜索API
-	SearchURL = "https://www.4khdr.cn/search.php?mod=forum"
-	// 详情页URL模式
-	ThreadURLPattern = "https://www.4khdr.cn/thread-%s-1-1.html"
+	// 默认站点地址（可通过插件配置的base_url覆盖）
+	BaseURL = "https://www.4khdr.cn"
+	// 搜索API路径
+	SearchPath = "/search.php?mod=forum"
+	// 详情页路径模式
+	ThreadPathPattern = "/thread-%s-1-1.html"
 	// 默认超时时间
 	DefaultTimeout = 10 * time.Second
 	// 最大重试次数
@@ -106,6 +110,18 @@ func NewHdr4kAsyncPlugin() *Hdr4kAsyncPlugin {
 	}
 }
 
+// baseURL 返回站点地址（插件配置优先）
+func (p *Hdr4kAsyncPlugin) baseURL() string {
+	return p.Config().BaseURLOr(BaseURL)
+}
+
+// ExtSchema 声明支持的ext参数
+func (p *Hdr4kAsyncPlugin) ExtSchema() []plugin.ExtParam {
+	return []plugin.ExtParam{
+		{Name: "title_en", Type: plugin.ExtTypeString, Description: "英文标题，提供时代替关键词进行搜索"},
+	}
+}
+
 // Search 执行搜索并返回结果（兼容性方法）
 func (p *Hdr4kAsyncPlugin) Search(keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
 	result, err := p.SearchWithResult(keyword, ext)
@@ -138,7 +154,7 @@ func (p *Hdr4kAsyncPlugin) doSearch(client *http.Client, keyword string, ext map
 	data.Set("searchsubmit", "yes")
 	
 	// 发送POST请求
-	req, err := http.NewRequest("POST", SearchURL, strings.NewReader(data.Encode()))
+	req, err := http.NewRequest("POST", p.baseURL()+SearchPath, strings.NewReader(data.Encode()))
 	if err != nil {
 		return nil, fmt.Errorf("创建请求失败: %w", err)
 	}
@@ -146,10 +162,10 @@ func (p *Hdr4kAsyncPlugin) doSearch(client *http.Client, keyword string, ext map
 	// 设置请求头
 	req.Header.Set("User-Agent", getRandomUA())
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("Referer", "https://www.4khdr.cn/")
+	req.Header.Set("Referer", p.baseURL()+"/")
 	
 	// 发送请求（带重试）
-	resp, err := p.doRequestWithRetry(client, req, MaxRetries)
+	resp, err := p.DoWithRetryPolicy(req, client, retryPolicy())
 	if err != nil {
 		return nil, fmt.Errorf("请求失败: %w", err)
 	}
@@ -224,6 +240,7 @@ func (p *Hdr4kAsyncPlugin) doSearch(client *http.Client, keyword string, ext map
 		
 		go func(index int, s *goquery.Selection) {
 			defer wg.Done()
+			defer plugin.RecoverPanic(p.Name())
 			
 			// 获取信号量
 			semaphore <- struct{}{}
@@ -302,6 +319,7 @@ func (p *Hdr4kAsyncPlugin) doSearch(client *http.Client, keyword string, ext map
 	
 	// 等待所有goroutine完成
 	go func() {
+		defer plugin.RecoverPanic(p.Name())
 		wg.Wait()
 		close(resultChan)
 		close(errorChan)
@@ -387,7 +405,7 @@ func (p *Hdr4kAsyncPlugin) getLinksFromDetail(client *http.Client, postID string
 	}
 	
 	// 构建详情页URL
-	detailURL := fmt.Sprintf(ThreadURLPattern, postID)
+	detailURL := p.baseURL() + fmt.Sprintf(ThreadPathPattern, postID)
 	
 	// 发送GET请求获取详情页
 	req, err := http.NewRequest("GET", detailURL, nil)
@@ -397,10 +415,10 @@ func (p *Hdr4kAsyncPlugin) getLinksFromDetail(client *http.Client, postID string
 	
 	// 设置请求头
 	req.Header.Set("User-Agent", getRandomUA())
-	req.Header.Set("Referer", "https://www.4khdr.cn/")
+	req.Header.Set("Referer", p.baseURL()+"/")
 	
 	// 发送请求（带重试）
-	resp, err := p.doRequestWithRetry(client, req, MaxRetries)
+	resp, err := p.DoWithRetryPolicy(req, client, retryPolicy())
 	if err != nil {
 		return []model.Link{}, "", fmt.Errorf("请求失败: %w", err)
 	}
@@ -573,53 +591,14 @@ func (p *Hdr4kAsyncPlugin) determineLinkType(url, name string) string {
 	return linkType
 }
 
-// doRequestWithRetry 发送HTTP请求并支持重试
-func (p *Hdr4kAsyncPlugin) doRequestWithRetry(client *http.Client, req *http.Request, maxRetries int) (*http.Response, error) {
-	var resp *http.Response
-	var err error
-	
-	for i := 0; i <= maxRetries; i++ {
-		// 如果不是第一次尝试，等待一段时间
-		if i > 0 {
-			// 指数退避算法
-			backoff := time.Duration(1<<uint(i-1)) * 500 * time.Millisecond
-			if backoff > 5*time.Second {
-				backoff = 5 * time.Second
-			}
-			time.Sleep(backoff)
-		}
-		
-		// 克隆请求，避免重用同一个请求对象
-		reqClone := req.Clone(req.Context())
-		
-		// 发送请求
-		resp, err = client.Do(reqClone)
-		
-		// 如果请求成功或者是不可重试的错误，则退出循环
-		if err == nil || !p.isRetriableError(err) {
-			break
-		}
-	}
-	
-	return resp, err
-}
-
-// isRetriableError 判断错误是否可以重试
-func (p *Hdr4kAsyncPlugin) isRetriableError(err error) bool {
-	if err == nil {
-		return false
-	}
-	
-	// 判断是否是网络错误或超时错误
-	if netErr, ok := err.(net.Error); ok {
-		return netErr.Timeout() || netErr.Temporary()
-	}
-	
-	// 其他可能需要重试的错误类型
-	errStr := err.Error()
-	return strings.Contains(errStr, "connection refused") ||
-		   strings.Contains(errStr, "connection reset") ||
-		   strings.Contains(errStr, "EOF")
+// retryPolicy 插件的默认重试策略，搜索表单的POST请求没有副作用，允许重试
+func retryPolicy() util.RetryPolicy {
+	policy := util.DefaultRetryPolicy()
+	policy.MaxAttempts = MaxRetries + 1
+	policy.Backoff = 500 * time.Millisecond
+	policy.MaxBackoff = 5 * time.Second
+	policy.RetryNonIdempotent = true
+	return policy
 }
 
 // parseDateTime 解析日期时间字符串
@@ -21,13 +21,17 @@ func init() {
 }
 
 const (
-	// API端点
+	// API端点：三个站点各自维护数据，结果不完全相同，并行请求后合并去重（不是镜像，不能互相替代）
 	HunhepanAPI = "https://hunhepan.com/open/search/disk"
 	QkpansoAPI  = "https://qkpanso.com/v1/search/disk"
 	KuakeAPI    = "https://kuake8.com/v1/search/disk"
 	
 	// 默认页大小
 	DefaultPageSize = 30
+	
+	// 每个API默认获取的页数与深度搜索时的最大页数
+	DefaultPages = 3
+	DeepPages    = 10
 )
 
 // HunhepanAsyncPlugin 混合盘搜索异步插件
@@ -58,6 +62,8 @@ func (p *HunhepanAsyncPlugin) SearchWithResult(keyword string, ext map[string]in
 
 // doSearch 实际的搜索实现
 func (p *HunhepanAsyncPlugin) doSearch(client *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
+	maxPages := p.PageLimit(ext, DefaultPages, DeepPages)
+	
 	// 创建结果通道和错误通道
 	resultChan := make(chan []HunhepanItem, 3)
 	errChan := make(chan error, 3)
@@ -69,7 +75,8 @@ func (p *HunhepanAsyncPlugin) doSearch(client *http.Client, keyword string, ext
 	// 并行请求三个API
 	go func() {
 		defer wg.Done()
-		items, err := p.searchAPI(client, HunhepanAPI, keyword)
+		defer plugin.RecoverPanic(p.Name())
+		items, err := p.searchAPI(client, HunhepanAPI, keyword, maxPages)
 		if err != nil {
 			errChan <- fmt.Errorf("hunhepan API error: %w", err)
 			return
@@ -79,7 +86,8 @@ func (p *HunhepanAsyncPlugin) doSearch(client *http.Client, keyword string, ext
 	
 	go func() {
 		defer wg.Done()
-		items, err := p.searchAPI(client, QkpansoAPI, keyword)
+		defer plugin.RecoverPanic(p.Name())
+		items, err := p.searchAPI(client, QkpansoAPI, keyword, maxPages)
 		if err != nil {
 			errChan <- fmt.Errorf("qkpanso API error: %w", err)
 			return
@@ -89,7 +97,8 @@ func (p *HunhepanAsyncPlugin) doSearch(client *http.Client, keyword string, ext
 	
 	go func() {
 		defer wg.Done()
-		items, err := p.searchAPI(client, KuakeAPI, keyword)
+		defer plugin.RecoverPanic(p.Name())
+		items, err := p.searchAPI(client, KuakeAPI, keyword, maxPages)
 		if err != nil {
 			errChan <- fmt.Errorf("kuake API error: %w", err)
 			return
@@ -99,6 +108,7 @@ func (p *HunhepanAsyncPlugin) doSearch(client *http.Client, keyword string, ext
 	
 	// 启动一个goroutine等待所有请求完成并关闭通道
 	go func() {
+		defer plugin.RecoverPanic(p.Name())
 		wg.Wait()
 		close(resultChan)
 		close(errChan)
@@ -133,9 +143,7 @@ func (p *HunhepanAsyncPlugin) doSearch(client *http.Client, keyword string, ext
 }
 
 // searchAPI 向单个API发送请求
-func (p *HunhepanAsyncPlugin) searchAPI(client *http.Client, apiURL, keyword string) ([]HunhepanItem, error) {
-	maxPages := 3 // 最多获取3页数据，可以根据需要调整
-	
+func (p *HunhepanAsyncPlugin) searchAPI(client *http.Client, apiURL, keyword string, maxPages int) ([]HunhepanItem, error) {
 	// 创建结果通道和错误通道
 	resultChan := make(chan []HunhepanItem, maxPages)
 	errChan := make(chan error, maxPages)
@@ -149,6 +157,7 @@ func (p *HunhepanAsyncPlugin) searchAPI(client *http.Client, apiURL, keyword str
 		
 		go func(pageNum int) {
 			defer wg.Done()
+			defer plugin.RecoverPanic(p.Name())
 			
 			// 构建请求体
 			reqBody := map[string]interface{}{
@@ -222,6 +231,7 @@ func (p *HunhepanAsyncPlugin) searchAPI(client *http.Client, apiURL, keyword str
 	
 	// 启动一个goroutine等待所有页面请求完成并关闭通道
 	go func() {
+		defer plugin.RecoverPanic(p.Name())
 		wg.Wait()
 		close(resultChan)
 		close(errChan)
@@ -0,0 +1,13 @@
+package hunhepan_test
+
+import (
+	"testing"
+
+	"pansou/plugin/fixture"
+	"pansou/plugin/hunhepan"
+)
+
+func TestHunhepanFixture(t *testing.T) {
+	// 三个API各请求3页：跨API的重复资源只保留一条，单页失败不影响其他结果
+	fixture.Run(t, hunhepan.NewHunhepanAsyncPlugin(), fixture.Case{Name: "search", Keyword: "凡人修仙传"})
+}
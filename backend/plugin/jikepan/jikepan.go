@@ -35,6 +35,13 @@ func NewJikepanAsyncV2Plugin() *JikepanAsyncV2Plugin {
 	}
 }
 
+// ExtSchema 声明支持的ext参数
+func (p *JikepanAsyncV2Plugin) ExtSchema() []plugin.ExtParam {
+	return []plugin.ExtParam{
+		{Name: "is_all", Type: plugin.ExtTypeBool, Description: "全量搜索，结果更多但耗时约10秒", Default: false},
+	}
+}
+
 // Search 执行搜索并返回结果（兼容性方法）
 func (p *JikepanAsyncV2Plugin) Search(keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
 	result, err := p.SearchWithResult(keyword, ext)
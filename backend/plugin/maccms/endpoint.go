@@ -0,0 +1,133 @@
+package maccms
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"pansou/plugin"
+)
+
+// 默认参数
+const (
+	defaultAPIPath    = "/api.php/provide/vod"
+	defaultTimeout    = 8 // 秒
+	defaultMaxRetries = 2
+)
+
+// Endpoint MacCMS采集接口配置，每个接口注册为一个独立插件
+type Endpoint struct {
+	Name              string            `json:"name" yaml:"name"`                                                   // 插件名称
+	BaseURLs          []string          `json:"base_urls" yaml:"base_urls"`                                         // 站点地址列表，按顺序主备切换
+	Priority          int               `json:"priority" yaml:"priority"`                                           // 插件优先级（1-4，越小越优先）
+	APIPath           string            `json:"api_path,omitempty" yaml:"api_path,omitempty"`                       // 接口路径，默认 /api.php/provide/vod
+	TypeMapping       map[string]string `json:"type_mapping,omitempty" yaml:"type_mapping,omitempty"`               // 网盘标识映射覆盖，如 {"KKWP": "quark"}
+	Headers           map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`                         // 额外请求头
+	Timeout           int               `json:"timeout,omitempty" yaml:"timeout,omitempty"`                         // 单次请求超时（秒），默认8
+	MaxRetries        int               `json:"max_retries,omitempty" yaml:"max_retries,omitempty"`                 // 每个地址的最大尝试次数，默认2
+	SkipServiceFilter bool              `json:"skip_service_filter,omitempty" yaml:"skip_service_filter,omitempty"` // 是否跳过Service层关键词过滤
+}
+
+// endpointsFile 接口配置文件结构
+type endpointsFile struct {
+	Endpoints []*Endpoint `json:"endpoints" yaml:"endpoints"`
+}
+
+// LoadEndpoints 从JSON/YAML文件加载MacCMS接口配置并注册为全局插件，返回成功注册的数量
+// 单个接口配置无效时记录日志并跳过，不影响其他接口
+func LoadEndpoints(path string) (int, error) {
+	if path == "" {
+		return 0, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("读取MacCMS接口配置失败: %w", err)
+	}
+
+	var file endpointsFile
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &file)
+	default:
+		err = json.Unmarshal(data, &file)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("解析MacCMS接口配置失败: %w", err)
+	}
+
+	count := 0
+	for i, endpoint := range file.Endpoints {
+		if endpoint == nil {
+			continue
+		}
+		if err := endpoint.normalize(); err != nil {
+			log.Printf("[maccms] 跳过第 %d 个接口配置: %v", i+1, err)
+			continue
+		}
+		if _, exists := plugin.GetPluginByName(endpoint.Name); exists {
+			log.Printf("[maccms] 接口 %s 与已注册插件同名，将覆盖原插件", endpoint.Name)
+		}
+		plugin.RegisterGlobalPlugin(NewMacCMSPlugin(endpoint))
+		count++
+	}
+	return count, nil
+}
+
+// normalize 校验接口配置并补全默认值
+func (e *Endpoint) normalize() error {
+	if e.Name == "" {
+		return fmt.Errorf("缺少name")
+	}
+	// 来源识别依赖 "插件名-ID" 格式的UniqueID
+	if strings.ContainsAny(e.Name, "-:") {
+		return fmt.Errorf("接口名称 %s 不能包含 - 或 :", e.Name)
+	}
+
+	var baseURLs []string
+	for _, baseURL := range e.BaseURLs {
+		baseURL = strings.TrimSuffix(strings.TrimSpace(baseURL), "/")
+		if baseURL != "" {
+			baseURLs = append(baseURLs, baseURL)
+		}
+	}
+	if len(baseURLs) == 0 {
+		return fmt.Errorf("接口 %s 缺少base_urls", e.Name)
+	}
+	e.BaseURLs = baseURLs
+
+	if e.APIPath == "" {
+		e.APIPath = defaultAPIPath
+	}
+	if !strings.HasPrefix(e.APIPath, "/") {
+		e.APIPath = "/" + e.APIPath
+	}
+	if e.Priority < 1 || e.Priority > 4 {
+		e.Priority = 3
+	}
+	if e.Timeout <= 0 {
+		e.Timeout = defaultTimeout
+	}
+	if e.MaxRetries <= 0 {
+		e.MaxRetries = defaultMaxRetries
+	}
+
+	// 映射键统一转为大写，便于不区分大小写匹配
+	if len(e.TypeMapping) > 0 {
+		mapping := make(map[string]string, len(e.TypeMapping))
+		for code, linkType := range e.TypeMapping {
+			mapping[strings.ToUpper(strings.TrimSpace(code))] = strings.TrimSpace(linkType)
+		}
+		e.TypeMapping = mapping
+	}
+	return nil
+}
+
+// searchURL 构建指定站点地址的搜索URL
+func (e *Endpoint) searchURL(baseURL string, escapedKeyword string) string {
+	return baseURL + e.APIPath + "?ac=detail&wd=" + escapedKeyword
+}
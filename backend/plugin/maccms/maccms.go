@@ -0,0 +1,442 @@
+package maccms
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"pansou/model"
+	"pansou/plugin"
+	"pansou/util"
+	"pansou/util/json"
+)
+
+// HTTP连接池配置
+const (
+	MaxIdleConns        = 200
+	MaxIdleConnsPerHost = 50
+	MaxConnsPerHost     = 100
+	IdleConnTimeout     = 90 * time.Second
+)
+
+// 预编译的正则表达式
+var (
+	// 密码提取正则表达式
+	passwordRegex    = regexp.MustCompile(`\?pwd=([0-9a-zA-Z]+)`)
+	password115Regex = regexp.MustCompile(`password=([0-9a-zA-Z]+)`)
+
+	// 常见网盘链接的正则表达式（支持16种类型）
+	quarkLinkRegex      = regexp.MustCompile(`https?://pan\.quark\.cn/s/[0-9a-zA-Z]+`)
+	ucLinkRegex         = regexp.MustCompile(`https?://drive\.uc\.cn/s/[0-9a-zA-Z]+(\?[^"'\s]*)?`)
+	baiduLinkRegex      = regexp.MustCompile(`https?://pan\.baidu\.com/s/[0-9a-zA-Z_\-]+(\?pwd=[0-9a-zA-Z]+)?`)
+	aliyunLinkRegex     = regexp.MustCompile(`https?://(www\.)?(aliyundrive\.com|alipan\.com)/s/[0-9a-zA-Z]+`)
+	xunleiLinkRegex     = regexp.MustCompile(`https?://pan\.xunlei\.com/s/[0-9a-zA-Z_\-]+(\?pwd=[0-9a-zA-Z]+)?`)
+	tianyiLinkRegex     = regexp.MustCompile(`https?://cloud\.189\.cn/t/[0-9a-zA-Z]+`)
+	link115Regex        = regexp.MustCompile(`https?://(115\.com|115cdn\.com)/s/[0-9a-zA-Z]+`)
+	mobileLinkRegex     = regexp.MustCompile(`https?://caiyun\.feixin\.10086\.cn/[0-9a-zA-Z]+`)
+	weiyunLinkRegex     = regexp.MustCompile(`https?://share\.weiyun\.com/[0-9a-zA-Z]+`)
+	lanzouLinkRegex     = regexp.MustCompile(`https?://(www\.)?(lanzou[uixys]*|lan[zs]o[ux])\.(com|net|org)/[0-9a-zA-Z]+`)
+	jianguoyunLinkRegex = regexp.MustCompile(`https?://(www\.)?jianguoyun\.com/p/[0-9a-zA-Z]+`)
+	link123Regex        = regexp.MustCompile(`https?://(123pan\.com|www\.123912\.com|www\.123865\.com|www\.123684\.com)/s/[0-9a-zA-Z]+`)
+	pikpakLinkRegex     = regexp.MustCompile(`https?://mypikpak\.com/s/[0-9a-zA-Z]+`)
+	magnetLinkRegex     = regexp.MustCompile(`magnet:\?xt=urn:btih:[0-9a-fA-F]{40}`)
+	ed2kLinkRegex       = regexp.MustCompile(`ed2k://\|file\|.+\|\d+\|[0-9a-fA-F]{32}\|/`)
+)
+
+// linkTypeRegexes 按URL识别网盘类型的顺序
+var linkTypeRegexes = []struct {
+	linkType string
+	regex    *regexp.Regexp
+}{
+	{"quark", quarkLinkRegex},
+	{"uc", ucLinkRegex},
+	{"baidu", baiduLinkRegex},
+	{"aliyun", aliyunLinkRegex},
+	{"xunlei", xunleiLinkRegex},
+	{"tianyi", tianyiLinkRegex},
+	{"115", link115Regex},
+	{"mobile", mobileLinkRegex},
+	{"weiyun", weiyunLinkRegex},
+	{"lanzou", lanzouLinkRegex},
+	{"jianguoyun", jianguoyunLinkRegex},
+	{"123", link123Regex},
+	{"pikpak", pikpakLinkRegex},
+	{"magnet", magnetLinkRegex},
+	{"ed2k", ed2kLinkRegex},
+}
+
+// defaultTypeMapping vod_down_from中常见的网盘标识（键为大写）
+var defaultTypeMapping = map[string]string{
+	"BD":     "baidu",
+	"KG":     "quark",
+	"UC":     "uc",
+	"ALY":    "aliyun",
+	"XL":     "xunlei",
+	"TY":     "tianyi",
+	"115":    "115",
+	"MB":     "mobile",
+	"WY":     "weiyun",
+	"LZ":     "lanzou",
+	"JGY":    "jianguoyun",
+	"123":    "123",
+	"PK":     "pikpak",
+	"PIKPAK": "pikpak",
+	"BDWP":   "baidu",
+	"KKWP":   "quark",
+	"UCWP":   "uc",
+	"ALWP":   "aliyun",
+	"XYWP":   "xunlei",
+	"TYWP":   "tianyi",
+	"115WP":  "115",
+	"WYWP":   "weiyun",
+	"LZWP":   "lanzou",
+	"JGYWP":  "jianguoyun",
+	"123WP":  "123",
+	"PKWP":   "pikpak",
+}
+
+// APIResponse MacCMS采集接口响应结构
+type APIResponse struct {
+	Code      int         `json:"code"`
+	Msg       string      `json:"msg"`
+	Page      interface{} `json:"page"` // 可能是字符串或数字
+	PageCount int         `json:"pagecount"`
+	Limit     interface{} `json:"limit"` // 可能是字符串或数字
+	Total     int         `json:"total"`
+	List      []APIItem   `json:"list"`
+}
+
+// APIItem MacCMS采集接口数据项
+type APIItem struct {
+	VodID       int    `json:"vod_id"`
+	VodName     string `json:"vod_name"`
+	VodActor    string `json:"vod_actor"`
+	VodDirector string `json:"vod_director"`
+	VodDownFrom string `json:"vod_down_from"`
+	VodDownURL  string `json:"vod_down_url"`
+	VodRemarks  string `json:"vod_remarks"`
+	VodPubdate  string `json:"vod_pubdate"`
+	VodArea     string `json:"vod_area"`
+	VodYear     string `json:"vod_year"`
+	VodContent  string `json:"vod_content"`
+	VodPic      string `json:"vod_pic"`
+}
+
+// MacCMSPlugin 基于MacCMS采集接口（api.php/provide/vod）的通用插件
+type MacCMSPlugin struct {
+	*plugin.BaseAsyncPlugin
+	endpoint        *Endpoint
+	optimizedClient *http.Client
+	mirrors         *plugin.MirrorSet
+
+	// 性能统计（原子操作）
+	searchRequests  int64
+	totalSearchTime int64 // 纳秒
+}
+
+// NewMacCMSPlugin 根据接口配置创建插件
+func NewMacCMSPlugin(endpoint *Endpoint) *MacCMSPlugin {
+	// 内置接口配置固定有效，配置文件中的接口已在加载时校验
+	_ = endpoint.normalize()
+
+	return &MacCMSPlugin{
+		BaseAsyncPlugin: plugin.NewBaseAsyncPluginWithFilter(endpoint.Name, endpoint.Priority, endpoint.SkipServiceFilter),
+		endpoint:        endpoint,
+		// 多地址为主备关系，默认按配置顺序切换，可在插件配置文件的mirrors中开启对冲请求与按延迟排序
+		mirrors:         plugin.NewMirrorSet(endpoint.Name, endpoint.BaseURLs, plugin.MirrorOptions{}),
+		optimizedClient: newOptimizedClient(endpoint, plugin.PluginConfig{}),
+	}
+}
+
+// newOptimizedClient 创建接口专用的HTTP客户端，应用插件配置中的代理、请求头、Cookie与超时
+func newOptimizedClient(endpoint *Endpoint, cfg plugin.PluginConfig) *http.Client {
+	return cfg.NewConfiguredClient(&http.Transport{
+		MaxIdleConns:        MaxIdleConns,
+		MaxIdleConnsPerHost: MaxIdleConnsPerHost,
+		MaxConnsPerHost:     MaxConnsPerHost,
+		IdleConnTimeout:     IdleConnTimeout,
+		DisableKeepAlives:   false,
+	}, time.Duration(endpoint.Timeout)*time.Second)
+}
+
+// ApplyConfig 按插件配置重建HTTP客户端，配置了会话时让客户端使用会话
+func (p *MacCMSPlugin) ApplyConfig(cfg plugin.PluginConfig) {
+	p.optimizedClient = newOptimizedClient(p.endpoint, cfg)
+	if session := p.Session(); session != nil {
+		session.Attach(p.optimizedClient)
+	}
+}
+
+// Endpoint 返回插件的接口配置
+func (p *MacCMSPlugin) Endpoint() *Endpoint {
+	return p.endpoint
+}
+
+// Search 同步搜索接口
+func (p *MacCMSPlugin) Search(keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
+	result, err := p.SearchWithResult(keyword, ext)
+	if err != nil {
+		return nil, err
+	}
+	return result.Results, nil
+}
+
+// SearchWithResult 带结果统计的搜索接口
+func (p *MacCMSPlugin) SearchWithResult(keyword string, ext map[string]interface{}) (model.PluginSearchResult, error) {
+	return p.AsyncSearchWithResult(keyword, p.searchImpl, p.MainCacheKey, ext)
+}
+
+// searchImpl 搜索实现（多地址主备切换）
+func (p *MacCMSPlugin) searchImpl(client *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
+	// 性能统计
+	start := time.Now()
+	atomic.AddInt64(&p.searchRequests, 1)
+	defer func() {
+		atomic.AddInt64(&p.totalSearchTime, time.Since(start).Nanoseconds())
+	}()
+
+	// 使用优化的客户端
+	if p.optimizedClient != nil {
+		client = p.optimizedClient
+	}
+
+	escapedKeyword := url.QueryEscape(keyword)
+	results, err := p.mirrors.Do(context.Background(), func(ctx context.Context, baseURL string) (interface{}, error) {
+		return p.tryRequest(ctx, client, baseURL, p.endpoint.searchURL(baseURL, escapedKeyword))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results.([]model.SearchResult), nil
+}
+
+// tryRequest 请求单个站点地址
+func (p *MacCMSPlugin) tryRequest(ctx context.Context, client *http.Client, baseURL string, searchURL string) ([]model.SearchResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.Config().TimeoutOr(time.Duration(p.endpoint.Timeout)*time.Second))
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建搜索请求失败: %w", err)
+	}
+
+	// 设置请求头
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
+	req.Header.Set("Accept", "application/json, text/plain, */*")
+	req.Header.Set("Accept-Language", "zh-CN,zh;q=0.9,en;q=0.8")
+	req.Header.Set("Connection", "keep-alive")
+	req.Header.Set("Referer", baseURL+"/")
+	req.Header.Set("Cache-Control", "no-cache")
+	for key, value := range p.endpoint.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := p.DoWithRetryPolicy(req, client, p.retryPolicy())
+	if err != nil {
+		return nil, fmt.Errorf("搜索请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	var apiResponse APIResponse
+	if err := json.Unmarshal(body, &apiResponse); err != nil {
+		return nil, fmt.Errorf("解析JSON响应失败: %w", err)
+	}
+
+	if apiResponse.Code != 1 {
+		return nil, fmt.Errorf("API返回错误: %s", apiResponse.Msg)
+	}
+
+	var results []model.SearchResult
+	for _, item := range apiResponse.List {
+		if result := p.parseAPIItem(item); result.Title != "" {
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// parseAPIItem 解析API数据项
+func (p *MacCMSPlugin) parseAPIItem(item APIItem) model.SearchResult {
+	title := strings.TrimSpace(item.VodName)
+	if title == "" {
+		return model.SearchResult{}
+	}
+
+	var tags []string
+	if item.VodYear != "" {
+		tags = append(tags, item.VodYear)
+	}
+	if area := strings.TrimSpace(item.VodArea); area != "" {
+		tags = append(tags, area)
+	}
+
+	return model.SearchResult{
+		UniqueID: fmt.Sprintf("%s-%d", p.Name(), item.VodID),
+		Title:    title,
+		Content:  buildContent(item),
+		Links:    p.parseDownloadLinks(item.VodDownFrom, item.VodDownURL),
+		Tags:     tags,
+		Channel:  "",          // 插件搜索结果Channel为空
+		Datetime: time.Time{}, // 使用零值而不是nil，参考jikepan插件标准
+	}
+}
+
+// buildContent 构建内容描述（清理演员、导演字段前后多余的逗号）
+func buildContent(item APIItem) string {
+	var contentParts []string
+	if actor := strings.TrimSpace(strings.Trim(item.VodActor, ",")); actor != "" {
+		contentParts = append(contentParts, fmt.Sprintf("主演: %s", actor))
+	}
+	if director := strings.TrimSpace(strings.Trim(item.VodDirector, ",")); director != "" {
+		contentParts = append(contentParts, fmt.Sprintf("导演: %s", director))
+	}
+	if area := strings.TrimSpace(item.VodArea); area != "" {
+		contentParts = append(contentParts, fmt.Sprintf("地区: %s", area))
+	}
+	if item.VodYear != "" {
+		contentParts = append(contentParts, fmt.Sprintf("年份: %s", item.VodYear))
+	}
+	if item.VodRemarks != "" {
+		contentParts = append(contentParts, fmt.Sprintf("状态: %s", item.VodRemarks))
+	}
+	return strings.Join(contentParts, " | ")
+}
+
+// parseDownloadLinks 解析下载链接
+// vod_down_from与vod_down_url按$$$一一对应，每组链接可以是单个URL，
+// 也可以是"名称$链接#名称$链接"的多集格式
+func (p *MacCMSPlugin) parseDownloadLinks(vodDownFrom, vodDownURL string) []model.Link {
+	if vodDownFrom == "" || vodDownURL == "" {
+		return nil
+	}
+
+	fromParts := strings.Split(vodDownFrom, "$$$")
+	urlParts := strings.Split(vodDownURL, "$$$")
+	minLen := len(fromParts)
+	if len(urlParts) < minLen {
+		minLen = len(urlParts)
+	}
+
+	seen := make(map[string]bool)
+	var links []model.Link
+	for i := 0; i < minLen; i++ {
+		fromType := strings.TrimSpace(fromParts[i])
+		for _, episode := range strings.Split(urlParts[i], "#") {
+			linkURL := episode
+			if idx := strings.LastIndex(linkURL, "$"); idx >= 0 {
+				linkURL = linkURL[idx+1:]
+			}
+			linkURL = strings.TrimSpace(linkURL)
+
+			linkType := p.resolveLinkType(fromType, linkURL)
+			if linkType == "" {
+				continue
+			}
+
+			key := linkType + "-" + linkURL
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			links = append(links, model.Link{
+				Type:     linkType,
+				URL:      linkURL,
+				Password: extractPassword(linkURL),
+			})
+		}
+	}
+	return links
+}
+
+// resolveLinkType 确定链接类型：链接必须是支持的网盘格式，
+// 接口配置的映射或内置映射得到的类型只在URL符合该类型时采用，否则根据URL识别
+func (p *MacCMSPlugin) resolveLinkType(apiType, linkURL string) string {
+	urlType := determineLinkType(linkURL)
+	if urlType == "" {
+		return ""
+	}
+
+	code := strings.ToUpper(apiType)
+	linkType := p.endpoint.TypeMapping[code]
+	if linkType == "" {
+		linkType = defaultTypeMapping[code]
+	}
+	// 站点标错的网盘标识很常见，只有URL确实符合标识对应类型时才采用
+	if linkType != "" && matchLinkType(linkType, linkURL) {
+		return linkType
+	}
+	return urlType
+}
+
+// matchLinkType 检查URL是否符合指定网盘类型的格式，没有对应规则的类型视为不符合
+func matchLinkType(linkType, linkURL string) bool {
+	for _, item := range linkTypeRegexes {
+		if item.linkType == linkType {
+			return item.regex.MatchString(linkURL)
+		}
+	}
+	return false
+}
+
+// determineLinkType 根据URL确定链接类型（支持16种类型），不支持的链接返回空字符串
+func determineLinkType(linkURL string) string {
+	if linkURL == "" || strings.Contains(linkURL, "javascript:") ||
+		(!strings.HasPrefix(linkURL, "http") && !strings.HasPrefix(linkURL, "magnet:") && !strings.HasPrefix(linkURL, "ed2k:")) {
+		return ""
+	}
+	for _, item := range linkTypeRegexes {
+		if item.regex.MatchString(linkURL) {
+			return item.linkType
+		}
+	}
+	return ""
+}
+
+// extractPassword 从URL中提取密码
+func extractPassword(linkURL string) string {
+	if matches := passwordRegex.FindStringSubmatch(linkURL); len(matches) > 1 {
+		return matches[1]
+	}
+	if matches := password115Regex.FindStringSubmatch(linkURL); len(matches) > 1 {
+		return matches[1]
+	}
+	return ""
+}
+
+// retryPolicy 采集接口的默认重试策略：JSON API快速重试，尝试次数由接口配置决定
+func (p *MacCMSPlugin) retryPolicy() util.RetryPolicy {
+	policy := util.DefaultRetryPolicy()
+	policy.MaxAttempts = p.endpoint.MaxRetries
+	policy.Backoff = 100 * time.Millisecond
+	policy.MaxBackoff = 100 * time.Millisecond
+	return policy
+}
+
+// GetPerformanceStats 获取性能统计信息
+func (p *MacCMSPlugin) GetPerformanceStats() map[string]interface{} {
+	totalRequests := atomic.LoadInt64(&p.searchRequests)
+	totalTime := atomic.LoadInt64(&p.totalSearchTime)
+
+	var avgTime float64
+	if totalRequests > 0 {
+		avgTime = float64(totalTime) / float64(totalRequests) / 1e6 // 转换为毫秒
+	}
+
+	return map[string]interface{}{
+		"search_requests":      totalRequests,
+		"avg_search_time_ms":   avgTime,
+		"total_search_time_ns": totalTime,
+	}
+}
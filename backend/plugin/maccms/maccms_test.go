@@ -0,0 +1,50 @@
+package maccms_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"pansou/plugin"
+	"pansou/plugin/fixture"
+	"pansou/plugin/maccms"
+)
+
+func TestMacCMSFixture(t *testing.T) {
+	p := maccms.NewMacCMSPlugin(&maccms.Endpoint{
+		Name:     "fixturecms",
+		BaseURLs: []string{"https://vod.example.com"},
+	})
+
+	fixture.Run(t, p, fixture.Case{Name: "search", Keyword: "凡人修仙传"})
+
+	// 站点把百度链接标为KG（夸克）时按链接地址识别
+	fixture.Run(t, p, fixture.Case{Name: "mismatch", Keyword: "三体"})
+}
+
+func TestLoadEndpointsRejectsReservedNameChars(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "maccms.yaml")
+	config := `endpoints:
+  - name: bad-cms
+    base_urls: ["https://vod.example.com"]
+  - name: "bad:cms"
+    base_urls: ["https://vod.example.com"]
+  - name: goodcms
+    base_urls: ["https://vod.example.com"]
+`
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	count, err := maccms.LoadEndpoints(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("应只注册1个合法接口，实际 %d 个", count)
+	}
+	for _, name := range []string{"bad-cms", "bad:cms"} {
+		if _, ok := plugin.GetPluginByName(name); ok {
+			t.Errorf("名称 %q 含有保留字符，不应注册", name)
+		}
+	}
+}
@@ -0,0 +1,391 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultMirrorMaxFailures 镜像连续失败多少次后暂时降级
+	defaultMirrorMaxFailures = 3
+	// defaultMirrorCooldown 镜像降级的持续时间
+	defaultMirrorCooldown = time.Minute
+)
+
+// MirrorOptions 镜像集合选项
+type MirrorOptions struct {
+	HedgeDelay  time.Duration // 当前镜像超过该时间未返回时并行请求下一个镜像，取先成功的结果，0表示不并行
+	Reorder     bool          // 是否按观测到的延迟重新排序，健康的镜像中延迟低的优先
+	MaxFailures int           // 连续失败多少次后暂时降级，默认3
+	Cooldown    time.Duration // 降级持续时间，默认1分钟，期间只在其他镜像都失败时使用
+}
+
+// MirrorConfig 插件配置文件中的镜像设置，未设置的字段使用插件默认值
+type MirrorConfig struct {
+	URLs         []string `json:"urls,omitempty" yaml:"urls,omitempty"`                     // 镜像地址列表，替换插件内置的镜像
+	HedgeDelayMs *int     `json:"hedge_delay_ms,omitempty" yaml:"hedge_delay_ms,omitempty"` // 对冲请求延迟（毫秒），0表示不发送对冲请求
+	Reorder      *bool    `json:"reorder,omitempty" yaml:"reorder,omitempty"`               // 是否按延迟自动排序
+}
+
+// normalize 校验镜像配置
+func (c *MirrorConfig) normalize() error {
+	urls := make([]string, 0, len(c.URLs))
+	for _, u := range c.URLs {
+		u = strings.TrimSuffix(strings.TrimSpace(u), "/")
+		if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+			return fmt.Errorf("镜像地址无效: %q", u)
+		}
+		urls = append(urls, u)
+	}
+	c.URLs = urls
+	if c.HedgeDelayMs != nil && *c.HedgeDelayMs < 0 {
+		return fmt.Errorf("hedge_delay_ms不能为负数")
+	}
+	return nil
+}
+
+// MirrorStats 单个镜像的状态
+type MirrorStats struct {
+	URL                 string     `json:"url"`
+	Healthy             bool       `json:"healthy"`
+	LatencyMs           int64      `json:"latency_ms"` // 平滑延迟（含被取消请求的已耗时间），尚无数据时为0
+	Successes           int64      `json:"successes"`
+	Failures            int64      `json:"failures"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastError           string     `json:"last_error,omitempty"`
+	DownUntil           *time.Time `json:"down_until,omitempty"`
+}
+
+// MirrorSetStatus 镜像集合的状态
+type MirrorSetStatus struct {
+	Plugin       string        `json:"plugin"`
+	HedgeDelayMs int64         `json:"hedge_delay_ms"`
+	Reorder      bool          `json:"reorder"`
+	Mirrors      []MirrorStats `json:"mirrors"` // 按下次请求的尝试顺序排列
+}
+
+// MirrorFunc 向单个镜像发送请求，baseURL为镜像地址，ctx在其他镜像先成功时被取消
+type MirrorFunc func(ctx context.Context, baseURL string) (interface{}, error)
+
+// MirrorSet 多个镜像地址组成的集合：按顺序失败切换，可选对冲请求，并记录每个镜像的健康状态与延迟
+type MirrorSet struct {
+	name        string
+	defaultURLs []string
+	defaultOpts MirrorOptions
+
+	mu      sync.Mutex
+	opts    MirrorOptions
+	mirrors []*mirror
+}
+
+// mirror 单个镜像的运行状态
+type mirror struct {
+	url                 string
+	index               int
+	latency             time.Duration
+	successes           int64
+	failures            int64
+	consecutiveFailures int
+	lastError           string
+	downUntil           time.Time
+}
+
+// mirrorResult 单个镜像的请求结果
+type mirrorResult struct {
+	mirror  *mirror
+	value   interface{}
+	err     error
+	elapsed time.Duration
+}
+
+var (
+	mirrorSets     = make(map[string]*MirrorSet)
+	mirrorSetsLock sync.RWMutex
+)
+
+// NewMirrorSet 创建插件的镜像集合并登记，同名集合会被替换。插件配置文件中的mirrors会覆盖这里的默认地址与选项
+func NewMirrorSet(name string, urls []string, opts MirrorOptions) *MirrorSet {
+	if opts.MaxFailures <= 0 {
+		opts.MaxFailures = defaultMirrorMaxFailures
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = defaultMirrorCooldown
+	}
+	s := &MirrorSet{
+		name:        name,
+		defaultURLs: append([]string(nil), urls...),
+		defaultOpts: opts,
+	}
+	s.configure(GetPluginConfig(name).Mirrors)
+
+	mirrorSetsLock.Lock()
+	mirrorSets[name] = s
+	mirrorSetsLock.Unlock()
+	return s
+}
+
+// GetMirrorSet 获取插件的镜像集合，插件未使用镜像集合时返回nil
+func GetMirrorSet(name string) *MirrorSet {
+	mirrorSetsLock.RLock()
+	defer mirrorSetsLock.RUnlock()
+	return mirrorSets[name]
+}
+
+// ListMirrorSets 获取所有镜像集合的状态，按插件名排序
+func ListMirrorSets() []MirrorSetStatus {
+	mirrorSetsLock.RLock()
+	list := make([]MirrorSetStatus, 0, len(mirrorSets))
+	for _, s := range mirrorSets {
+		list = append(list, s.Status())
+	}
+	mirrorSetsLock.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Plugin < list[j].Plugin })
+	return list
+}
+
+// setMirrorConfigs 按插件配置重新配置已登记的镜像集合，未配置mirrors的集合恢复默认值
+func setMirrorConfigs(configs map[string]PluginConfig) {
+	mirrorSetsLock.RLock()
+	defer mirrorSetsLock.RUnlock()
+	for name, s := range mirrorSets {
+		s.configure(configs[name].Mirrors)
+	}
+}
+
+// configure 应用镜像配置，镜像列表变化时重置健康统计
+func (s *MirrorSet) configure(cfg *MirrorConfig) {
+	urls := s.defaultURLs
+	opts := s.defaultOpts
+	if cfg != nil {
+		if len(cfg.URLs) > 0 {
+			urls = cfg.URLs
+		}
+		if cfg.HedgeDelayMs != nil {
+			opts.HedgeDelay = time.Duration(*cfg.HedgeDelayMs) * time.Millisecond
+		}
+		if cfg.Reorder != nil {
+			opts.Reorder = *cfg.Reorder
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.opts = opts
+	if s.sameURLs(urls) {
+		return
+	}
+	s.mirrors = make([]*mirror, len(urls))
+	for i, u := range urls {
+		s.mirrors[i] = &mirror{url: u, index: i}
+	}
+}
+
+// sameURLs 镜像列表是否与当前一致（调用方持有锁）
+func (s *MirrorSet) sameURLs(urls []string) bool {
+	if len(urls) != len(s.mirrors) {
+		return false
+	}
+	for i, m := range s.mirrors {
+		if m.url != urls[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// URLs 按下次请求的尝试顺序返回镜像地址
+func (s *MirrorSet) URLs() []string {
+	order, _ := s.order()
+	urls := make([]string, len(order))
+	for i, m := range order {
+		urls[i] = m.url
+	}
+	return urls
+}
+
+// Do 依次向镜像发送请求直到成功：当前镜像失败时立即请求下一个，设置了对冲延迟时超时未返回也会并行请求下一个，
+// 返回最先成功的结果并取消其他请求。所有镜像都失败时返回最后一个错误
+func (s *MirrorSet) Do(ctx context.Context, fn MirrorFunc) (interface{}, error) {
+	order, opts := s.order()
+	if len(order) == 0 {
+		return nil, fmt.Errorf("[%s] 未配置镜像地址", s.name)
+	}
+
+	attemptCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan mirrorResult, len(order))
+	next := 0
+	inflight := make(map[*mirror]time.Time)
+	launch := func() {
+		m := order[next]
+		next++
+		inflight[m] = time.Now()
+		go func() {
+			start := time.Now()
+			var value interface{}
+			var err error
+			// fn panic时转换为错误继续尝试其他镜像，结果总会送回，避免Do一直等待
+			defer func() {
+				if r := recover(); r != nil {
+					value, err = nil, HandlePanic(s.name, r)
+				}
+				results <- mirrorResult{mirror: m, value: value, err: err, elapsed: time.Since(start)}
+			}()
+			value, err = fn(attemptCtx, m.url)
+		}()
+	}
+
+	var hedgeTimer *time.Timer
+	var hedgeC <-chan time.Time
+	resetHedge := func() {
+		if hedgeTimer != nil {
+			hedgeTimer.Stop()
+		}
+		hedgeTimer, hedgeC = nil, nil
+		if opts.HedgeDelay > 0 && next < len(order) {
+			hedgeTimer = time.NewTimer(opts.HedgeDelay)
+			hedgeC = hedgeTimer.C
+		}
+	}
+	defer func() {
+		if hedgeTimer != nil {
+			hedgeTimer.Stop()
+		}
+	}()
+
+	launch()
+	resetHedge()
+
+	var lastErr error
+	for len(inflight) > 0 {
+		select {
+		case r := <-results:
+			delete(inflight, r.mirror)
+			if r.err == nil {
+				s.recordSuccess(r.mirror, r.elapsed)
+				// 被取消的对冲请求至少用了这么长时间，避免慢镜像因为没有延迟数据一直排在前面
+				for m, started := range inflight {
+					s.recordSlow(m, time.Since(started))
+				}
+				return r.value, nil
+			}
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			s.recordFailure(r.mirror, r.err)
+			lastErr = fmt.Errorf("%s: %w", r.mirror.url, r.err)
+			if next < len(order) {
+				launch()
+				resetHedge()
+			}
+		case <-hedgeC:
+			launch()
+			resetHedge()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return nil, fmt.Errorf("[%s] 所有镜像都请求失败: %w", s.name, lastErr)
+}
+
+// order 返回本次请求的镜像尝试顺序：健康的镜像优先（开启排序时按延迟），降级的镜像按恢复时间排在最后
+func (s *MirrorSet) order() ([]*mirror, MirrorOptions) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	order := append([]*mirror(nil), s.mirrors...)
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		aDown, bDown := a.downUntil.After(now), b.downUntil.After(now)
+		if aDown != bDown {
+			return !aDown
+		}
+		if aDown {
+			return a.downUntil.Before(b.downUntil)
+		}
+		if s.opts.Reorder && a.latency != b.latency {
+			// 尚无成功请求的镜像延迟为0，会被优先尝试以获得延迟数据
+			return a.latency < b.latency
+		}
+		return a.index < b.index
+	})
+	return order, s.opts
+}
+
+// recordSuccess 记录镜像请求成功，延迟按指数加权平滑
+func (s *MirrorSet) recordSuccess(m *mirror, elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m.successes == 0 {
+		m.latency = elapsed
+	} else {
+		m.latency = (m.latency*7 + elapsed*3) / 10
+	}
+	m.successes++
+	m.consecutiveFailures = 0
+	m.downUntil = time.Time{}
+}
+
+// recordSlow 记录被取消的请求已经耗费的时间，只在延迟比当前记录更高时更新
+func (s *MirrorSet) recordSlow(m *mirror, elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case elapsed <= m.latency:
+	case m.successes == 0:
+		m.latency = elapsed
+	default:
+		m.latency = (m.latency*7 + elapsed*3) / 10
+	}
+}
+
+// recordFailure 记录镜像请求失败，连续失败达到上限时暂时降级
+func (s *MirrorSet) recordFailure(m *mirror, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m.failures++
+	m.consecutiveFailures++
+	m.lastError = err.Error()
+	if m.consecutiveFailures >= s.opts.MaxFailures {
+		m.downUntil = time.Now().Add(s.opts.Cooldown)
+	}
+}
+
+// Status 获取镜像集合的状态
+func (s *MirrorSet) Status() MirrorSetStatus {
+	order, opts := s.order()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	status := MirrorSetStatus{
+		Plugin:       s.name,
+		HedgeDelayMs: opts.HedgeDelay.Milliseconds(),
+		Reorder:      opts.Reorder,
+		Mirrors:      make([]MirrorStats, 0, len(order)),
+	}
+	for _, m := range order {
+		stats := MirrorStats{
+			URL:                 m.url,
+			Healthy:             !m.downUntil.After(now),
+			LatencyMs:           m.latency.Milliseconds(),
+			Successes:           m.successes,
+			Failures:            m.failures,
+			ConsecutiveFailures: m.consecutiveFailures,
+			LastError:           m.lastError,
+		}
+		if !stats.Healthy {
+			downUntil := m.downUntil
+			stats.DownUntil = &downUntil
+		}
+		status.Mirrors = append(status.Mirrors, stats)
+	}
+	return status
+}
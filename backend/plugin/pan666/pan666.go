@@ -28,6 +28,8 @@ const (
 	
 	// 默认参数
 	PageSize = 50 // 符合API实际返回数量
+	DefaultPages = 2 // 默认请求的页数
+	DeepPages    = 6 // 深度搜索时的最大页数
 	MaxRetries = 2
 )
 
@@ -74,8 +76,8 @@ func (p *Pan666AsyncPlugin) doSearch(client *http.Client, keyword string, ext ma
 	// 初始化随机数种子
 	rand.Seed(time.Now().UnixNano())
 	
-	// 只并发请求2个页面（0-1页）
-	allResults, _, err := p.fetchBatch(client, keyword, 0, 2)
+	// 默认并发请求2个页面（0-1页），页数随搜索深度调整
+	allResults, _, err := p.fetchBatch(client, keyword, 0, p.PageLimit(ext, DefaultPages, DeepPages))
 	if err != nil {
 		return nil, err
 	}
@@ -106,6 +108,7 @@ func (p *Pan666AsyncPlugin) fetchBatch(client *http.Client, keyword string, star
 		
 		go func(offset int, index int) {
 			defer wg.Done()
+			defer plugin.RecoverPanic(p.Name())
 			
 			// 第一个请求立即执行，后续请求添加随机延迟
 			if index > 0 {
@@ -133,6 +136,7 @@ func (p *Pan666AsyncPlugin) fetchBatch(client *http.Client, keyword string, star
 	
 	// 等待所有请求完成
 	go func() {
+		defer plugin.RecoverPanic(p.Name())
 		wg.Wait()
 		close(resultChan)
 	}()
@@ -0,0 +1,119 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"time"
+
+	"pansou/config"
+	"pansou/model"
+)
+
+// PanicError 插件代码panic后转换成的错误
+type PanicError struct {
+	Plugin string      // 插件名称
+	Value  interface{} // panic的值
+	Stack  string      // panic时的调用栈
+}
+
+// Error 实现error接口
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("[%s] 插件panic: %v", e.Plugin, e.Value)
+}
+
+// IsPanicError 判断错误是否由插件panic转换而来
+func IsPanicError(err error) bool {
+	var panicErr *PanicError
+	return errors.As(err, &panicErr)
+}
+
+// HandlePanic 处理插件panic：记录调用栈、计入插件统计，panic次数过多时自动禁用插件，返回对应的错误。
+// 必须在recover()之后调用
+func HandlePanic(name string, value interface{}) error {
+	err := &PanicError{Plugin: name, Value: value, Stack: string(debug.Stack())}
+	log.Printf("[plugin] 插件 %s panic: %v\n%s", name, value, err.Stack)
+
+	if recordPluginPanic(name) {
+		if disableErr := SetPluginEnabled(name, false); disableErr != nil {
+			log.Printf("[plugin] 自动禁用插件 %s 失败: %v", name, disableErr)
+		} else {
+			log.Printf("[plugin] 插件 %s 在 %v 内panic达到 %d 次，已自动禁用",
+				name, config.AppConfig.PluginPanicWindow, config.AppConfig.PluginPanicDisableThreshold)
+		}
+	}
+	return err
+}
+
+// RecoverPanic 在插件自行启动的协程中以defer调用，恢复panic并计入插件统计：
+//
+//	go func() {
+//		defer plugin.RecoverPanic(p.Name())
+//		...
+//	}()
+func RecoverPanic(name string) {
+	if r := recover(); r != nil {
+		HandlePanic(name, r)
+	}
+}
+
+// RecoverError 在返回error的函数中以defer调用，恢复panic并把PanicError写入返回的错误，
+// 用于调用方依赖返回值（如按任务数收集结果）的场景：
+//
+//	func (p *MyPlugin) fetchPage(page int) (items []Item, err error) {
+//		defer plugin.RecoverError(p.Name(), &err)
+//		...
+//	}
+func RecoverError(name string, errp *error) {
+	if r := recover(); r != nil {
+		*errp = HandlePanic(name, r)
+	}
+}
+
+// SafeCall 执行插件搜索代码，panic时转换为PanicError返回
+func SafeCall(name string, fn func() ([]model.SearchResult, error)) (results []model.SearchResult, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			results = nil
+			err = HandlePanic(name, r)
+		}
+	}()
+	return fn()
+}
+
+// recordPluginPanic 记录一次插件panic，返回是否达到自动禁用阈值
+func recordPluginPanic(name string) bool {
+	m := getPluginMetrics(name)
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.panics++
+	m.lastPanicTime = now
+
+	threshold := 0
+	window := time.Duration(0)
+	if config.AppConfig != nil {
+		threshold = config.AppConfig.PluginPanicDisableThreshold
+		window = config.AppConfig.PluginPanicWindow
+	}
+	if threshold <= 0 {
+		return false
+	}
+
+	// 只保留统计窗口内的panic时间
+	recent := m.recentPanics[:0]
+	for _, t := range m.recentPanics {
+		if now.Sub(t) < window {
+			recent = append(recent, t)
+		}
+	}
+	m.recentPanics = append(recent, now)
+	if len(m.recentPanics) < threshold {
+		return false
+	}
+	m.recentPanics = nil
+	return true
+}
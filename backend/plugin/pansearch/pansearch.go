@@ -42,6 +42,8 @@ func init() {
 
 // startCacheCleaner 启动一个定期清理缓存的goroutine
 func startCacheCleaner() {
+	defer plugin.RecoverPanic("pansearch")
+	
 	// 每小时清理一次缓存
 	ticker := time.NewTicker(1 * time.Hour)
 	defer ticker.Stop()
@@ -102,7 +104,6 @@ type PanSearchAsyncPlugin struct {
 	maxResults    int
 	maxConcurrent int
 	retries       int
-	workerPool    *WorkerPool // 添加工作池
 }
 
 // WorkerPool 工作池结构
@@ -150,7 +151,7 @@ func (wp *WorkerPool) Start(ctx context.Context, handler func(ctx context.Contex
 						return
 					}
 
-					result, err := handler(ctx, task)
+					result, err := runTask(ctx, handler, task)
 					if err != nil {
 						select {
 						case wp.errors <- err:
@@ -177,6 +178,12 @@ func (wp *WorkerPool) Start(ctx context.Context, handler func(ctx context.Contex
 	}
 }
 
+// runTask 执行任务，panic时转换为错误，保证每个已提交的任务都会返回结果或错误
+func runTask(ctx context.Context, handler func(ctx context.Context, task Task) (TaskResult, error), task Task) (result TaskResult, err error) {
+	defer plugin.RecoverError("pansearch", &err)
+	return handler(ctx, task)
+}
+
 // Submit 提交任务到工作池
 func (wp *WorkerPool) Submit(task Task) bool {
 	wp.mu.Lock()
@@ -241,11 +248,11 @@ func NewPanSearchPlugin() *PanSearchAsyncPlugin {
 		maxResults:      MaxResults,
 		maxConcurrent:   maxConcurrent,
 		retries:         MaxRetries,
-		workerPool:      NewWorkerPool(maxConcurrent), // 初始化工作池
 	}
 
 	// 初始化时预热获取 buildId
 	go func() {
+		defer plugin.RecoverPanic(p.Name())
 		_, err := p.getBuildId()
 		if err != nil {
 			fmt.Printf("预热获取 buildId 失败: %v\n", err)
@@ -253,7 +260,10 @@ func NewPanSearchPlugin() *PanSearchAsyncPlugin {
 	}()
 
 	// 启动后台 buildId 更新器
-	go p.startBuildIdUpdater()
+	go func() {
+		defer plugin.RecoverPanic(p.Name())
+		p.startBuildIdUpdater()
+	}()
 
 	return p
 }
@@ -359,11 +369,6 @@ func (p *PanSearchAsyncPlugin) Name() string {
 	return "pansearch"
 }
 
-// Priority 返回插件优先级
-func (p *PanSearchAsyncPlugin) Priority() int {
-	return 3 // 中等优先级
-}
-
 // getBuildId 获取buildId，优先使用缓存
 func (p *PanSearchAsyncPlugin) getBuildId() (string, error) {
 	// 检查缓存是否有效
@@ -504,6 +509,9 @@ func (p *PanSearchAsyncPlugin) SearchWithResult(keyword string, ext map[string]i
 
 // doSearch 执行具体的搜索逻辑
 func (p *PanSearchAsyncPlugin) doSearch(client *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
+	// 按搜索深度计算最大结果数
+	maxResults := p.PageLimit(ext, p.maxResults/PageSize, MaxAPIPages) * PageSize
+	
 	// 获取API基础URL
 	baseURL, err := p.getBaseURL(client)
 	if err != nil {
@@ -536,7 +544,10 @@ func (p *PanSearchAsyncPlugin) doSearch(client *http.Client, keyword string, ext
 			}
 
 			// 成功刷新后，触发后台更新以保持最新状态
-			go p.updateBuildId()
+			go func() {
+				defer plugin.RecoverPanic(p.Name())
+				p.updateBuildId()
+			}()
 		} else {
 			return nil, fmt.Errorf("获取首页失败: %w", err)
 		}
@@ -545,7 +556,7 @@ func (p *PanSearchAsyncPlugin) doSearch(client *http.Client, keyword string, ext
 	allResults := firstPageResults
 
 	// 2. 计算需要的页数，但限制在最大结果数内和API最大页数内
-	remainingResults := min(total-PageSize, p.maxResults-PageSize)
+	remainingResults := min(total-PageSize, maxResults-PageSize)
 	if remainingResults <= 0 {
 		results := p.convertResults(allResults, keyword)
 		
@@ -577,8 +588,8 @@ func (p *PanSearchAsyncPlugin) doSearch(client *http.Client, keyword string, ext
 	// 根据实际页数确定并发数，但不超过最大并发数
 	actualConcurrent := min(neededPages, p.maxConcurrent)
 
-	// 创建适合实际并发数的工作池
-	p.workerPool = NewWorkerPool(actualConcurrent)
+	// 创建适合实际并发数的工作池（每次搜索独立，避免并发搜索互相替换）
+	workerPool := NewWorkerPool(actualConcurrent)
 
 	// 创建上下文用于管理所有请求
 	ctx, cancel := context.WithTimeout(context.Background(), p.timeout*2)
@@ -588,7 +599,7 @@ func (p *PanSearchAsyncPlugin) doSearch(client *http.Client, keyword string, ext
 	needRefreshBuildId := &atomic.Bool{}
 
 	// 启动工作池
-	p.workerPool.Start(ctx, func(ctx context.Context, task Task) (TaskResult, error) {
+	workerPool.Start(ctx, func(ctx context.Context, task Task) (TaskResult, error) {
 		var pageResults []PanSearchItem
 		var err error
 
@@ -611,6 +622,10 @@ func (p *PanSearchAsyncPlugin) doSearch(client *http.Client, keyword string, ext
 					needRefreshBuildId.Store(true)
 					// 在一个新的协程中刷新buildId
 					go func() {
+						defer plugin.RecoverPanic(p.Name())
+						// 无论是否成功都重置标志
+						defer needRefreshBuildId.Store(false)
+						
 						buildIdMutex.Lock()
 						buildIdCache = ""              // 清空缓存
 						buildIdCacheTime = time.Time{} // 重置缓存时间
@@ -623,9 +638,6 @@ func (p *PanSearchAsyncPlugin) doSearch(client *http.Client, keyword string, ext
 							task.baseURL = fmt.Sprintf(BaseURLTemplate, newBuildId)
 							fmt.Printf("成功刷新buildId: %s\n", newBuildId)
 						}
-
-						// 重置标志
-						needRefreshBuildId.Store(false)
 					}()
 				}
 
@@ -690,7 +702,7 @@ func (p *PanSearchAsyncPlugin) doSearch(client *http.Client, keyword string, ext
 		// 提交一批任务
 		for j := i; j < end; j++ {
 			offset := PageSize + j*PageSize
-			if offset < p.maxResults {
+			if offset < maxResults {
 				task := Task{
 					keyword: keyword,
 					offset:  offset,
@@ -698,7 +710,7 @@ func (p *PanSearchAsyncPlugin) doSearch(client *http.Client, keyword string, ext
 				}
 
 				// 尝试提交任务，如果失败则跳出循环
-				if !p.workerPool.Submit(task) {
+				if !workerPool.Submit(task) {
 					fmt.Printf("无法提交任务，工作池可能已关闭\n")
 					goto CollectResults
 				}
@@ -721,7 +733,10 @@ func (p *PanSearchAsyncPlugin) doSearch(client *http.Client, keyword string, ext
 
 CollectResults:
 	// 关闭任务提交通道
-	go p.workerPool.Close()
+	go func() {
+		defer plugin.RecoverPanic(p.Name())
+		workerPool.Close()
+	}()
 
 	// 收集结果
 	resultCount := 0
@@ -731,7 +746,7 @@ CollectResults:
 	// 使用select非阻塞地收集结果和错误
 	for resultCount+errorCount < submittedTasks {
 		select {
-		case result, ok := <-p.workerPool.results:
+		case result, ok := <-workerPool.results:
 			if !ok {
 				// 结果通道已关闭
 				goto ProcessResults
@@ -739,7 +754,7 @@ CollectResults:
 			allResults = append(allResults, result.results...)
 			resultCount++
 
-		case err, ok := <-p.workerPool.errors:
+		case err, ok := <-workerPool.errors:
 			if !ok {
 				// 错误通道已关闭
 				goto ProcessResults
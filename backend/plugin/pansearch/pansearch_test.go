@@ -0,0 +1,35 @@
+package pansearch
+
+import (
+	"testing"
+	"time"
+
+	"pansou/plugin"
+	"pansou/plugin/fixture"
+)
+
+func TestPanSearchFixture(t *testing.T) {
+	// 预置buildId，使回放的请求地址固定，也避免init中预热获取buildId的请求混入回放
+	buildIdMutex.Lock()
+	buildIdCache, buildIdCacheTime = "fixture-build", time.Now()
+	buildIdMutex.Unlock()
+
+	// total为12时先取首页，再按偏移量10取第二页，两页中重复的资源按id去重
+	fixture.RunRegistered(t, "pansearch", fixture.Case{Name: "search", Keyword: "凡人修仙传"})
+}
+
+func TestPriorityOverride(t *testing.T) {
+	// 插件曾自行覆盖Priority()，导致管理后台设置的优先级不生效
+	p, ok := plugin.GetPluginByName("pansearch")
+	if !ok {
+		t.Fatal("插件 pansearch 未注册")
+	}
+	if err := plugin.SetPluginPriority("pansearch", 4); err != nil {
+		t.Fatalf("设置优先级失败: %v", err)
+	}
+	defer plugin.SetPluginPriority("pansearch", 0)
+
+	if got := p.Priority(); got != 4 {
+		t.Errorf("Priority() = %d，应返回覆盖值 4", got)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net/url"
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 	"regexp"
 	"strings"
 	"sync"
@@ -189,6 +190,8 @@ func NewPantaAsyncPlugin() *PantaAsyncPlugin {
 
 // startCacheCleaner 启动一个定期清理缓存的goroutine
 func startCacheCleaner() {
+	defer plugin.RecoverPanic("panta")
+	
 	// 每小时清理一次缓存
 	ticker := time.NewTicker(1 * time.Hour)
 	defer ticker.Stop()
@@ -211,11 +214,6 @@ func (p *PantaAsyncPlugin) Name() string {
 	return pluginName
 }
 
-// Priority 返回插件优先级
-func (p *PantaAsyncPlugin) Priority() int {
-	return defaultPriority
-}
-
 // Search 执行搜索并返回结果（兼容性方法）
 func (p *PantaAsyncPlugin) Search(keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
 	result, err := p.SearchWithResult(keyword, ext)
@@ -322,6 +320,7 @@ func (p *PantaAsyncPlugin) parseSearchResults(doc *goquery.Document, client *htt
 		// 为每个话题创建一个goroutine
 		go func(index int, s *goquery.Selection) {
 			defer wg.Done()
+			defer plugin.RecoverPanic(p.Name())
 			
 			// 获取信号量，限制并发数
 			semaphore <- struct{}{}
@@ -443,6 +442,7 @@ func (p *PantaAsyncPlugin) parseSearchResults(doc *goquery.Document, client *htt
 	
 	// 等待所有goroutine完成
 	go func() {
+		defer plugin.RecoverPanic(p.Name())
 		wg.Wait()
 		close(resultChan)
 		close(errorChan)
@@ -809,6 +809,7 @@ func extractTextLinks(text string, yearFromTitle string) []model.Link {
 		wg.Add(1)
 		go func(pattern *regexp.Regexp) {
 			defer wg.Done()
+			defer plugin.RecoverPanic("panta")
 			
 			// 获取信号量
 			semaphore <- struct{}{}
@@ -866,6 +867,7 @@ func extractTextLinks(text string, yearFromTitle string) []model.Link {
 			wg.Add(1)
 			go func(pattern *regexp.Regexp) {
 				defer wg.Done()
+				defer plugin.RecoverPanic("panta")
 				
 				// 获取信号量
 				semaphore <- struct{}{}
@@ -1249,46 +1251,16 @@ func (p *PantaAsyncPlugin) recordResponseTime(d time.Duration) {
 	p.responseTimes = append(p.responseTimes, d)
 }
 
-// doRequestWithRetry 发送HTTP请求，带重试机制
+// doRequestWithRetry 按插件的重试策略发送HTTP请求，并记录响应时间用于调整并发数
 func (p *PantaAsyncPlugin) doRequestWithRetry(req *http.Request, client *http.Client) (*http.Response, error) {
-	var resp *http.Response
-	var err error
-	var startTime time.Time
-	
-	// 重试循环
-	for retry := 0; retry <= maxRetries; retry++ {
-		// 如果不是第一次尝试，则等待一段时间
-		if retry > 0 {
-			// 使用指数退避算法计算等待时间
-			backoffTime := time.Duration(min(backoffBase*1<<uint(retry-1), maxBackoff)) * time.Millisecond
-			time.Sleep(backoffTime)
-			
-			// 创建新的请求，因为原请求可能已经被关闭
-			newReq := req.Clone(req.Context())
-			req = newReq
-		}
-		
-		// 记录开始时间
-		startTime = time.Now()
-		
-		// 发送请求
-		resp, err = client.Do(req)
-		
-		// 记录响应时间
-		responseTime := time.Since(startTime)
-		p.recordResponseTime(responseTime)
-		
-		// 如果请求成功，或者是不可重试的错误，则退出重试循环
-		if err == nil && resp.StatusCode < 500 {
-			break
-		}
-		
-		// 如果请求失败，但响应不为nil，则关闭响应体
-		if resp != nil {
-			resp.Body.Close()
-		}
-	}
-	
+	policy := util.DefaultRetryPolicy()
+	policy.MaxAttempts = maxRetries + 1
+	policy.Backoff = backoffBase * time.Millisecond
+	policy.MaxBackoff = maxBackoff * time.Millisecond
+
+	startTime := time.Now()
+	resp, err := p.DoWithRetryPolicy(req, client, policy)
+	p.recordResponseTime(time.Since(startTime))
 	return resp, err
 }
 
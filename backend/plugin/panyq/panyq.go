@@ -5,7 +5,6 @@ import (
 	"pansou/util/json"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -18,6 +17,7 @@ import (
 
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 )
 
 // 常量定义
@@ -36,6 +36,9 @@ const (
 	BaseURL = "https://panyq.com"
 	// 请求来源控制默认为开启状态
 	EnableRefererCheck = true
+	// 默认获取的页数与深度搜索时的最大页数
+	DefaultPages = 3
+	DeepPages    = 10
 )
 
 // 动态Action ID的键名
@@ -111,7 +114,7 @@ func NewPanyqPlugin() *PanyqPlugin {
 	
 	client := &http.Client{
 		Timeout:   DefaultTimeout,
-		Transport: transport,
+		Transport: util.NewOutboundTransport(transport),
 		Jar:       jar, // 使用Cookie管理
 		// 自动处理重定向
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
@@ -128,14 +131,24 @@ func NewPanyqPlugin() *PanyqPlugin {
 	}
 }
 
+// ExtSchema 声明支持的ext参数
+func (p *PanyqPlugin) ExtSchema() []plugin.ExtParam {
+	return []plugin.ExtParam{
+		{Name: "referer", Type: plugin.ExtTypeString, Description: "请求来源，启用来源检查时必须以允许的地址开头"},
+	}
+}
+
 // Search 执行搜索并返回结果
 func (p *PanyqPlugin) Search(keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
 	if DebugLog {
 		fmt.Println("panyq: ext 参数内容:", ext)
 	}
 
-	// 检查搜索结果缓存
+	// 检查搜索结果缓存（不同搜索深度分别缓存）
 	cacheKey := fmt.Sprintf("search:%s", keyword)
+	if suffix := plugin.GetSearchOptions(ext).CacheKey(); suffix != "" {
+		cacheKey += "|" + suffix
+	}
 	searchResultCacheLock.RLock()
 	if cachedResults, ok := searchResultCache[cacheKey]; ok {
 		searchResultCacheLock.RUnlock()
@@ -243,8 +256,8 @@ func (p *PanyqPlugin) doSearch(client *http.Client, keyword string, ext map[stri
 		if DebugLog {
 			fmt.Printf("panyq: found %d pages, fetching additional pages...\n", maxPageNum)
 		}
-		if maxPageNum >= 3 {
-			maxPageNum = 3
+		if maxPages := p.PageLimit(ext, DefaultPages, DeepPages); maxPageNum > maxPages {
+			maxPageNum = maxPages
 		}
 		// 创建通道存储其他页的结果
 		hitsChan := make(chan []SearchHit, maxPageNum-1)
@@ -255,6 +268,7 @@ func (p *PanyqPlugin) doSearch(client *http.Client, keyword string, ext map[stri
 			wg.Add(1)
 			go func(pageNum int) {
 				defer wg.Done()
+				defer plugin.RecoverPanic(p.Name())
 				
 				if DebugLog {
 					fmt.Printf("panyq: fetching page %d...\n", pageNum)
@@ -272,6 +286,7 @@ func (p *PanyqPlugin) doSearch(client *http.Client, keyword string, ext map[stri
 		
 		// 等待所有页面获取完成
 		go func() {
+			defer plugin.RecoverPanic(p.Name())
 			wg.Wait()
 			close(hitsChan)
 		}()
@@ -300,6 +315,7 @@ func (p *PanyqPlugin) doSearch(client *http.Client, keyword string, ext map[stri
 		
 		go func(index int, item SearchHit) {
 			defer wg.Done()
+			defer plugin.RecoverPanic(p.Name())
 			defer func() { <-sem }() // 释放信号量
 			
 			// 步骤3: 执行中间状态确认
@@ -349,6 +365,7 @@ func (p *PanyqPlugin) doSearch(client *http.Client, keyword string, ext map[stri
 	
 	// 启动协程等待所有任务完成并关闭通道
 	go func() {
+		defer plugin.RecoverPanic(p.Name())
 		wg.Wait()
 		close(resultChan)
 	}()
@@ -451,6 +468,7 @@ func (p *PanyqPlugin) discoverActionIDs() (map[string]string, error) {
 		wg.Add(1)
 		go func(index int, actionID string) {
 			defer wg.Done()
+			defer plugin.RecoverPanic(p.Name())
 			if DebugLog {
 				fmt.Printf("panyq: 并发尝试第 %d 个ID作为credential_action_id: %.10s...\n", index+1, actionID)
 			}
@@ -777,57 +795,14 @@ func (p *PanyqPlugin) validateFinalLinkID(actionID, testEID string) bool {
 	return false
 }
 
-// doRequestWithRetry 发送HTTP请求并支持重试
-func (p *PanyqPlugin) doRequestWithRetry(client *http.Client, req *http.Request, maxRetries int) (*http.Response, error) {
-	var resp *http.Response
-	var err error
-	
-	for i := 0; i <= maxRetries; i++ {
-		// 如果不是第一次尝试，等待一段时间
-		if i > 0 {
-			// 指数退避算法
-			backoff := time.Duration(1<<uint(i-1)) * 500 * time.Millisecond
-			if backoff > 5*time.Second {
-				backoff = 5 * time.Second
-			}
-			time.Sleep(backoff)
-			
-			if DebugLog {
-				fmt.Printf("panyq: 重试请求 #%d，等待 %v\n", i, backoff)
-			}
-		}
-		
-		// 克隆请求，避免重用同一个请求对象
-		reqClone := req.Clone(req.Context())
-		
-		// 发送请求
-		resp, err = client.Do(reqClone)
-		
-		// 如果请求成功或者是不可重试的错误，则退出循环
-		if err == nil || !isRetriableError(err) {
-			break
-		}
-	}
-	
-	return resp, err
-}
-
-// isRetriableError 判断错误是否可以重试
-func isRetriableError(err error) bool {
-	if err == nil {
-		return false
-	}
-	
-	// 判断是否是网络错误或超时错误
-	if netErr, ok := err.(net.Error); ok {
-		return netErr.Timeout() || netErr.Temporary()
-	}
-	
-	// 其他可能需要重试的错误类型
-	errStr := err.Error()
-	return strings.Contains(errStr, "connection refused") ||
-		   strings.Contains(errStr, "connection reset") ||
-		   strings.Contains(errStr, "EOF")
+// retryPolicy 插件的默认重试策略，尝试次数由MaxRetries决定，搜索接口的POST请求没有副作用，允许重试
+func retryPolicy() util.RetryPolicy {
+	policy := util.DefaultRetryPolicy()
+	policy.MaxAttempts = MaxRetries + 1
+	policy.Backoff = 500 * time.Millisecond
+	policy.MaxBackoff = 5 * time.Second
+	policy.RetryNonIdempotent = true
+	return policy
 }
 
 // getRawFinalLinkResponse 获取最终链接的原始响应文本
@@ -900,7 +875,7 @@ func (p *PanyqPlugin) getRawFinalLinkResponse(actionID, eid string, client *http
 	}
 	
 	// 发送请求并支持重试
-	resp, err := p.doRequestWithRetry(client, req, MaxRetries)
+	resp, err := p.DoWithRetryPolicy(req, client, retryPolicy())
 	if err != nil {
 		// 网络错误等情况下返回空字符串和错误
 		if DebugLog {
@@ -958,7 +933,7 @@ func (p *PanyqPlugin) getCredentials(query, actionID string, client *http.Client
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/138.0.0.0 Safari/537.36")
 	
 	// 发送请求并支持重试
-	resp, err := p.doRequestWithRetry(client, req, MaxRetries)
+	resp, err := p.DoWithRetryPolicy(req, client, retryPolicy())
 	if err != nil {
 		return nil, err
 	}
@@ -1015,7 +990,7 @@ func (p *PanyqPlugin) getSearchResults(sign string, pageNum int, client *http.Cl
 	actionIDCacheLock.RUnlock()
 	
 	// 发送请求并支持重试
-	resp, err := p.doRequestWithRetry(client, req, MaxRetries)
+	resp, err := p.DoWithRetryPolicy(req, client, retryPolicy())
 	if err != nil {
 		return nil, 0, err
 	}
@@ -1086,7 +1061,7 @@ func (p *PanyqPlugin) performIntermediateStep(actionID, hashVal, shaVal, eid str
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/138.0.0.0 Safari/537.36")
 	
 	// 发送请求并支持重试
-	resp, err := p.doRequestWithRetry(client, req, MaxRetries)
+	resp, err := p.DoWithRetryPolicy(req, client, retryPolicy())
 	if err != nil {
 		return err
 	}
@@ -1338,6 +1313,8 @@ func init() {
 
 // 启动缓存清理器
 func startCacheCleaner() {
+	defer plugin.RecoverPanic("panyq")
+	
 	ticker := time.NewTicker(30 * time.Minute)
 	defer ticker.Stop()
 	
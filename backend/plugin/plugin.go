@@ -110,9 +110,22 @@ func (pm *PluginManager) RegisterAllGlobalPlugins() {
 	}
 }
 
-// GetPlugins 获取所有注册的异步插件
+// GetPlugins 获取所有启用的异步插件（不含管理后台禁用的插件）
 func (pm *PluginManager) GetPlugins() []AsyncSearchPlugin {
-	return pm.plugins
+	plugins := make([]AsyncSearchPlugin, 0, len(pm.plugins))
+	for _, p := range pm.plugins {
+		if IsPluginEnabled(p.Name()) {
+			plugins = append(plugins, p)
+		}
+	}
+	return plugins
+}
+
+// GetAllPlugins 获取所有注册的异步插件（包含已禁用的插件）
+func (pm *PluginManager) GetAllPlugins() []AsyncSearchPlugin {
+	plugins := make([]AsyncSearchPlugin, len(pm.plugins))
+	copy(plugins, pm.plugins)
+	return plugins
 }
 
 // FilterResultsByKeyword 根据关键词过滤搜索结果的全局辅助函数
@@ -0,0 +1,267 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"pansou/util"
+)
+
+// PluginConfig 单个插件的配置（来自插件配置文件），未设置的字段使用插件内置默认值
+type PluginConfig struct {
+	BaseURL  string            `json:"base_url,omitempty" yaml:"base_url,omitempty"`   // 站点地址覆盖，用于站点迁移
+	Proxy    string            `json:"proxy,omitempty" yaml:"proxy,omitempty"`         // 代理地址，支持 http://、https://、socks5://
+	ProxyTag string            `json:"proxy_tag,omitempty" yaml:"proxy_tag,omitempty"` // 使用代理池中指定标签的代理（每个请求轮换），与proxy互斥
+	Headers  map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`     // 额外请求头，覆盖插件默认请求头
+	Cookies  map[string]string `json:"cookies,omitempty" yaml:"cookies,omitempty"`     // 额外Cookie
+	Timeout  int               `json:"timeout,omitempty" yaml:"timeout,omitempty"`     // 请求超时（秒）
+	MaxPages int               `json:"max_pages,omitempty" yaml:"max_pages,omitempty"` // 最大抓取页数
+	Enabled  *bool             `json:"enabled,omitempty" yaml:"enabled,omitempty"`     // 默认是否启用，管理后台的设置优先
+	Session  *SessionConfig    `json:"session,omitempty" yaml:"session,omitempty"`     // 会话配置（Cookie持久化、登录），为空时不使用会话
+	Retry    *RetryConfig      `json:"retry,omitempty" yaml:"retry,omitempty"`         // 重试策略覆盖，为空时使用插件默认策略
+	Mirrors  *MirrorConfig     `json:"mirrors,omitempty" yaml:"mirrors,omitempty"`     // 镜像地址与对冲请求设置，只对使用镜像集合的插件生效
+}
+
+// ConfigurablePlugin 自建HTTP客户端等需要在插件配置加载后重新初始化的插件
+type ConfigurablePlugin interface {
+	// ApplyConfig 应用插件配置，在插件配置加载后调用
+	ApplyConfig(cfg PluginConfig)
+}
+
+// baseConfigurable 嵌入了BaseAsyncPlugin的插件
+type baseConfigurable interface {
+	applyBaseConfig(cfg PluginConfig)
+}
+
+// pluginConfigsFile 插件配置文件结构
+type pluginConfigsFile struct {
+	Plugins map[string]PluginConfig `json:"plugins" yaml:"plugins"`
+}
+
+var (
+	pluginConfigs     = make(map[string]PluginConfig)
+	pluginConfigsLock sync.RWMutex
+)
+
+// LoadPluginConfigs 从JSON/YAML文件加载插件配置，并应用到已注册的插件
+// 单个插件配置无效时记录日志并跳过，不影响其他插件
+func LoadPluginConfigs(path string) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取插件配置失败: %w", err)
+	}
+
+	var file pluginConfigsFile
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &file)
+	default:
+		err = json.Unmarshal(data, &file)
+	}
+	if err != nil {
+		return fmt.Errorf("解析插件配置失败: %w", err)
+	}
+
+	configs := make(map[string]PluginConfig, len(file.Plugins))
+	for name, cfg := range file.Plugins {
+		if err := cfg.normalize(); err != nil {
+			log.Printf("[plugin] 跳过插件 %s 的配置: %v", name, err)
+			continue
+		}
+		configs[name] = cfg
+	}
+
+	pluginConfigsLock.Lock()
+	pluginConfigs = configs
+	pluginConfigsLock.Unlock()
+	setSessions(configs)
+	setMirrorConfigs(configs)
+
+	for name, cfg := range configs {
+		p, exists := GetPluginByName(name)
+		if !exists {
+			log.Printf("[plugin] 插件配置中的 %s 未注册，已忽略", name)
+			continue
+		}
+		if base, ok := p.(baseConfigurable); ok {
+			base.applyBaseConfig(cfg)
+		}
+		if configurable, ok := p.(ConfigurablePlugin); ok {
+			configurable.ApplyConfig(cfg)
+		}
+	}
+	notifyStateChange()
+	return nil
+}
+
+// GetPluginConfig 获取插件配置，未配置时返回零值
+func GetPluginConfig(name string) PluginConfig {
+	pluginConfigsLock.RLock()
+	defer pluginConfigsLock.RUnlock()
+	return pluginConfigs[name]
+}
+
+// normalize 校验插件配置
+func (c *PluginConfig) normalize() error {
+	c.BaseURL = strings.TrimSuffix(strings.TrimSpace(c.BaseURL), "/")
+	if c.BaseURL != "" && !strings.HasPrefix(c.BaseURL, "http://") && !strings.HasPrefix(c.BaseURL, "https://") {
+		return fmt.Errorf("base_url无效: %q", c.BaseURL)
+	}
+	if c.Proxy != "" {
+		if _, err := c.ProxyURL(); err != nil {
+			return err
+		}
+	}
+	c.ProxyTag = strings.TrimSpace(c.ProxyTag)
+	if c.Proxy != "" && c.ProxyTag != "" {
+		return fmt.Errorf("proxy与proxy_tag不能同时设置")
+	}
+	if c.Timeout < 0 || c.MaxPages < 0 {
+		return fmt.Errorf("timeout与max_pages不能为负数")
+	}
+	if c.Session != nil {
+		if err := c.Session.normalize(); err != nil {
+			return fmt.Errorf("session无效: %w", err)
+		}
+	}
+	if c.Retry != nil {
+		if err := c.Retry.normalize(); err != nil {
+			return fmt.Errorf("retry无效: %w", err)
+		}
+	}
+	if c.Mirrors != nil {
+		if err := c.Mirrors.normalize(); err != nil {
+			return fmt.Errorf("mirrors无效: %w", err)
+		}
+	}
+	return nil
+}
+
+// ProxyURL 解析代理地址，未配置代理时返回nil
+func (c PluginConfig) ProxyURL() (*url.URL, error) {
+	if c.Proxy == "" {
+		return nil, nil
+	}
+	proxyURL, err := url.Parse(c.Proxy)
+	if err != nil {
+		return nil, fmt.Errorf("proxy无效: %w", err)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return proxyURL, nil
+	}
+	return nil, fmt.Errorf("proxy协议不支持: %q", proxyURL.Scheme)
+}
+
+// ConfigureTransport 为传输层设置配置的代理或代理池标签
+func (c PluginConfig) ConfigureTransport(transport *http.Transport) {
+	if proxyURL, _ := c.ProxyURL(); proxyURL != nil {
+		transport.Proxy = http.ProxyURL(proxyURL)
+	} else if c.ProxyTag != "" {
+		transport.Proxy = util.PoolProxyFunc(c.ProxyTag)
+	}
+}
+
+// NewHTTPClient 创建应用了代理与超时配置的HTTP客户端，transport为nil时使用默认传输层
+func (c PluginConfig) NewHTTPClient(transport *http.Transport, defaultTimeout time.Duration) *http.Client {
+	if transport == nil {
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+		// 清空默认拨号函数，未设置代理时由NewOutboundTransport换成使用自定义DNS解析的拨号函数
+		transport.DialContext = nil
+	}
+	c.ConfigureTransport(transport)
+	return &http.Client{
+		Transport: util.NewOutboundTransport(transport),
+		Timeout:   c.TimeoutOr(defaultTimeout),
+	}
+}
+
+// NewConfiguredClient 创建应用了代理、超时、请求头与Cookie配置的HTTP客户端，供自建HTTP客户端的插件使用，
+// 请求头与Cookie在每个请求发送前设置，覆盖插件设置的同名请求头
+func (c PluginConfig) NewConfiguredClient(transport *http.Transport, defaultTimeout time.Duration) *http.Client {
+	client := c.NewHTTPClient(transport, defaultTimeout)
+	if len(c.Headers) > 0 || len(c.Cookies) > 0 {
+		client.Transport = headerTransport{cfg: c, next: client.Transport}
+	}
+	return client
+}
+
+// BaseURLOr 返回配置的站点地址，未配置时返回默认值
+func (c PluginConfig) BaseURLOr(defaultURL string) string {
+	if c.BaseURL != "" {
+		return c.BaseURL
+	}
+	return defaultURL
+}
+
+// TimeoutOr 返回配置的请求超时，未配置时返回默认值
+func (c PluginConfig) TimeoutOr(defaultTimeout time.Duration) time.Duration {
+	if c.Timeout > 0 {
+		return time.Duration(c.Timeout) * time.Second
+	}
+	return defaultTimeout
+}
+
+// MaxPagesOr 返回配置的最大页数，未配置时返回默认值
+func (c PluginConfig) MaxPagesOr(defaultPages int) int {
+	if c.MaxPages > 0 {
+		return c.MaxPages
+	}
+	return defaultPages
+}
+
+// ApplyHeaders 设置配置的请求头与Cookie，请求头覆盖同名默认值，Cookie追加到已有Cookie之后
+func (c PluginConfig) ApplyHeaders(req *http.Request) {
+	for key, value := range c.Headers {
+		req.Header.Set(key, value)
+	}
+	for name, value := range c.Cookies {
+		req.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+}
+
+// applyBaseConfig 按插件配置重建基础HTTP客户端（插件配置在启动时、开始搜索前加载）
+func (p *BaseAsyncPlugin) applyBaseConfig(cfg PluginConfig) {
+	p.client = cfg.newBaseClient(p.client.Timeout)
+	p.backgroundClient = cfg.newBaseClient(p.backgroundClient.Timeout)
+	if session := p.Session(); session != nil {
+		session.Attach(p.client)
+		session.Attach(p.backgroundClient)
+	}
+}
+
+// Config 返回插件配置
+func (p *BaseAsyncPlugin) Config() PluginConfig {
+	return GetPluginConfig(p.name)
+}
+
+// newBaseClient 创建基础HTTP客户端，配置了请求头或Cookie时自动设置到每个请求
+func (c PluginConfig) newBaseClient(defaultTimeout time.Duration) *http.Client {
+	return c.NewConfiguredClient(nil, defaultTimeout)
+}
+
+// headerTransport 为HTTP客户端的请求设置配置的请求头与Cookie
+type headerTransport struct {
+	cfg  PluginConfig
+	next http.RoundTripper
+}
+
+// RoundTrip 实现http.RoundTripper
+func (t headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	t.cfg.ApplyHeaders(req)
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,311 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"pansou/util"
+)
+
+// 插件错误分类
+const (
+	ErrorCategoryNetwork    = "network"     // 连接失败、DNS解析失败、连接被重置等
+	ErrorCategoryTimeout    = "timeout"     // 请求超时或插件未在响应超时内返回
+	ErrorCategoryHTTPStatus = "http_status" // 上游返回非预期的HTTP状态码
+	ErrorCategoryParse      = "parse"       // 响应解析失败
+	ErrorCategoryBlocked    = "blocked"     // 被反爬/验证页拦截
+	ErrorCategoryPanic      = "panic"       // 插件代码panic
+	ErrorCategoryOther      = "other"
+)
+
+// 插件健康状态
+const (
+	PluginStatusIdle     = "idle"     // 尚无调用记录
+	PluginStatusHealthy  = "healthy"  // 近期调用基本成功
+	PluginStatusDegraded = "degraded" // 近期失败率较高
+	PluginStatusFailing  = "failing"  // 连续失败
+	PluginStatusBlocked  = "blocked"  // 最近的调用被反爬验证页拦截
+	PluginStatusDisabled = "disabled" // 已在管理后台禁用
+)
+
+const (
+	// metricsWindowSize 用于计算延迟分位数与近期失败率的最近调用数
+	metricsWindowSize = 200
+	// failingThreshold 连续失败达到该次数视为failing
+	failingThreshold = 5
+	// degradedErrorRate 近期失败率达到该比例视为degraded
+	degradedErrorRate = 0.5
+)
+
+// ErrResponseTimeout 插件未在响应超时内返回结果（后台仍在继续处理）
+var ErrResponseTimeout = errors.New("插件响应超时")
+
+// PluginStats 插件调用统计
+type PluginStats struct {
+	Name                string           `json:"name"`
+	Status              string           `json:"status"`
+	Calls               int64            `json:"calls"`
+	Successes           int64            `json:"successes"`
+	Errors              int64            `json:"errors"`
+	Timeouts            int64            `json:"timeouts"`
+	EmptyResults        int64            `json:"empty_results"`
+	EmptyResultRatio    float64          `json:"empty_result_ratio"` // 成功调用中结果为空的比例
+	ConsecutiveFailures int              `json:"consecutive_failures"`
+	ErrorCategories     map[string]int64 `json:"error_categories"`
+	LatencyP50Ms        int64            `json:"latency_p50_ms"`
+	LatencyP90Ms        int64            `json:"latency_p90_ms"`
+	LatencyP99Ms        int64            `json:"latency_p99_ms"`
+	LastError           string           `json:"last_error,omitempty"`
+	LastErrorCategory   string           `json:"last_error_category,omitempty"`
+	LastErrorTime       time.Time        `json:"last_error_time,omitempty"`
+	LastSuccessTime     time.Time        `json:"last_success_time,omitempty"`
+	Panics              int64            `json:"panics"` // 累计panic次数（包括后台刷新等不计入调用次数的panic）
+	LastPanicTime       time.Time        `json:"last_panic_time,omitempty"`
+
+	CircuitBreaker *CircuitBreakerStatus `json:"circuit_breaker,omitempty"` // 未启用熔断时为空
+}
+
+// callSample 单次调用的采样
+type callSample struct {
+	latency time.Duration
+	failed  bool
+}
+
+// pluginMetrics 单个插件的调用统计
+type pluginMetrics struct {
+	mu                  sync.Mutex
+	calls               int64
+	successes           int64
+	errors              int64
+	timeouts            int64
+	emptyResults        int64
+	consecutiveFailures int
+	categories          map[string]int64
+	lastError           string
+	lastErrorCategory   string
+	lastErrorTime       time.Time
+	lastSuccessTime     time.Time
+	panics              int64
+	lastPanicTime       time.Time
+	recentPanics        []time.Time // 统计窗口内的panic时间，用于自动禁用
+
+	// 最近调用的环形缓冲区
+	samples []callSample
+	next    int
+}
+
+// 全局插件统计
+var (
+	pluginMetricsMap  = make(map[string]*pluginMetrics)
+	pluginMetricsLock sync.RWMutex
+)
+
+// getPluginMetrics 获取插件统计，不存在时创建
+func getPluginMetrics(name string) *pluginMetrics {
+	pluginMetricsLock.RLock()
+	m, ok := pluginMetricsMap[name]
+	pluginMetricsLock.RUnlock()
+	if ok {
+		return m
+	}
+
+	pluginMetricsLock.Lock()
+	defer pluginMetricsLock.Unlock()
+	if m, ok = pluginMetricsMap[name]; !ok {
+		m = &pluginMetrics{categories: make(map[string]int64)}
+		pluginMetricsMap[name] = m
+	}
+	return m
+}
+
+// RecordPluginCall 记录一次插件调用的结果
+func RecordPluginCall(name string, latency time.Duration, resultCount int, err error) {
+	m := getPluginMetrics(name)
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls++
+	sample := callSample{latency: latency, failed: err != nil}
+	if len(m.samples) < metricsWindowSize {
+		m.samples = append(m.samples, sample)
+	} else {
+		m.samples[m.next] = sample
+		m.next = (m.next + 1) % metricsWindowSize
+	}
+
+	if err == nil {
+		m.successes++
+		m.consecutiveFailures = 0
+		m.lastSuccessTime = now
+		if resultCount == 0 {
+			m.emptyResults++
+		}
+		return
+	}
+
+	category := CategorizeError(err)
+	if category == ErrorCategoryTimeout {
+		m.timeouts++
+	} else {
+		m.errors++
+	}
+	m.categories[category]++
+	m.consecutiveFailures++
+	m.lastError = err.Error()
+	m.lastErrorCategory = category
+	m.lastErrorTime = now
+}
+
+// GetPluginStats 获取插件调用统计，status会结合插件是否被禁用
+func GetPluginStats(name string) PluginStats {
+	m := getPluginMetrics(name)
+
+	m.mu.Lock()
+	stats := PluginStats{
+		Name:                name,
+		Calls:               m.calls,
+		Successes:           m.successes,
+		Errors:              m.errors,
+		Timeouts:            m.timeouts,
+		EmptyResults:        m.emptyResults,
+		ConsecutiveFailures: m.consecutiveFailures,
+		ErrorCategories:     make(map[string]int64, len(m.categories)),
+		LastError:           m.lastError,
+		LastErrorCategory:   m.lastErrorCategory,
+		LastErrorTime:       m.lastErrorTime,
+		LastSuccessTime:     m.lastSuccessTime,
+		Panics:              m.panics,
+		LastPanicTime:       m.lastPanicTime,
+		CircuitBreaker:      GetCircuitBreakerStatus(name),
+	}
+	for category, count := range m.categories {
+		stats.ErrorCategories[category] = count
+	}
+	samples := make([]callSample, len(m.samples))
+	copy(samples, m.samples)
+	m.mu.Unlock()
+
+	if stats.Successes > 0 {
+		stats.EmptyResultRatio = float64(stats.EmptyResults) / float64(stats.Successes)
+	}
+
+	failed := 0
+	latencies := make([]time.Duration, 0, len(samples))
+	for _, s := range samples {
+		latencies = append(latencies, s.latency)
+		if s.failed {
+			failed++
+		}
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	stats.LatencyP50Ms = percentile(latencies, 0.50).Milliseconds()
+	stats.LatencyP90Ms = percentile(latencies, 0.90).Milliseconds()
+	stats.LatencyP99Ms = percentile(latencies, 0.99).Milliseconds()
+
+	switch {
+	case !IsPluginEnabled(name):
+		stats.Status = PluginStatusDisabled
+	case stats.Calls == 0:
+		stats.Status = PluginStatusIdle
+	case stats.ConsecutiveFailures > 0 && stats.LastErrorCategory == ErrorCategoryBlocked:
+		stats.Status = PluginStatusBlocked
+	case stats.ConsecutiveFailures >= failingThreshold:
+		stats.Status = PluginStatusFailing
+	case len(samples) > 0 && float64(failed)/float64(len(samples)) >= degradedErrorRate:
+		stats.Status = PluginStatusDegraded
+	default:
+		stats.Status = PluginStatusHealthy
+	}
+	return stats
+}
+
+// GetPluginStatus 获取插件健康状态
+func GetPluginStatus(name string) string {
+	return GetPluginStats(name).Status
+}
+
+// GetStats 获取插件调用统计
+func (pm *PluginManager) GetStats(name string) PluginStats {
+	return GetPluginStats(name)
+}
+
+// percentile 计算已排序延迟的分位数
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(float64(len(sorted)-1) * p)
+	return sorted[idx]
+}
+
+// CategorizeError 按错误类型与错误信息对插件错误分类
+func CategorizeError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	if IsPanicError(err) {
+		return ErrorCategoryPanic
+	}
+	if util.IsBlockedError(err) {
+		return ErrorCategoryBlocked
+	}
+	if errors.Is(err, ErrResponseTimeout) || errors.Is(err, context.DeadlineExceeded) {
+		return ErrorCategoryTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrorCategoryTimeout
+	}
+
+	msg := strings.ToLower(err.Error())
+	if containsAny(msg, "cloudflare", "captcha", "验证码", "人机验证", "challenge", "blocked", "拦截", "access denied") {
+		return ErrorCategoryBlocked
+	}
+
+	var urlErr *url.Error
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	if errors.As(err, &urlErr) || errors.As(err, &opErr) || errors.As(err, &dnsErr) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrorCategoryNetwork
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+		return ErrorCategoryParse
+	}
+
+	switch {
+	case containsAny(msg, "timeout", "超时", "deadline exceeded"):
+		return ErrorCategoryTimeout
+	case containsAny(msg, "状态码", "status code", "状态:", "http状态"):
+		return ErrorCategoryHTTPStatus
+	case containsAny(msg, "解析", "解码", "parse", "decode", "unmarshal", "invalid character"):
+		return ErrorCategoryParse
+	case containsAny(msg, "connection", "no such host", "eof", "请求失败", "request failed", "tls"):
+		return ErrorCategoryNetwork
+	}
+	return ErrorCategoryOther
+}
+
+// containsAny 检查文本是否包含任一子串
+func containsAny(text string, substrs ...string) bool {
+	for _, s := range substrs {
+		if strings.Contains(text, s) {
+			return true
+		}
+	}
+	return false
+}
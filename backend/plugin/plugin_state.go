@@ -0,0 +1,178 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// PluginState 插件运行时状态（管理后台设置，持久化到文件）
+type PluginState struct {
+	Disabled bool `json:"disabled,omitempty"` // 是否禁用
+	Enabled  bool `json:"enabled,omitempty"`  // 是否启用（仅在插件配置文件默认禁用该插件时记录）
+	Priority int  `json:"priority,omitempty"` // 优先级覆盖（0表示使用插件默认优先级）
+}
+
+// 插件运行时状态
+var (
+	pluginStates     = make(map[string]PluginState)
+	pluginStatesPath string
+	pluginStatesLock sync.RWMutex
+
+	// 状态变更监听器（用于失效依赖插件列表或优先级的缓存）
+	stateListeners     []func()
+	stateListenersLock sync.RWMutex
+)
+
+// LoadPluginStates 从文件加载插件运行时状态，文件不存在时使用空状态
+// 之后的状态变更会写回该文件
+func LoadPluginStates(path string) error {
+	states := make(map[string]PluginState)
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("读取插件状态文件失败: %w", err)
+		}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &states); err != nil {
+				return fmt.Errorf("解析插件状态文件失败: %w", err)
+			}
+		}
+	}
+
+	pluginStatesLock.Lock()
+	pluginStates = states
+	pluginStatesPath = path
+	pluginStatesLock.Unlock()
+
+	notifyStateChange()
+	return nil
+}
+
+// GetPluginState 获取插件运行时状态
+func GetPluginState(name string) PluginState {
+	pluginStatesLock.RLock()
+	defer pluginStatesLock.RUnlock()
+	return pluginStates[name]
+}
+
+// IsPluginEnabled 检查插件是否启用：管理后台的设置优先，其次是插件配置文件，默认启用
+func IsPluginEnabled(name string) bool {
+	state := GetPluginState(name)
+	if state.Disabled {
+		return false
+	}
+	if state.Enabled {
+		return true
+	}
+	if enabled := GetPluginConfig(name).Enabled; enabled != nil {
+		return *enabled
+	}
+	return true
+}
+
+// GetPriorityOverride 获取插件优先级覆盖值
+func GetPriorityOverride(name string) (int, bool) {
+	state := GetPluginState(name)
+	return state.Priority, state.Priority > 0
+}
+
+// SetPluginEnabled 启用或禁用插件并持久化
+func SetPluginEnabled(name string, enabled bool) error {
+	// 与插件配置文件的默认值相同时不单独记录
+	configEnabled := GetPluginConfig(name).Enabled
+	return updatePluginState(name, func(state *PluginState) {
+		state.Disabled = !enabled && (configEnabled == nil || *configEnabled)
+		state.Enabled = enabled && configEnabled != nil && !*configEnabled
+	})
+}
+
+// SetPluginPriority 设置插件优先级覆盖并持久化，priority为0时恢复默认优先级
+func SetPluginPriority(name string, priority int) error {
+	if priority < 0 || priority > 4 {
+		return fmt.Errorf("优先级必须在1-4之间，0表示恢复默认")
+	}
+	return updatePluginState(name, func(state *PluginState) {
+		state.Priority = priority
+	})
+}
+
+// OnPluginStateChange 注册插件状态变更监听器
+func OnPluginStateChange(listener func()) {
+	stateListenersLock.Lock()
+	stateListeners = append(stateListeners, listener)
+	stateListenersLock.Unlock()
+}
+
+// updatePluginState 修改插件状态、持久化并通知监听器
+func updatePluginState(name string, update func(state *PluginState)) error {
+	if _, exists := GetPluginByName(name); !exists {
+		return fmt.Errorf("插件 %s 不存在", name)
+	}
+
+	pluginStatesLock.Lock()
+	previous, existed := pluginStates[name]
+	state := previous
+	update(&state)
+	if state == (PluginState{}) {
+		delete(pluginStates, name)
+	} else {
+		pluginStates[name] = state
+	}
+
+	if err := savePluginStatesLocked(); err != nil {
+		// 持久化失败时回滚，保证内存状态与文件一致
+		if existed {
+			pluginStates[name] = previous
+		} else {
+			delete(pluginStates, name)
+		}
+		pluginStatesLock.Unlock()
+		return err
+	}
+	pluginStatesLock.Unlock()
+
+	notifyStateChange()
+	return nil
+}
+
+// savePluginStatesLocked 将插件状态写入文件（调用方需持有写锁）
+func savePluginStatesLocked() error {
+	if pluginStatesPath == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(pluginStates, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化插件状态失败: %w", err)
+	}
+
+	if dir := filepath.Dir(pluginStatesPath); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("创建插件状态目录失败: %w", err)
+		}
+	}
+
+	tmpPath := pluginStatesPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("写入插件状态文件失败: %w", err)
+	}
+	if err := os.Rename(tmpPath, pluginStatesPath); err != nil {
+		return fmt.Errorf("保存插件状态文件失败: %w", err)
+	}
+	return nil
+}
+
+// notifyStateChange 通知所有状态变更监听器
+func notifyStateChange() {
+	stateListenersLock.RLock()
+	listeners := make([]func(), len(stateListeners))
+	copy(listeners, stateListeners)
+	stateListenersLock.RUnlock()
+
+	for _, listener := range listeners {
+		listener()
+	}
+}
@@ -0,0 +1,60 @@
+package plugintest
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"pansou/model"
+	"pansou/plugin"
+)
+
+func TestCircuitBreakerCountsOnlyRealSearches(t *testing.T) {
+	// 熔断配置见TestMain：连续失败2次后熔断1分钟
+	const name = "breakertest"
+	defer plugin.ResetCircuitBreaker(name)
+
+	var calls int32
+	p := plugin.NewBaseAsyncPlugin(name, 3)
+	searchImpl := func(_ *http.Client, keyword string, _ map[string]interface{}) ([]model.SearchResult, error) {
+		atomic.AddInt32(&calls, 1)
+		if keyword == "ok" {
+			return []model.SearchResult{{UniqueID: "breakertest-1", Title: "结果"}}, nil
+		}
+		return nil, errors.New("上游返回状态码: 503")
+	}
+	search := func(keyword string) error {
+		_, err := p.AsyncSearch(keyword, searchImpl, "", nil)
+		return err
+	}
+
+	if err := search("ok"); err != nil {
+		t.Fatalf("搜索失败: %v", err)
+	}
+	search("fail-1")
+	// 命中插件缓存不算成功，不会清零连续失败次数
+	if err := search("ok"); err != nil {
+		t.Fatalf("命中缓存应返回结果: %v", err)
+	}
+	search("fail-2")
+
+	status := plugin.GetCircuitBreakerStatus(name)
+	if status == nil || status.State != plugin.BreakerOpen {
+		t.Fatalf("连续两次实际请求失败后应熔断: %+v", status)
+	}
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Errorf("实际请求次数 = %d，期望3", got)
+	}
+
+	// 熔断中不发送请求
+	if err := search("fail-3"); !errors.Is(err, plugin.ErrCircuitOpen) {
+		t.Errorf("熔断中应返回ErrCircuitOpen: %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Errorf("熔断中不应调用搜索函数，实际请求次数 = %d", got)
+	}
+	if !plugin.RejectIfCircuitOpen(name) {
+		t.Error("熔断中提交任务前应被过滤")
+	}
+}
@@ -0,0 +1,3 @@
+// Package plugintest 插件框架（pansou/plugin）的测试，只通过导出接口测试插件框架。
+// 测试放在单独的包中，避免go test对plugin包运行vet时因已有的格式化字符串告警而无法编译测试
+package plugintest
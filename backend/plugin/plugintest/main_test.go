@@ -0,0 +1,22 @@
+package plugintest
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"pansou/config"
+)
+
+// TestMain 设置所有测试共用的配置。插件在后台继续执行的搜索会读取全局配置，
+// 测试中途替换config.AppConfig会与这些goroutine竞争，因此只在这里设置一次
+func TestMain(m *testing.M) {
+	config.AppConfig = &config.Config{
+		AsyncResponseTimeoutDur:        50 * time.Millisecond,
+		CircuitBreakerEnabled:          true,
+		CircuitBreakerFailureThreshold: 2,
+		CircuitBreakerOpenDuration:     time.Minute,
+		CircuitBreakerHalfOpenSuccess:  1,
+	}
+	os.Exit(m.Run())
+}
@@ -0,0 +1,133 @@
+package plugintest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"pansou/plugin"
+)
+
+// mirrorServer 模拟单个镜像：延迟delay后返回body，status非200时返回错误状态码
+type mirrorServer struct {
+	*httptest.Server
+	calls     int32
+	firstCall chan time.Time // 首次请求到达的时间
+	cancelled chan struct{}  // 请求在返回前被客户端取消
+}
+
+func newMirrorServer(t *testing.T, delay time.Duration, status int, body string) *mirrorServer {
+	t.Helper()
+	s := &mirrorServer{firstCall: make(chan time.Time, 1), cancelled: make(chan struct{}, 1)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&s.calls, 1) == 1 {
+			s.firstCall <- time.Now()
+		}
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			s.cancelled <- struct{}{}
+			return
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// fetchMirror 向镜像发送GET请求，非200状态码视为失败
+func fetchMirror(ctx context.Context, baseURL string) (interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP状态码: %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	return string(body), err
+}
+
+func TestMirrorSetHedge(t *testing.T) {
+	const hedgeDelay = 100 * time.Millisecond
+	slow := newMirrorServer(t, 2*time.Second, http.StatusOK, "slow")
+	fast := newMirrorServer(t, 0, http.StatusOK, "fast")
+	set := plugin.NewMirrorSet("mirrorhedgetest", []string{slow.URL, fast.URL}, plugin.MirrorOptions{HedgeDelay: hedgeDelay})
+
+	start := time.Now()
+	value, err := set.Do(context.Background(), fetchMirror)
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	if value != "fast" {
+		t.Errorf("应返回先成功的镜像结果，实际 %v", value)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("对冲请求成功后应立即返回，实际耗时 %v", elapsed)
+	}
+
+	select {
+	case hedgedAt := <-fast.firstCall:
+		if wait := hedgedAt.Sub(start); wait < hedgeDelay {
+			t.Errorf("对冲请求应在 %v 后发出，实际 %v", hedgeDelay, wait)
+		}
+	default:
+		t.Fatal("主镜像超时未返回时应请求下一个镜像")
+	}
+	select {
+	case <-slow.cancelled:
+	case <-time.After(time.Second):
+		t.Error("对冲请求成功后应取消主镜像的请求")
+	}
+}
+
+func TestMirrorSetNoHedgeBeforeDelay(t *testing.T) {
+	primary := newMirrorServer(t, 0, http.StatusOK, "primary")
+	backup := newMirrorServer(t, 0, http.StatusOK, "backup")
+	set := plugin.NewMirrorSet("mirrornohedgetest", []string{primary.URL, backup.URL}, plugin.MirrorOptions{HedgeDelay: time.Second})
+
+	value, err := set.Do(context.Background(), fetchMirror)
+	if err != nil || value != "primary" {
+		t.Fatalf("应返回主镜像结果: %v %v", value, err)
+	}
+	if n := atomic.LoadInt32(&backup.calls); n != 0 {
+		t.Errorf("主镜像在对冲延迟内返回时不应请求备用镜像，实际请求 %d 次", n)
+	}
+}
+
+func TestMirrorSetFailedMirrorMovesToBack(t *testing.T) {
+	broken := newMirrorServer(t, 0, http.StatusBadGateway, "")
+	healthy := newMirrorServer(t, 0, http.StatusOK, "ok")
+	set := plugin.NewMirrorSet("mirrorfailovertest", []string{broken.URL, healthy.URL}, plugin.MirrorOptions{MaxFailures: 1})
+
+	value, err := set.Do(context.Background(), fetchMirror)
+	if err != nil || value != "ok" {
+		t.Fatalf("主镜像失败时应切换到下一个镜像: %v %v", value, err)
+	}
+	if urls := set.URLs(); len(urls) != 2 || urls[0] != healthy.URL || urls[1] != broken.URL {
+		t.Errorf("失败的镜像应排到最后，实际顺序 %v", urls)
+	}
+	status := set.Status()
+	if last := status.Mirrors[len(status.Mirrors)-1]; last.Healthy || last.Failures != 1 || last.DownUntil == nil {
+		t.Errorf("失败的镜像应被降级: %+v", last)
+	}
+
+	// 降级期间直接请求健康的镜像
+	if _, err := set.Do(context.Background(), fetchMirror); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&broken.calls); n != 1 {
+		t.Errorf("降级的镜像不应被优先请求，实际请求 %d 次", n)
+	}
+}
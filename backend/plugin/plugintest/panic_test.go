@@ -0,0 +1,61 @@
+package plugintest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"pansou/model"
+	"pansou/plugin"
+)
+
+func TestAsyncSearchPanicReturnsError(t *testing.T) {
+	p := plugin.NewBaseAsyncPlugin("panictest", 3)
+	searchImpl := func(*http.Client, string, map[string]interface{}) ([]model.SearchResult, error) {
+		panic("boom")
+	}
+
+	results, err := p.AsyncSearch("关键词", searchImpl, "", nil)
+	if err == nil {
+		t.Fatalf("searchImpl panic后应返回错误，实际返回 %d 条结果", len(results))
+	}
+	if !plugin.IsPanicError(err) {
+		t.Errorf("错误应为PanicError: %v", err)
+	}
+}
+
+func TestRecoverError(t *testing.T) {
+	fetch := func() (err error) {
+		defer plugin.RecoverError("panictest", &err)
+		var m map[string]int
+		m["x"] = 1
+		return nil
+	}
+	if err := fetch(); !plugin.IsPanicError(err) {
+		t.Errorf("panic应转换为PanicError: %v", err)
+	}
+}
+
+func TestMirrorSetPanicFailsOver(t *testing.T) {
+	set := plugin.NewMirrorSet("panictest-mirrors", []string{"https://a.example.com", "https://b.example.com"}, plugin.MirrorOptions{})
+	value, err := set.Do(context.Background(), func(ctx context.Context, baseURL string) (interface{}, error) {
+		if baseURL == "https://a.example.com" {
+			panic("boom")
+		}
+		return baseURL, nil
+	})
+	if err != nil {
+		t.Fatalf("第一个镜像panic后应切换到下一个镜像: %v", err)
+	}
+	if value != "https://b.example.com" {
+		t.Errorf("结果 = %v，期望来自第二个镜像", value)
+	}
+
+	_, err = set.Do(context.Background(), func(ctx context.Context, baseURL string) (interface{}, error) {
+		panic("boom")
+	})
+	if err == nil || !errors.As(err, new(*plugin.PanicError)) {
+		t.Errorf("所有镜像panic时应返回PanicError: %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package plugintest
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"pansou/model"
+	"pansou/plugin"
+)
+
+func TestCacheHitNotRecorded(t *testing.T) {
+	p := plugin.NewBaseAsyncPlugin("metricstest-cache", 3)
+	searchImpl := func(*http.Client, string, map[string]interface{}) ([]model.SearchResult, error) {
+		return []model.SearchResult{{UniqueID: "metricstest-1", Title: "结果"}}, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := p.AsyncSearch("关键词", searchImpl, "", nil); err != nil {
+			t.Fatalf("搜索失败: %v", err)
+		}
+	}
+	if stats := plugin.GetPluginStats("metricstest-cache"); stats.Calls != 1 || stats.Successes != 1 {
+		t.Errorf("命中插件缓存不应计入调用统计: calls=%d successes=%d", stats.Calls, stats.Successes)
+	}
+}
+
+func TestBackgroundCompletionRecorded(t *testing.T) {
+	// 响应超时见TestMain（50ms），搜索在响应超时后于后台完成
+	p := plugin.NewBaseAsyncPlugin("metricstest-background", 3)
+	searchImpl := func(*http.Client, string, map[string]interface{}) ([]model.SearchResult, error) {
+		time.Sleep(100 * time.Millisecond)
+		return nil, errors.New("上游返回状态码: 503")
+	}
+
+	results, err := p.AsyncSearch("关键词", searchImpl, "", nil)
+	if err != nil || len(results) != 0 {
+		t.Fatalf("响应超时后应返回空结果: %v %v", results, err)
+	}
+	if stats := plugin.GetPluginStats("metricstest-background"); stats.Calls != 0 {
+		t.Errorf("搜索尚未完成时不应记录调用: calls=%d", stats.Calls)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if stats := plugin.GetPluginStats("metricstest-background"); stats.Calls > 0 {
+			if stats.Errors != 1 || stats.LatencyP50Ms < 100 {
+				t.Errorf("后台完成的搜索应按实际结果记录: %+v", stats)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("后台完成的搜索没有计入调用统计")
+}
@@ -0,0 +1,42 @@
+package plugintest
+
+import (
+	"testing"
+
+	"pansou/plugin"
+	_ "pansou/plugin/panta"
+	_ "pansou/plugin/qupansou"
+)
+
+func TestPriorityOverride(t *testing.T) {
+	// panta、qupansou曾自行覆盖Priority()，导致管理后台设置的优先级不生效。
+	// pansearch在init中会发起预热请求，与TestMain设置全局配置竞争，其用例放在pansearch包中
+	for _, name := range []string{"panta", "qupansou"} {
+		p, ok := plugin.GetPluginByName(name)
+		if !ok {
+			t.Fatalf("插件 %s 未注册", name)
+		}
+		defaultPriority := p.Priority()
+		override := 4
+		if defaultPriority == 4 {
+			override = 1
+		}
+
+		if err := plugin.SetPluginPriority(name, override); err != nil {
+			t.Fatalf("%s: 设置优先级失败: %v", name, err)
+		}
+		if got := p.Priority(); got != override {
+			t.Errorf("%s: Priority() = %d，应返回覆盖值 %d", name, got, override)
+		}
+		if dp, ok := p.(interface{ DefaultPriority() int }); !ok || dp.DefaultPriority() != defaultPriority {
+			t.Errorf("%s: DefaultPriority()应保持插件声明的优先级 %d", name, defaultPriority)
+		}
+
+		if err := plugin.SetPluginPriority(name, 0); err != nil {
+			t.Fatalf("%s: 恢复默认优先级失败: %v", name, err)
+		}
+		if got := p.Priority(); got != defaultPriority {
+			t.Errorf("%s: 恢复后 Priority() = %d，期望 %d", name, got, defaultPriority)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package plugintest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"pansou/config"
+	"pansou/plugin"
+)
+
+// loginServer 模拟需要登录的站点：登录页下发formhash，登录成功后设置auth Cookie，
+// 调用expire后旧的auth Cookie失效
+type loginServer struct {
+	*httptest.Server
+	logins  int32
+	mu      sync.Mutex
+	version int
+}
+
+func newLoginServer(t *testing.T) *loginServer {
+	s := &loginServer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			fmt.Fprint(w, `<form><input type="hidden" name="formhash" value="abc123"></form>`)
+			return
+		}
+		r.ParseForm()
+		if r.PostForm.Get("formhash") != "abc123" || r.PostForm.Get("username") != "user" {
+			http.Error(w, "bad form", http.StatusBadRequest)
+			return
+		}
+		atomic.AddInt32(&s.logins, 1)
+		s.mu.Lock()
+		token := fmt.Sprintf("v%d", s.version)
+		s.mu.Unlock()
+		http.SetCookie(w, &http.Cookie{Name: "auth", Value: token, Path: "/"})
+		fmt.Fprint(w, "欢迎您回来")
+	})
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		token := fmt.Sprintf("v%d", s.version)
+		s.mu.Unlock()
+		if c, err := r.Cookie("auth"); err != nil || c.Value != token {
+			http.Error(w, "请先登录", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, "results")
+	})
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// expire 让已下发的auth Cookie失效
+func (s *loginServer) expire() {
+	s.mu.Lock()
+	s.version++
+	s.mu.Unlock()
+}
+
+func (s *loginServer) sessionConfig() plugin.SessionConfig {
+	return plugin.SessionConfig{
+		ExpiredStatus: []int{http.StatusUnauthorized},
+		Login: &plugin.LoginConfig{
+			FormURL:       s.URL + "/login",
+			URL:           s.URL + "/login",
+			Method:        http.MethodPost,
+			Form:          map[string]string{"username": "user", "password": "${SESSION_TEST_PASSWORD}"},
+			SuccessCookie: "auth",
+		},
+	}
+}
+
+// loadSession 通过插件配置文件创建插件会话（与启动时加载插件配置相同），每次调用都会重新创建会话
+func loadSession(t *testing.T, cfg plugin.SessionConfig) *plugin.Session {
+	t.Helper()
+	data, err := json.Marshal(map[string]interface{}{
+		"plugins": map[string]plugin.PluginConfig{"sessiontest": {Session: &cfg}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "plugins.json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := plugin.LoadPluginConfigs(path); err != nil {
+		t.Fatalf("加载插件配置失败: %v", err)
+	}
+	session := plugin.GetSession("sessiontest")
+	if session == nil {
+		t.Fatal("配置了session的插件应创建会话")
+	}
+	return session
+}
+
+func fetchSearch(t *testing.T, client *http.Client, url string) (int, string) {
+	t.Helper()
+	resp, err := client.Get(url + "/search")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestSessionLoginBeforeFirstRequest(t *testing.T) {
+	server := newLoginServer(t)
+	session := loadSession(t, server.sessionConfig())
+	client := session.Attach(&http.Client{})
+
+	status, body := fetchSearch(t, client, server.URL)
+	if status != http.StatusOK || body != "results" {
+		t.Fatalf("登录后请求应成功，实际 %d %q", status, body)
+	}
+	if got := atomic.LoadInt32(&server.logins); got != 1 {
+		t.Errorf("登录次数 = %d，期望1", got)
+	}
+
+	fetchSearch(t, client, server.URL)
+	if got := atomic.LoadInt32(&server.logins); got != 1 {
+		t.Errorf("已登录时不应再次登录，登录次数 = %d", got)
+	}
+	if st := session.Status(); !st.LoggedIn || st.Logins != 1 || st.Cookies != 1 {
+		t.Errorf("会话状态不正确: %+v", st)
+	}
+}
+
+func TestSessionLoginFailure(t *testing.T) {
+	server := newLoginServer(t)
+	cfg := server.sessionConfig()
+	cfg.Login.Form = map[string]string{"username": "nobody"}
+	session := loadSession(t, cfg)
+	client := session.Attach(&http.Client{})
+
+	if status, _ := fetchSearch(t, client, server.URL); status != http.StatusUnauthorized {
+		t.Errorf("登录失败时应返回上游状态码401，实际 %d", status)
+	}
+	if st := session.Status(); st.LoggedIn || st.LoginFailures != 1 || st.LastError == "" {
+		t.Errorf("会话应记录登录失败: %+v", st)
+	}
+}
+
+func TestSessionPersistence(t *testing.T) {
+	config.AppConfig.PluginSessionDir = t.TempDir()
+	defer func() { config.AppConfig.PluginSessionDir = "" }()
+
+	server := newLoginServer(t)
+	first := loadSession(t, server.sessionConfig())
+	if status, _ := fetchSearch(t, first.Attach(&http.Client{}), server.URL); status != http.StatusOK {
+		t.Fatalf("首次请求应成功，实际 %d", status)
+	}
+
+	// 模拟重启：从磁盘恢复的会话直接使用原Cookie，不再登录
+	restored := loadSession(t, server.sessionConfig())
+	if st := restored.Status(); !st.Persistent || !st.LoggedIn || st.Cookies != 1 {
+		t.Fatalf("会话应从磁盘恢复: %+v", st)
+	}
+	if status, _ := fetchSearch(t, restored.Attach(&http.Client{}), server.URL); status != http.StatusOK {
+		t.Fatalf("恢复的会话请求应成功，实际 %d", status)
+	}
+	if got := atomic.LoadInt32(&server.logins); got != 1 {
+		t.Errorf("恢复会话后不应重新登录，登录次数 = %d", got)
+	}
+
+	// 清除后磁盘上的会话也被清空
+	restored.Clear()
+	if st := loadSession(t, server.sessionConfig()).Status(); st.LoggedIn || st.Cookies != 0 {
+		t.Errorf("清除后不应恢复出Cookie: %+v", st)
+	}
+}
+
+func TestSessionReloginOnExpiry(t *testing.T) {
+	server := newLoginServer(t)
+	session := loadSession(t, server.sessionConfig())
+	client := session.Attach(&http.Client{})
+
+	if status, _ := fetchSearch(t, client, server.URL); status != http.StatusOK {
+		t.Fatalf("首次请求应成功，实际 %d", status)
+	}
+
+	server.expire()
+	status, body := fetchSearch(t, client, server.URL)
+	if status != http.StatusOK || body != "results" {
+		t.Fatalf("会话过期后应重新登录并重试，实际 %d %q", status, body)
+	}
+	if got := atomic.LoadInt32(&server.logins); got != 2 {
+		t.Errorf("登录次数 = %d，期望2", got)
+	}
+	if st := session.Status(); st.Expirations != 1 || st.Logins != 2 || !st.LoggedIn {
+		t.Errorf("会话状态不正确: %+v", st)
+	}
+}
+
+func TestSessionExpiredContains(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<div>请先登录后查看下载地址</div>")
+	}))
+	defer server.Close()
+
+	session := loadSession(t, plugin.SessionConfig{ExpiredContains: []string{"请先登录"}})
+	resp, err := session.Attach(&http.Client{}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "请先登录") {
+		t.Errorf("检测过期后应还原响应内容，实际 %q", body)
+	}
+	if st := session.Status(); st.Expirations != 1 {
+		t.Errorf("过期次数 = %d，期望1", st.Expirations)
+	}
+}
@@ -37,6 +37,8 @@ func init() {
 
 // startCacheCleaner 启动一个定期清理缓存的goroutine
 func startCacheCleaner() {
+	defer plugin.RecoverPanic("qupansou")
+	
 	// 每小时清理一次缓存
 	ticker := time.NewTicker(1 * time.Hour)
 	defer ticker.Stop()
@@ -83,11 +85,6 @@ func (p *QuPanSouAsyncPlugin) Name() string {
 	return "qupansou"
 }
 
-// Priority 返回插件优先级
-func (p *QuPanSouAsyncPlugin) Priority() int {
-	return 3 // 中等优先级
-}
-
 // Search 执行搜索并返回结果（兼容性方法）
 func (p *QuPanSouAsyncPlugin) Search(keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
 	result, err := p.SearchWithResult(keyword, ext)
@@ -0,0 +1,253 @@
+// Package remote 把其他UniSearch实例注册为搜索源（联邦搜索），
+// 搜索请求转发到对端的 /api/search，结果以 remote:<实例名> 标注来源
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"pansou/model"
+	"pansou/plugin"
+	"pansou/util"
+)
+
+const (
+	// NamePrefix 远程实例插件名前缀，插件名同时作为结果来源标识
+	NamePrefix = "remote:"
+	// HopHeader 转发请求携带的请求头，带有该请求头的搜索不会再转发给远程实例，避免实例间循环转发
+	HopHeader = "X-UniSearch-Hop"
+)
+
+// 默认参数
+const (
+	defaultTimeout  = 8 // 秒
+	maxResponseSize = 32 * 1024 * 1024
+	searchAPIPath   = "/api/search"
+)
+
+// Peer 远程实例配置，每项注册为一个名为 remote:<name> 的插件
+type Peer struct {
+	Name     string   `json:"name" yaml:"name"`                             // 实例名称（不能包含 - 或 :）
+	URL      string   `json:"url" yaml:"url"`                               // 实例地址，如 https://eu.example.com
+	APIKey   string   `json:"api_key,omitempty" yaml:"api_key,omitempty"`   // 对端启用API Key认证时使用
+	Priority int      `json:"priority" yaml:"priority"`                     // 插件优先级（1-4，越小越优先）
+	Timeout  int      `json:"timeout,omitempty" yaml:"timeout,omitempty"`   // 请求超时（秒），默认8
+	Source   string   `json:"src,omitempty" yaml:"src,omitempty"`           // 对端数据来源类型：all、tg、plugin、index，默认all
+	Plugins  []string `json:"plugins,omitempty" yaml:"plugins,omitempty"`   // 对端插件列表，不指定则使用对端全部插件
+	Channels []string `json:"channels,omitempty" yaml:"channels,omitempty"` // 对端频道列表，不指定则使用对端默认频道
+}
+
+// peersFile 远程实例配置文件结构
+type peersFile struct {
+	Peers []*Peer `json:"peers" yaml:"peers"`
+}
+
+// IsRemote 判断插件是否为远程实例
+func IsRemote(name string) bool {
+	return strings.HasPrefix(name, NamePrefix)
+}
+
+// LoadPeers 从JSON/YAML文件加载远程实例配置并注册为全局插件，返回成功注册的数量
+// 单个实例配置无效时记录日志并跳过，不影响其他实例
+func LoadPeers(path string) (int, error) {
+	if path == "" {
+		return 0, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("读取远程实例配置失败: %w", err)
+	}
+
+	var file peersFile
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &file)
+	default:
+		err = json.Unmarshal(data, &file)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("解析远程实例配置失败: %w", err)
+	}
+
+	count := 0
+	for i, peer := range file.Peers {
+		if peer == nil {
+			continue
+		}
+		if err := peer.normalize(); err != nil {
+			log.Printf("[remote] 跳过第 %d 个实例配置: %v", i+1, err)
+			continue
+		}
+		plugin.RegisterGlobalPlugin(NewRemotePlugin(peer))
+		count++
+	}
+	return count, nil
+}
+
+// normalize 校验实例配置并补全默认值
+func (p *Peer) normalize() error {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return fmt.Errorf("缺少name")
+	}
+	// 来源识别依赖 "插件名-ID" 格式的UniqueID
+	if strings.ContainsAny(p.Name, "-:") {
+		return fmt.Errorf("实例名称 %s 不能包含 - 或 :", p.Name)
+	}
+
+	p.URL = strings.TrimSuffix(strings.TrimSpace(p.URL), "/")
+	if !strings.HasPrefix(p.URL, "http://") && !strings.HasPrefix(p.URL, "https://") {
+		return fmt.Errorf("实例 %s 的url无效: %q", p.Name, p.URL)
+	}
+
+	if p.Priority < 1 || p.Priority > 4 {
+		p.Priority = 3
+	}
+	if p.Timeout <= 0 {
+		p.Timeout = defaultTimeout
+	}
+	switch p.Source {
+	case "":
+		p.Source = "all"
+	case "all", "tg", "plugin", "index":
+	default:
+		return fmt.Errorf("实例 %s 的src无效: %q", p.Name, p.Source)
+	}
+	return nil
+}
+
+// searchRequest 转发给对端的搜索请求
+type searchRequest struct {
+	Keyword    string                 `json:"kw"`
+	Channels   []string               `json:"channels,omitempty"`
+	ResultType string                 `json:"res"`
+	SourceType string                 `json:"src"`
+	Plugins    []string               `json:"plugins,omitempty"`
+	Ext        map[string]interface{} `json:"ext,omitempty"`
+	Depth      string                 `json:"depth,omitempty"`
+	MaxPages   int                    `json:"max_pages,omitempty"`
+}
+
+// searchResponse 对端的搜索响应
+type searchResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		Results []model.SearchResult `json:"results"`
+	} `json:"data"`
+}
+
+// RemotePlugin 远程实例插件
+type RemotePlugin struct {
+	*plugin.BaseAsyncPlugin
+	peer   *Peer
+	client *http.Client
+}
+
+// NewRemotePlugin 根据实例配置创建插件
+func NewRemotePlugin(peer *Peer) *RemotePlugin {
+	return &RemotePlugin{
+		// 对端已按关键词过滤，且可能包含跳过过滤的插件结果，本地不再过滤
+		BaseAsyncPlugin: plugin.NewBaseAsyncPluginWithFilter(NamePrefix+peer.Name, peer.Priority, true),
+		peer:            peer,
+		client: &http.Client{
+			Transport: util.NewOutboundTransport(nil),
+			Timeout:   time.Duration(peer.Timeout) * time.Second,
+		},
+	}
+}
+
+// Peer 返回实例配置
+func (p *RemotePlugin) Peer() *Peer {
+	return p.peer
+}
+
+// Search 同步搜索接口
+func (p *RemotePlugin) Search(keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
+	result, err := p.SearchWithResult(keyword, ext)
+	if err != nil {
+		return nil, err
+	}
+	return result.Results, nil
+}
+
+// SearchWithResult 带结果统计的搜索接口
+func (p *RemotePlugin) SearchWithResult(keyword string, ext map[string]interface{}) (model.PluginSearchResult, error) {
+	return p.AsyncSearchWithResult(keyword, p.searchImpl, p.MainCacheKey, ext)
+}
+
+// searchImpl 转发搜索请求到对端实例
+func (p *RemotePlugin) searchImpl(_ *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
+	opts := plugin.GetSearchOptions(ext)
+	body, err := json.Marshal(searchRequest{
+		Keyword:    keyword,
+		Channels:   p.peer.Channels,
+		ResultType: "results",
+		SourceType: p.peer.Source,
+		Plugins:    p.peer.Plugins,
+		Ext:        plugin.WithoutSearchOptions(ext),
+		Depth:      opts.Depth,
+		MaxPages:   opts.MaxPages,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(p.peer.Timeout)*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.peer.URL+searchAPIPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("[%s] 创建请求失败: %w", p.Name(), err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HopHeader, "1")
+	if p.peer.APIKey != "" {
+		req.Header.Set("X-API-Key", p.peer.APIKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("[%s] 请求失败: %w", p.Name(), err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("[%s] 读取响应失败: %w", p.Name(), err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("[%s] 请求失败，状态码: %d", p.Name(), resp.StatusCode)
+	}
+
+	var result searchResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("[%s] 解析响应失败: %w", p.Name(), err)
+	}
+	if result.Code != 0 {
+		return nil, fmt.Errorf("[%s] 对端返回错误: %s", p.Name(), result.Message)
+	}
+
+	results := make([]model.SearchResult, 0, len(result.Data.Results))
+	for i, r := range result.Data.Results {
+		if r.UniqueID == "" {
+			r.UniqueID = fmt.Sprintf("%d", i)
+		}
+		// 统一标注为本实例来源，频道结果也按远程实例计算来源与等级
+		r.UniqueID = p.Name() + "-" + r.UniqueID
+		r.Channel = ""
+		results = append(results, r)
+	}
+	return results, nil
+}
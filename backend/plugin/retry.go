@@ -0,0 +1,80 @@
+package plugin
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"pansou/util"
+)
+
+// RetryConfig 插件重试策略覆盖，未设置的字段使用插件默认策略
+type RetryConfig struct {
+	MaxAttempts        int      `json:"max_attempts,omitempty" yaml:"max_attempts,omitempty"`                 // 最多尝试次数（含首次请求），1表示不重试
+	BackoffMs          int      `json:"backoff_ms,omitempty" yaml:"backoff_ms,omitempty"`                     // 首次重试前的等待时间（毫秒）
+	MaxBackoffMs       int      `json:"max_backoff_ms,omitempty" yaml:"max_backoff_ms,omitempty"`             // 单次等待时间上限（毫秒）
+	Jitter             *float64 `json:"jitter,omitempty" yaml:"jitter,omitempty"`                             // 等待时间随机抖动比例（0~1）
+	RetryStatus        []int    `json:"retry_status,omitempty" yaml:"retry_status,omitempty"`                 // 需要重试的HTTP状态码，替换默认列表
+	RetryNonIdempotent *bool    `json:"retry_non_idempotent,omitempty" yaml:"retry_non_idempotent,omitempty"` // 是否重试POST等非幂等请求
+	MaxRetryAfter      *int     `json:"max_retry_after,omitempty" yaml:"max_retry_after,omitempty"`           // 遵循Retry-After的最长等待时间（秒），0表示忽略Retry-After
+}
+
+// normalize 校验重试配置
+func (c *RetryConfig) normalize() error {
+	if c.MaxAttempts < 0 || c.BackoffMs < 0 || c.MaxBackoffMs < 0 {
+		return fmt.Errorf("max_attempts、backoff_ms与max_backoff_ms不能为负数")
+	}
+	if c.Jitter != nil && (*c.Jitter < 0 || *c.Jitter > 1) {
+		return fmt.Errorf("jitter必须在0到1之间: %v", *c.Jitter)
+	}
+	for _, status := range c.RetryStatus {
+		if status < 100 || status > 599 {
+			return fmt.Errorf("retry_status无效: %d", status)
+		}
+	}
+	if c.MaxRetryAfter != nil && *c.MaxRetryAfter < 0 {
+		return fmt.Errorf("max_retry_after不能为负数")
+	}
+	return nil
+}
+
+// RetryPolicy 在插件默认重试策略上应用配置文件中的覆盖
+func (c PluginConfig) RetryPolicy(defaults util.RetryPolicy) util.RetryPolicy {
+	r := c.Retry
+	if r == nil {
+		return defaults
+	}
+	policy := defaults
+	if r.MaxAttempts > 0 {
+		policy.MaxAttempts = r.MaxAttempts
+	}
+	if r.BackoffMs > 0 {
+		policy.Backoff = time.Duration(r.BackoffMs) * time.Millisecond
+	}
+	if r.MaxBackoffMs > 0 {
+		policy.MaxBackoff = time.Duration(r.MaxBackoffMs) * time.Millisecond
+	}
+	if r.Jitter != nil {
+		policy.Jitter = *r.Jitter
+	}
+	if len(r.RetryStatus) > 0 {
+		policy.RetryStatus = append([]int(nil), r.RetryStatus...)
+	}
+	if r.RetryNonIdempotent != nil {
+		policy.RetryNonIdempotent = *r.RetryNonIdempotent
+	}
+	if r.MaxRetryAfter != nil {
+		policy.MaxRetryAfter = time.Duration(*r.MaxRetryAfter) * time.Second
+	}
+	return policy
+}
+
+// DoWithRetry 按全局默认重试策略（可被插件配置覆盖）发送请求，只有2xx响应视为成功
+func (p *BaseAsyncPlugin) DoWithRetry(req *http.Request, client *http.Client) (*http.Response, error) {
+	return p.DoWithRetryPolicy(req, client, util.DefaultRetryPolicy())
+}
+
+// DoWithRetryPolicy 以插件自己的默认重试策略发送请求，插件配置中的retry仍然优先
+func (p *BaseAsyncPlugin) DoWithRetryPolicy(req *http.Request, client *http.Client, defaults util.RetryPolicy) (*http.Response, error) {
+	return util.DoWithRetry(client, req, p.Config().RetryPolicy(defaults))
+}
@@ -0,0 +1,363 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"pansou/model"
+	"pansou/plugin"
+	"pansou/util"
+)
+
+// 详情页缓存有效期
+const detailCacheTTL = 1 * time.Hour
+
+// 默认请求头（避免反爬虫）
+var defaultHeaders = map[string]string{
+	"User-Agent":      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
+	"Accept":          "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8",
+	"Accept-Language": "zh-CN,zh;q=0.9,en;q=0.8",
+	"Connection":      "keep-alive",
+}
+
+// detailCacheEntry 详情页链接缓存项
+type detailCacheEntry struct {
+	links    []model.Link
+	cachedAt time.Time
+}
+
+// ScraperPlugin 由站点定义实例化的通用HTML抓取插件
+type ScraperPlugin struct {
+	*plugin.BaseAsyncPlugin
+	site        *SiteDefinition
+	detailCache sync.Map // 详情页URL -> detailCacheEntry
+}
+
+// NewScraperPlugin 根据站点定义创建插件
+func NewScraperPlugin(site *SiteDefinition) *ScraperPlugin {
+	return &ScraperPlugin{
+		BaseAsyncPlugin: plugin.NewBaseAsyncPluginWithFilter(site.Name, site.Priority, site.SkipServiceFilter),
+		site:            site,
+	}
+}
+
+// Site 返回插件的站点定义
+func (p *ScraperPlugin) Site() *SiteDefinition {
+	return p.site
+}
+
+// Search 执行搜索并返回结果（兼容性方法）
+func (p *ScraperPlugin) Search(keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
+	result, err := p.SearchWithResult(keyword, ext)
+	if err != nil {
+		return nil, err
+	}
+	return result.Results, nil
+}
+
+// SearchWithResult 执行搜索并返回包含IsFinal标记的结果
+func (p *ScraperPlugin) SearchWithResult(keyword string, ext map[string]interface{}) (model.PluginSearchResult, error) {
+	return p.AsyncSearchWithResult(keyword, p.searchImpl, p.MainCacheKey, ext)
+}
+
+// searchImpl 实现具体的搜索逻辑
+// 使用基础插件传入的客户端，插件配置中的代理、请求头、Cookie与会话已应用在该客户端上；
+// 搜索与详情请求的超时由站点定义的timeout与detail_timeout通过context控制
+func (p *ScraperPlugin) searchImpl(client *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
+	site := p.site
+	searchURL := site.expandURL(site.SearchURL, map[string]string{"keyword": url.QueryEscape(keyword)})
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.Config().TimeoutOr(time.Duration(site.Timeout)*time.Second))
+	defer cancel()
+
+	doc, err := p.fetchDocument(ctx, client, searchURL)
+	if err != nil {
+		return nil, fmt.Errorf("[%s] 搜索请求失败: %w", p.Name(), err)
+	}
+
+	var results []model.SearchResult
+	var detailURLs []string
+	doc.Find(site.List.Item).Each(func(i int, s *goquery.Selection) {
+		result, detailURL := p.parseSearchItem(s, searchURL)
+		if result.UniqueID == "" {
+			return
+		}
+		results = append(results, result)
+		detailURLs = append(detailURLs, detailURL)
+	})
+
+	if site.Detail != nil {
+		p.enhanceWithDetails(client, results, detailURLs)
+	}
+
+	// 过滤没有链接的结果
+	filtered := results[:0]
+	for _, r := range results {
+		if len(r.Links) > 0 {
+			filtered = append(filtered, r)
+		}
+	}
+
+	return plugin.FilterResultsByKeyword(filtered, keyword), nil
+}
+
+// parseSearchItem 解析单个搜索结果项，返回结果与详情页地址
+func (p *ScraperPlugin) parseSearchItem(s *goquery.Selection, pageURL string) (model.SearchResult, string) {
+	site := p.site
+	result := model.SearchResult{}
+
+	itemID := ""
+	detailURL := ""
+	if site.List.DetailURL != "" {
+		detailURL = resolveURL(pageURL, selectValue(s, site.List.DetailURL))
+		if detailURL == "" {
+			return result, ""
+		}
+		itemID = detailURL
+		if site.idRegex != nil {
+			matches := site.idRegex.FindStringSubmatch(detailURL)
+			if len(matches) < 2 {
+				return result, ""
+			}
+			itemID = matches[1]
+		}
+		if site.Detail != nil && site.Detail.URL != "" {
+			detailURL = site.expandURL(site.Detail.URL, map[string]string{"id": itemID})
+		}
+	}
+
+	result.Title = selectValue(s, site.List.Title)
+	if result.Title == "" {
+		return result, ""
+	}
+	if itemID == "" {
+		itemID = result.Title
+	}
+	result.UniqueID = fmt.Sprintf("%s-%s", p.Name(), itemID)
+
+	if site.List.Tags != "" {
+		s.Find(site.List.Tags).Each(func(i int, tag *goquery.Selection) {
+			if text := strings.TrimSpace(tag.Text()); text != "" {
+				result.Tags = append(result.Tags, text)
+			}
+		})
+	}
+
+	var contentParts []string
+	for _, field := range site.List.Content {
+		if value := selectJoined(s, field.Selector, field.Limit); value != "" {
+			contentParts = append(contentParts, field.Label+value+field.Suffix)
+		}
+	}
+	result.Content = strings.Join(contentParts, "\n")
+
+	if site.List.Links != "" {
+		result.Links = p.extractLinks(s, []string{site.List.Links}, "", s.Text())
+	}
+
+	result.Channel = "" // 插件搜索结果不设置频道名，只有Telegram频道结果才设置
+	result.Datetime = time.Time{}
+	return result, detailURL
+}
+
+// enhanceWithDetails 并发获取详情页中的网盘链接
+func (p *ScraperPlugin) enhanceWithDetails(client *http.Client, results []model.SearchResult, detailURLs []string) {
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, p.site.MaxConcurrency)
+
+	for i := range results {
+		if detailURLs[i] == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer plugin.RecoverPanic(p.Name())
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			if links := p.fetchDetailLinks(client, detailURLs[i]); len(links) > 0 {
+				results[i].Links = append(results[i].Links, links...)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// fetchDetailLinks 获取详情页的下载链接（带缓存）
+func (p *ScraperPlugin) fetchDetailLinks(client *http.Client, detailURL string) []model.Link {
+	if cached, ok := p.detailCache.Load(detailURL); ok {
+		entry := cached.(detailCacheEntry)
+		if time.Since(entry.cachedAt) < detailCacheTTL {
+			return entry.links
+		}
+		p.detailCache.Delete(detailURL)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(p.site.DetailTimeout)*time.Second)
+	defer cancel()
+
+	doc, err := p.fetchDocument(ctx, client, detailURL)
+	if err != nil {
+		return nil
+	}
+
+	detail := p.site.Detail
+	links := p.extractLinks(doc.Selection, detail.Links, detail.Password, doc.Text())
+	p.detailCache.Store(detailURL, detailCacheEntry{links: links, cachedAt: time.Now()})
+	return links
+}
+
+// extractLinks 按选择器提取网盘链接并识别类型与提取码
+func (p *ScraperPlugin) extractLinks(s *goquery.Selection, selectors []string, passwordSelector string, pageText string) []model.Link {
+	password := ""
+	if passwordSelector != "" {
+		password = selectValue(s, passwordSelector)
+	}
+
+	seen := make(map[string]bool)
+	var links []model.Link
+	for _, selector := range selectors {
+		for _, raw := range selectValues(s, selector) {
+			linkURL := strings.TrimSpace(raw)
+			if seen[linkURL] || !p.isValidLink(linkURL) {
+				continue
+			}
+			seen[linkURL] = true
+
+			linkPassword := password
+			if linkPassword == "" {
+				linkPassword = util.ExtractPassword(pageText, linkURL)
+			}
+			links = append(links, model.Link{
+				Type:     util.GetLinkType(linkURL),
+				URL:      linkURL,
+				Password: linkPassword,
+			})
+		}
+	}
+	return links
+}
+
+// isValidLink 检查链接是否为站点允许的网盘链接
+func (p *ScraperPlugin) isValidLink(linkURL string) bool {
+	if linkURL == "" || strings.HasPrefix(linkURL, "javascript:") {
+		return false
+	}
+	if !strings.HasPrefix(linkURL, "http") && !strings.HasPrefix(linkURL, "magnet:") && !strings.HasPrefix(linkURL, "ed2k:") {
+		return false
+	}
+
+	linkType := util.GetLinkType(linkURL)
+	if linkType == "others" {
+		return false
+	}
+	if len(p.site.LinkTypes) > 0 {
+		allowed := false
+		for _, t := range p.site.LinkTypes {
+			if t == linkType {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	return p.site.linkRegex == nil || p.site.linkRegex.MatchString(linkURL)
+}
+
+// fetchDocument 请求页面并解析为HTML文档（带重试）
+func (p *ScraperPlugin) fetchDocument(ctx context.Context, client *http.Client, pageURL string) (*goquery.Document, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range defaultHeaders {
+		req.Header.Set(key, value)
+	}
+	if p.site.BaseURL != "" {
+		req.Header.Set("Referer", p.site.BaseURL+"/")
+	}
+	for key, value := range p.site.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := p.DoWithRetry(req, client)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return goquery.NewDocumentFromReader(resp.Body)
+}
+
+// parseSelector 拆分"CSS选择器@属性名"格式的选择器
+func parseSelector(selector string) (string, string) {
+	if idx := strings.LastIndex(selector, "@"); idx >= 0 {
+		return strings.TrimSpace(selector[:idx]), strings.TrimSpace(selector[idx+1:])
+	}
+	return strings.TrimSpace(selector), ""
+}
+
+// selectValues 获取选择器匹配的所有元素的文本或属性值
+func selectValues(s *goquery.Selection, selector string) []string {
+	css, attr := parseSelector(selector)
+	target := s
+	if css != "" {
+		target = s.Find(css)
+	}
+
+	var values []string
+	target.Each(func(i int, el *goquery.Selection) {
+		var value string
+		if attr != "" {
+			value, _ = el.Attr(attr)
+		} else {
+			value = el.Text()
+		}
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	})
+	return values
+}
+
+// selectValue 获取选择器匹配的第一个非空值
+func selectValue(s *goquery.Selection, selector string) string {
+	if values := selectValues(s, selector); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// selectJoined 获取选择器匹配的多个值并用"、"连接，limit大于0时超出部分以"等"省略
+func selectJoined(s *goquery.Selection, selector string, limit int) string {
+	values := selectValues(s, selector)
+	if limit > 0 && len(values) > limit {
+		return strings.Join(values[:limit], "、") + "等"
+	}
+	return strings.Join(values, "、")
+}
+
+// resolveURL 将相对地址解析为绝对地址
+func resolveURL(base string, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	return baseURL.ResolveReference(refURL).String()
+}
@@ -0,0 +1,244 @@
+package scraper
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"pansou/plugin"
+)
+
+// SiteDefinition 站点定义，描述一个HTML搜索站点的请求方式与页面结构
+//
+// URL模板占位符：{keyword}（URL编码后的关键词）、{id}（从详情链接中提取的ID）、{base}（站点根地址）
+type SiteDefinition struct {
+	Name              string            `json:"name" yaml:"name"`                                                   // 插件名称
+	Priority          int               `json:"priority" yaml:"priority"`                                           // 插件优先级（1-4，越小越优先）
+	SkipServiceFilter bool              `json:"skip_service_filter,omitempty" yaml:"skip_service_filter,omitempty"` // 是否跳过Service层关键词过滤
+	BaseURL           string            `json:"base_url" yaml:"base_url"`                                           // 站点根地址，如 http://example.com
+	SearchURL         string            `json:"search_url" yaml:"search_url"`                                       // 搜索页URL模板
+	Headers           map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`                         // 额外请求头，默认带浏览器UA与Referer
+	Timeout           int               `json:"timeout,omitempty" yaml:"timeout,omitempty"`                         // 搜索请求超时（秒），默认8
+	DetailTimeout     int               `json:"detail_timeout,omitempty" yaml:"detail_timeout,omitempty"`           // 详情请求超时（秒），默认6
+	MaxConcurrency    int               `json:"max_concurrency,omitempty" yaml:"max_concurrency,omitempty"`         // 详情页最大并发数，默认20
+	List              ListDefinition    `json:"list" yaml:"list"`                                                   // 搜索结果列表解析规则
+	Detail            *DetailDefinition `json:"detail,omitempty" yaml:"detail,omitempty"`                           // 详情页解析规则，为空时直接从列表项提取链接
+	LinkTypes         []string          `json:"link_types,omitempty" yaml:"link_types,omitempty"`                   // 允许的网盘类型，如["quark"]，为空表示全部
+	LinkPattern       string            `json:"link_pattern,omitempty" yaml:"link_pattern,omitempty"`               // 链接必须匹配的正则（可选）
+
+	linkRegex *regexp.Regexp
+	idRegex   *regexp.Regexp
+}
+
+// ListDefinition 搜索结果列表解析规则
+//
+// 选择器格式："CSS选择器" 取文本，"CSS选择器@属性名" 取属性值
+type ListDefinition struct {
+	Item      string         `json:"item" yaml:"item"`                             // 结果项选择器，如 .module-search-item
+	DetailURL string         `json:"detail_url" yaml:"detail_url"`                 // 详情链接选择器，如 .video-info-header h3 a@href
+	IDRegex   string         `json:"id_regex,omitempty" yaml:"id_regex,omitempty"` // 从详情链接提取ID的正则，取第一个捕获组
+	Title     string         `json:"title" yaml:"title"`                           // 标题选择器
+	Tags      string         `json:"tags,omitempty" yaml:"tags,omitempty"`         // 标签选择器（匹配多个元素）
+	Content   []ContentField `json:"content,omitempty" yaml:"content,omitempty"`   // 拼接到内容描述中的字段
+	Links     string         `json:"links,omitempty" yaml:"links,omitempty"`       // 列表项内的链接选择器（无详情页时使用）
+}
+
+// ContentField 内容描述字段
+type ContentField struct {
+	Label    string `json:"label,omitempty" yaml:"label,omitempty"`   // 字段前缀，如"导演："
+	Suffix   string `json:"suffix,omitempty" yaml:"suffix,omitempty"` // 字段后缀，如"】"
+	Selector string `json:"selector" yaml:"selector"`                 // 字段选择器，匹配多个元素时用"、"连接
+	Limit    int    `json:"limit,omitempty" yaml:"limit,omitempty"`   // 最多取几个元素，超出时追加"等"
+}
+
+// DetailDefinition 详情页解析规则
+type DetailDefinition struct {
+	URL      string   `json:"url" yaml:"url"`                               // 详情页URL模板，为空时使用列表中的详情链接
+	Links    []string `json:"links" yaml:"links"`                           // 链接选择器列表，如 [data-clipboard-text]@data-clipboard-text
+	Password string   `json:"password,omitempty" yaml:"password,omitempty"` // 提取码选择器（可选），未命中时从页面文本中识别
+}
+
+// 默认参数
+const (
+	defaultSiteTimeout       = 8
+	defaultSiteDetailTimeout = 6
+	defaultSiteConcurrency   = 20
+)
+
+// bundledSites 内置站点定义，编译进程序并默认注册
+//
+//go:embed sites/*.yaml
+var bundledSites embed.FS
+
+func init() {
+	loadBundledSites()
+}
+
+// loadBundledSites 注册内置站点定义，SCRAPER_SITES_DIR中的同名站点会覆盖内置站点
+func loadBundledSites() {
+	entries, err := bundledSites.ReadDir("sites")
+	if err != nil {
+		log.Printf("[scraper] 读取内置站点定义失败: %v", err)
+		return
+	}
+	for _, entry := range entries {
+		file := "sites/" + entry.Name()
+		data, err := bundledSites.ReadFile(file)
+		if err != nil {
+			log.Printf("[scraper] 跳过内置站点定义 %s: %v", file, err)
+			continue
+		}
+		site, err := parseSite(data, filepath.Ext(file))
+		if err != nil {
+			log.Printf("[scraper] 跳过内置站点定义 %s: %v", file, err)
+			continue
+		}
+		plugin.RegisterGlobalPlugin(NewScraperPlugin(site))
+	}
+}
+
+// LoadSites 从目录加载站点定义（*.json、*.yaml、*.yml）并注册为全局插件，返回成功注册的数量
+// 单个文件无效时记录日志并跳过，不影响其他站点
+func LoadSites(dir string) (int, error) {
+	if dir == "" {
+		return 0, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, fmt.Errorf("读取站点定义目录失败: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".json", ".yaml", ".yml":
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+
+	count := 0
+	for _, file := range files {
+		site, err := LoadSiteFile(file)
+		if err != nil {
+			log.Printf("[scraper] 跳过站点定义 %s: %v", file, err)
+			continue
+		}
+		if _, exists := plugin.GetPluginByName(site.Name); exists {
+			log.Printf("[scraper] 站点 %s 与已注册插件同名，将覆盖原插件", site.Name)
+		}
+		plugin.RegisterGlobalPlugin(NewScraperPlugin(site))
+		count++
+	}
+	return count, nil
+}
+
+// LoadSiteFile 加载并校验单个站点定义文件
+func LoadSiteFile(path string) (*SiteDefinition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseSite(data, filepath.Ext(path))
+}
+
+// parseSite 按扩展名解析并校验站点定义
+func parseSite(data []byte, ext string) (*SiteDefinition, error) {
+	site := &SiteDefinition{}
+	var err error
+	switch strings.ToLower(ext) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, site)
+	default:
+		err = json.Unmarshal(data, site)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析失败: %w", err)
+	}
+
+	if err := site.compile(); err != nil {
+		return nil, err
+	}
+	return site, nil
+}
+
+// compile 校验站点定义并补全默认值
+func (s *SiteDefinition) compile() error {
+	if s.Name == "" {
+		return fmt.Errorf("缺少name")
+	}
+	// 来源识别依赖 "插件名-ID" 格式的UniqueID
+	if strings.ContainsAny(s.Name, "-:") {
+		return fmt.Errorf("站点名称 %s 不能包含 - 或 :", s.Name)
+	}
+	if s.SearchURL == "" {
+		return fmt.Errorf("缺少search_url")
+	}
+	if !strings.Contains(s.SearchURL, "{keyword}") {
+		return fmt.Errorf("search_url缺少{keyword}占位符")
+	}
+	if s.List.Item == "" || s.List.Title == "" {
+		return fmt.Errorf("list.item与list.title不能为空")
+	}
+	if s.Detail == nil && s.List.Links == "" {
+		return fmt.Errorf("需要配置detail或list.links")
+	}
+	if s.Detail != nil {
+		if len(s.Detail.Links) == 0 {
+			return fmt.Errorf("detail.links不能为空")
+		}
+		if s.List.DetailURL == "" {
+			return fmt.Errorf("配置detail时list.detail_url不能为空")
+		}
+		if strings.Contains(s.Detail.URL, "{id}") && s.List.IDRegex == "" {
+			return fmt.Errorf("detail.url使用{id}时list.id_regex不能为空")
+		}
+	}
+
+	if s.Priority < 1 || s.Priority > 4 {
+		s.Priority = 3
+	}
+	if s.Timeout <= 0 {
+		s.Timeout = defaultSiteTimeout
+	}
+	if s.DetailTimeout <= 0 {
+		s.DetailTimeout = defaultSiteDetailTimeout
+	}
+	if s.MaxConcurrency <= 0 {
+		s.MaxConcurrency = defaultSiteConcurrency
+	}
+	s.BaseURL = strings.TrimSuffix(s.BaseURL, "/")
+
+	var err error
+	if s.List.IDRegex != "" {
+		if s.idRegex, err = regexp.Compile(s.List.IDRegex); err != nil {
+			return fmt.Errorf("list.id_regex无效: %w", err)
+		}
+	}
+	if s.LinkPattern != "" {
+		if s.linkRegex, err = regexp.Compile(s.LinkPattern); err != nil {
+			return fmt.Errorf("link_pattern无效: %w", err)
+		}
+	}
+	return nil
+}
+
+// expandURL 替换URL模板中的占位符
+func (s *SiteDefinition) expandURL(template string, replacements map[string]string) string {
+	result := strings.ReplaceAll(template, "{base}", s.BaseURL)
+	for key, value := range replacements {
+		result = strings.ReplaceAll(result, "{"+key+"}", value)
+	}
+	return result
+}
@@ -0,0 +1,134 @@
+package scraper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"pansou/plugin"
+)
+
+func TestBundledSitesRegistered(t *testing.T) {
+	want := map[string]int{"labi": 1, "shandian": 2, "muou": 2, "duoduo": 2}
+	for name, priority := range want {
+		p, ok := plugin.GetPluginByName(name)
+		if !ok {
+			t.Errorf("内置站点 %s 未注册", name)
+			continue
+		}
+		if _, ok := p.(*ScraperPlugin); !ok {
+			t.Errorf("%s 应由站点定义实例化，实际为 %T", name, p)
+		}
+		if p.Priority() != priority {
+			t.Errorf("%s 的优先级为 %d，期望 %d", name, p.Priority(), priority)
+		}
+	}
+}
+
+// mockSitePages 模拟MacCMS影视站的搜索页与详情页
+func mockSitePages(t *testing.T) *httptest.Server {
+	t.Helper()
+	pages := map[string]string{
+		"/index.php/vod/search/wd/凡人修仙传.html": `<html><body>
+<div class="module-search-item">
+  <div class="module-item-pic"><a href="/index.php/vod/detail/id/101.html"></a></div>
+  <div class="video-info-header"><h3><a href="/index.php/vod/detail/id/101.html">凡人修仙传</a></h3></div>
+  <div class="video-serial">更新至第120集</div>
+  <div class="video-info-aux"><div class="tag-link"><a>国产动漫</a></div><div class="tag-link"><a>2020</a></div></div>
+  <div class="video-info-items"><span class="video-info-itemtitle">导演：</span><div class="video-info-actor"><a>王裕仁</a></div></div>
+  <div class="video-info-items"><span class="video-info-itemtitle">主演：</span><div class="video-info-actor"><a>杨天翔</a><a>钱文青</a><a>歪歪</a><a>姜广涛</a></div></div>
+  <div class="video-info-items"><span class="video-info-itemtitle">剧情：</span><div class="video-info-item">平凡少年韩立踏上修仙之路。</div></div>
+</div>
+<div class="module-search-item">
+  <div class="module-item-pic"><a href="/index.php/vod/detail/id/102.html"></a></div>
+  <div class="video-info-header"><h3><a href="/index.php/vod/detail/id/102.html">斗罗大陆</a></h3></div>
+</div>
+</body></html>`,
+		"/index.php/vod/detail/id/101.html": `<html><body><div id="download-list">
+  <div class="module-row-one"><a data-clipboard-text="https://pan.quark.cn/s/aaa111" href="https://pan.quark.cn/s/aaa111">复制</a></div>
+  <div class="module-row-one"><a href="https://drive.uc.cn/s/bbb222">UC网盘</a></div>
+  <div class="module-row-one"><a href="javascript:void(0)">举报</a></div>
+</div></body></html>`,
+		"/index.php/vod/detail/id/102.html": `<html><body><div id="download-list">
+  <div class="module-row-one"><a href="https://pan.quark.cn/s/ccc333">夸克</a></div>
+</div></body></html>`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestBundledSiteSearch(t *testing.T) {
+	server := mockSitePages(t)
+
+	tests := []struct {
+		file  string
+		links []string
+	}{
+		{"labi.yaml", []string{"https://pan.quark.cn/s/aaa111"}},
+		{"shandian.yaml", []string{"https://drive.uc.cn/s/bbb222"}},
+		{"muou.yaml", []string{"https://pan.quark.cn/s/aaa111", "https://drive.uc.cn/s/bbb222"}},
+		{"duoduo.yaml", []string{"https://pan.quark.cn/s/aaa111", "https://drive.uc.cn/s/bbb222"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			site, err := LoadSiteFile(filepath.Join("sites", tt.file))
+			if err != nil {
+				t.Fatalf("站点定义无效: %v", err)
+			}
+			site.BaseURL = server.URL
+			p := NewScraperPlugin(site)
+
+			results, err := p.searchImpl(&http.Client{}, "凡人修仙传", nil)
+			if err != nil {
+				t.Fatalf("搜索失败: %v", err)
+			}
+			if len(results) != 1 {
+				t.Fatalf("应只返回命中关键词的1条结果，实际 %+v", results)
+			}
+			r := results[0]
+			if r.UniqueID != site.Name+"-101" || r.Title != "凡人修仙传" {
+				t.Errorf("结果ID或标题不正确: %s %s", r.UniqueID, r.Title)
+			}
+			wantContent := "【更新至第120集】\n导演：王裕仁\n主演：杨天翔、钱文青、歪歪等\n平凡少年韩立踏上修仙之路。"
+			if r.Content != wantContent {
+				t.Errorf("内容为 %q，期望 %q", r.Content, wantContent)
+			}
+			if len(r.Tags) != 2 {
+				t.Errorf("标签不正确: %v", r.Tags)
+			}
+			if len(r.Links) != len(tt.links) {
+				t.Fatalf("链接为 %+v，期望 %v", r.Links, tt.links)
+			}
+			for i, link := range r.Links {
+				if link.URL != tt.links[i] {
+					t.Errorf("第%d个链接为 %s，期望 %s", i, link.URL, tt.links[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSiteNameRejectsReservedChars(t *testing.T) {
+	data, err := bundledSites.ReadFile("sites/labi.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"la-bi", "la:bi"} {
+		renamed := strings.Replace(string(data), "name: labi", "name: "+strconv.Quote(name), 1)
+		if _, err := parseSite([]byte(renamed), ".yaml"); err == nil {
+			t.Errorf("站点名称 %q 应被拒绝", name)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package plugin
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ext的保留键，只能由服务端设置
+const (
+	SearchOptionsKey  = "_search_options"  // 搜索选项，由API层设置，插件通过GetSearchOptions读取
+	SearchDeadlineKey = "_search_deadline" // 调用方的截止时间，由搜索服务设置，插件通过GetSearchDeadline读取
+)
+
+// IsReservedExtKey 是否为ext的保留键
+func IsReservedExtKey(key string) bool {
+	return key == SearchOptionsKey || key == SearchDeadlineKey
+}
+
+// 搜索深度
+const (
+	DepthQuick  = "quick"  // 只抓取第一页
+	DepthNormal = "normal" // 插件默认页数
+	DepthDeep   = "deep"   // 插件允许的最大页数
+)
+
+// SearchOptions 对所有插件生效的标准搜索选项
+type SearchOptions struct {
+	Depth    string `json:"depth,omitempty"`     // 搜索深度：quick、normal（默认）、deep
+	MaxPages int    `json:"max_pages,omitempty"` // 最大抓取页数，优先于Depth，不超过插件允许的最大页数
+}
+
+// Normalize 校验搜索选项并统一深度取值
+func (o *SearchOptions) Normalize() error {
+	o.Depth = strings.ToLower(strings.TrimSpace(o.Depth))
+	switch o.Depth {
+	case "", DepthNormal:
+		o.Depth = ""
+	case DepthQuick, DepthDeep:
+	default:
+		return fmt.Errorf("depth无效: %q（应为quick、normal或deep）", o.Depth)
+	}
+	if o.MaxPages < 0 {
+		return fmt.Errorf("max_pages不能为负数")
+	}
+	return nil
+}
+
+// IsDefault 是否为默认搜索选项
+func (o SearchOptions) IsDefault() bool {
+	return o.Depth == "" && o.MaxPages == 0
+}
+
+// CacheKey 返回区分搜索选项的缓存键后缀，默认选项返回空字符串以保持原有缓存键
+func (o SearchOptions) CacheKey() string {
+	if o.MaxPages > 0 {
+		return fmt.Sprintf("max_pages=%d", o.MaxPages)
+	}
+	if o.Depth != "" {
+		return "depth=" + o.Depth
+	}
+	return ""
+}
+
+// Pages 计算插件应抓取的页数：normalPages为插件默认页数，deepPages为插件允许的最大页数
+func (o SearchOptions) Pages(normalPages, deepPages int) int {
+	if deepPages < normalPages {
+		deepPages = normalPages
+	}
+	if o.MaxPages > 0 {
+		if o.MaxPages > deepPages {
+			return deepPages
+		}
+		return o.MaxPages
+	}
+	switch o.Depth {
+	case DepthQuick:
+		return 1
+	case DepthDeep:
+		return deepPages
+	}
+	return normalPages
+}
+
+// GetSearchOptions 从ext中读取搜索选项，未设置时返回默认选项
+func GetSearchOptions(ext map[string]interface{}) SearchOptions {
+	switch v := ext[SearchOptionsKey].(type) {
+	case SearchOptions:
+		return v
+	case *SearchOptions:
+		if v != nil {
+			return *v
+		}
+	}
+	return SearchOptions{}
+}
+
+// SetSearchOptions 把搜索选项写入ext，默认选项时移除保留键
+func SetSearchOptions(ext map[string]interface{}, opts SearchOptions) {
+	if opts.IsDefault() {
+		delete(ext, SearchOptionsKey)
+		return
+	}
+	ext[SearchOptionsKey] = opts
+}
+
+// WithoutSearchOptions 返回不含保留键（搜索选项、截止时间）的ext副本，用于把ext转发给外部服务
+func WithoutSearchOptions(ext map[string]interface{}) map[string]interface{} {
+	reserved := false
+	for key := range ext {
+		if IsReservedExtKey(key) {
+			reserved = true
+			break
+		}
+	}
+	if !reserved {
+		return ext
+	}
+	copied := make(map[string]interface{}, len(ext))
+	for key, value := range ext {
+		if !IsReservedExtKey(key) {
+			copied[key] = value
+		}
+	}
+	return copied
+}
+
+// WithSearchDeadline 返回带调用方截止时间的ext副本（不修改传入的ext，它可能被其他搜索同时读取）
+func WithSearchDeadline(ext map[string]interface{}, deadline time.Time) map[string]interface{} {
+	copied := make(map[string]interface{}, len(ext)+1)
+	for key, value := range ext {
+		copied[key] = value
+	}
+	copied[SearchDeadlineKey] = deadline
+	return copied
+}
+
+// GetSearchDeadline 从ext中读取调用方的截止时间，未设置时返回false。
+// 调用方在截止时间后不再等待结果，转发请求的插件可以据此缩短超时
+func GetSearchDeadline(ext map[string]interface{}) (time.Time, bool) {
+	deadline, ok := ext[SearchDeadlineKey].(time.Time)
+	return deadline, ok && !deadline.IsZero()
+}
+
+// PageLimit 按请求的搜索选项计算插件应抓取的页数，插件配置的max_pages会替换defaultPages
+func (p *BaseAsyncPlugin) PageLimit(ext map[string]interface{}, defaultPages, deepPages int) int {
+	return GetSearchOptions(ext).Pages(p.Config().MaxPagesOr(defaultPages), deepPages)
+}
+
+// responseCacheKey 生成插件响应缓存键，非默认搜索选项使用独立的缓存
+func (p *BaseAsyncPlugin) responseCacheKey(keyword string, ext map[string]interface{}) string {
+	key := fmt.Sprintf("%s:%s", p.name, keyword)
+	if suffix := GetSearchOptions(ext).CacheKey(); suffix != "" {
+		key += "|" + suffix
+	}
+	return key
+}
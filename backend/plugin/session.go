@@ -0,0 +1,700 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"pansou/config"
+	"pansou/util"
+)
+
+const (
+	// loginRetryInterval 登录失败后再次尝试登录的最短间隔，避免频繁请求登录接口
+	loginRetryInterval = time.Minute
+	// maxSessionBodyCheck 检测会话过期时最多读取的响应内容长度
+	maxSessionBodyCheck = 1 << 20
+)
+
+// SessionConfig 插件会话配置：Cookie持久化、登录流程与会话过期检测
+type SessionConfig struct {
+	Login           *LoginConfig `json:"login,omitempty" yaml:"login,omitempty"`                       // 登录流程，为空时只保存Cookie
+	ExpiredStatus   []int        `json:"expired_status,omitempty" yaml:"expired_status,omitempty"`     // 视为会话过期的响应状态码，如401、403
+	ExpiredContains []string     `json:"expired_contains,omitempty" yaml:"expired_contains,omitempty"` // 响应内容包含任一字符串时视为会话过期
+	ExpiredRedirect string       `json:"expired_redirect,omitempty" yaml:"expired_redirect,omitempty"` // 重定向地址包含该字符串时视为会话过期
+}
+
+// LoginConfig 登录流程定义
+type LoginConfig struct {
+	FormURL         string            `json:"form_url,omitempty" yaml:"form_url,omitempty"`                 // 登录页地址，登录前先访问以获取Cookie，页面中的隐藏表单字段（如Discuz的formhash）会加入表单
+	URL             string            `json:"url,omitempty" yaml:"url,omitempty"`                           // 登录提交地址，为空时只访问登录页（Cookie握手）
+	Method          string            `json:"method,omitempty" yaml:"method,omitempty"`                     // 提交方法，默认POST
+	Form            map[string]string `json:"form,omitempty" yaml:"form,omitempty"`                         // 表单字段，值支持${ENV}引用环境变量，避免在配置文件中保存密码
+	Headers         map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`                   // 登录请求的额外请求头
+	SuccessCookie   string            `json:"success_cookie,omitempty" yaml:"success_cookie,omitempty"`     // 登录后存在该Cookie视为成功
+	SuccessContains string            `json:"success_contains,omitempty" yaml:"success_contains,omitempty"` // 登录响应包含该字符串视为成功
+}
+
+// normalize 校验会话配置
+func (c *SessionConfig) normalize() error {
+	for _, status := range c.ExpiredStatus {
+		if status < 100 || status > 599 {
+			return fmt.Errorf("expired_status无效: %d", status)
+		}
+	}
+	if c.Login == nil {
+		return nil
+	}
+	login := c.Login
+	if login.FormURL == "" && login.URL == "" {
+		return fmt.Errorf("login需要设置form_url或url")
+	}
+	for _, rawURL := range []string{login.FormURL, login.URL} {
+		if rawURL != "" && !strings.HasPrefix(rawURL, "http://") && !strings.HasPrefix(rawURL, "https://") {
+			return fmt.Errorf("login地址无效: %q", rawURL)
+		}
+	}
+	login.Method = strings.ToUpper(strings.TrimSpace(login.Method))
+	switch login.Method {
+	case "":
+		login.Method = http.MethodPost
+	case http.MethodGet, http.MethodPost:
+	default:
+		return fmt.Errorf("login.method不支持: %q", login.Method)
+	}
+	return nil
+}
+
+// SessionStatus 插件会话状态（不包含Cookie值）
+type SessionStatus struct {
+	Plugin          string    `json:"plugin"`
+	Persistent      bool      `json:"persistent"`       // Cookie是否持久化到磁盘
+	LoginConfigured bool      `json:"login_configured"` // 是否配置了登录流程
+	LoggedIn        bool      `json:"logged_in"`
+	Cookies         int       `json:"cookies"`
+	Logins          int64     `json:"logins"`         // 成功登录次数
+	LoginFailures   int64     `json:"login_failures"` // 登录失败次数
+	Expirations     int64     `json:"expirations"`    // 检测到会话过期的次数
+	LastLogin       time.Time `json:"last_login,omitempty"`
+	LastExpired     time.Time `json:"last_expired,omitempty"`
+	LastError       string    `json:"last_error,omitempty"`
+}
+
+// storedCookie 持久化的Cookie
+type storedCookie struct {
+	URL      string    `json:"url"`
+	Name     string    `json:"name"`
+	Value    string    `json:"value"`
+	Domain   string    `json:"domain,omitempty"`
+	Path     string    `json:"path,omitempty"`
+	Expires  time.Time `json:"expires,omitempty"`
+	Secure   bool      `json:"secure,omitempty"`
+	HttpOnly bool      `json:"http_only,omitempty"`
+}
+
+// sessionFile 会话持久化文件结构
+type sessionFile struct {
+	Cookies   []storedCookie `json:"cookies"`
+	LoggedIn  bool           `json:"logged_in,omitempty"`
+	LastLogin time.Time      `json:"last_login,omitempty"`
+}
+
+// Session 插件会话：所有使用该会话的HTTP客户端共享同一个Cookie Jar，
+// 配置了登录流程时在首次请求前登录，检测到会话过期后重新登录并重试请求
+type Session struct {
+	name string
+	cfg  SessionConfig
+	path string // 持久化文件路径，空表示不持久化
+
+	jar         *cookiejar.Jar
+	loginClient *http.Client
+	loginMu     sync.Mutex // 保证同一时间只有一个登录请求
+
+	mu               sync.Mutex
+	cookies          map[string]storedCookie
+	loggedIn         bool
+	loginSeq         uint64 // 每次成功登录加1，用于判断其他请求是否已经重新登录
+	logins           int64
+	loginFailures    int64
+	expirations      int64
+	lastLogin        time.Time
+	lastLoginAttempt time.Time
+	lastExpired      time.Time
+	lastError        string
+}
+
+// 插件会话注册表
+var (
+	sessions     = make(map[string]*Session)
+	sessionsLock sync.RWMutex
+)
+
+// newSession 创建插件会话，设置了PLUGIN_SESSION_DIR时从磁盘恢复Cookie
+func newSession(name string, cfg SessionConfig) *Session {
+	jar, _ := cookiejar.New(nil)
+	s := &Session{
+		name:    name,
+		cfg:     cfg,
+		jar:     jar,
+		cookies: make(map[string]storedCookie),
+	}
+	if config.AppConfig != nil && config.AppConfig.PluginSessionDir != "" {
+		s.path = filepath.Join(config.AppConfig.PluginSessionDir, name+".json")
+		if err := s.load(); err != nil {
+			log.Printf("[plugin] 恢复插件 %s 的会话失败: %v", name, err)
+		}
+	}
+	return s
+}
+
+// setSessions 替换插件会话注册表（加载插件配置时调用）
+func setSessions(configs map[string]PluginConfig) {
+	created := make(map[string]*Session)
+	for name, cfg := range configs {
+		if cfg.Session != nil {
+			created[name] = newSession(name, *cfg.Session)
+		}
+	}
+	sessionsLock.Lock()
+	sessions = created
+	sessionsLock.Unlock()
+}
+
+// GetSession 获取插件会话，插件未配置session时返回nil
+func GetSession(name string) *Session {
+	sessionsLock.RLock()
+	defer sessionsLock.RUnlock()
+	return sessions[name]
+}
+
+// ListSessions 获取所有插件会话的状态，按插件名排序
+func ListSessions() []SessionStatus {
+	sessionsLock.RLock()
+	list := make([]SessionStatus, 0, len(sessions))
+	for _, s := range sessions {
+		list = append(list, s.Status())
+	}
+	sessionsLock.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Plugin < list[j].Plugin })
+	return list
+}
+
+// ClearSession 清除插件会话的Cookie与登录状态，下次请求时重新登录
+func ClearSession(name string) error {
+	s := GetSession(name)
+	if s == nil {
+		return fmt.Errorf("插件 %s 未配置会话", name)
+	}
+	s.Clear()
+	return nil
+}
+
+// Session 返回插件会话，未配置session时返回nil
+func (p *BaseAsyncPlugin) Session() *Session {
+	return GetSession(p.name)
+}
+
+// Attach 让HTTP客户端使用会话：共享Cookie Jar，请求前按需登录，会话过期时重新登录并重试。
+// 自建HTTP客户端的插件在ApplyConfig中调用，基础客户端会自动使用会话
+func (s *Session) Attach(client *http.Client) *http.Client {
+	next := client.Transport
+	if next == nil {
+		next = util.NewOutboundTransport(nil)
+	}
+	s.mu.Lock()
+	if s.loginClient == nil {
+		s.loginClient = &http.Client{Transport: next, Jar: sessionJar{s}, Timeout: client.Timeout}
+	}
+	s.mu.Unlock()
+
+	client.Jar = sessionJar{s}
+	client.Transport = sessionTransport{session: s, next: next}
+	return client
+}
+
+// Status 返回会话状态
+func (s *Session) Status() SessionStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return SessionStatus{
+		Plugin:          s.name,
+		Persistent:      s.path != "",
+		LoginConfigured: s.cfg.Login != nil,
+		LoggedIn:        s.loggedIn,
+		Cookies:         len(s.cookies),
+		Logins:          s.logins,
+		LoginFailures:   s.loginFailures,
+		Expirations:     s.expirations,
+		LastLogin:       s.lastLogin,
+		LastExpired:     s.lastExpired,
+		LastError:       s.lastError,
+	}
+}
+
+// Clear 清除Cookie与登录状态
+func (s *Session) Clear() {
+	jar, _ := cookiejar.New(nil)
+	s.mu.Lock()
+	s.jar = jar
+	s.cookies = make(map[string]storedCookie)
+	s.loggedIn = false
+	s.lastLoginAttempt = time.Time{}
+	s.lastError = ""
+	s.saveLocked()
+	s.mu.Unlock()
+}
+
+// Login 立即执行登录流程，未配置登录流程时返回错误
+func (s *Session) Login(ctx context.Context) error {
+	if s.cfg.Login == nil {
+		return fmt.Errorf("插件 %s 未配置登录流程", s.name)
+	}
+	s.loginMu.Lock()
+	defer s.loginMu.Unlock()
+	return s.doLogin(ctx)
+}
+
+// currentJar 返回当前的Cookie Jar（Clear会替换Jar）
+func (s *Session) currentJar() *cookiejar.Jar {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.jar
+}
+
+// currentSeq 返回当前登录序号与是否已登录
+func (s *Session) currentSeq() (uint64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loginSeq, s.loggedIn
+}
+
+// ensureLogin 配置了登录流程且尚未登录时登录，返回是否在此期间完成了登录
+func (s *Session) ensureLogin(ctx context.Context) bool {
+	if s.cfg.Login == nil {
+		return false
+	}
+	seq, loggedIn := s.currentSeq()
+	if loggedIn {
+		return false
+	}
+	return s.loginIfStale(ctx, seq)
+}
+
+// loginIfStale 登录序号仍为seq时执行登录；其他请求已在此之后登录成功时直接返回true
+func (s *Session) loginIfStale(ctx context.Context, seq uint64) bool {
+	s.loginMu.Lock()
+	defer s.loginMu.Unlock()
+
+	s.mu.Lock()
+	if s.loginSeq != seq && s.loggedIn {
+		s.mu.Unlock()
+		return true
+	}
+	if s.lastError != "" && time.Since(s.lastLoginAttempt) < loginRetryInterval {
+		s.mu.Unlock()
+		return false
+	}
+	s.mu.Unlock()
+
+	return s.doLogin(ctx) == nil
+}
+
+// doLogin 执行登录流程并记录结果（调用方需持有loginMu）
+func (s *Session) doLogin(ctx context.Context) error {
+	s.mu.Lock()
+	s.lastLoginAttempt = time.Now()
+	s.mu.Unlock()
+
+	err := s.submitLogin(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.loggedIn = false
+		s.loginFailures++
+		s.lastError = err.Error()
+		log.Printf("[plugin] 插件 %s 登录失败: %v", s.name, err)
+		return err
+	}
+	s.loggedIn = true
+	s.loginSeq++
+	s.logins++
+	s.lastLogin = time.Now()
+	s.lastError = ""
+	s.saveLocked()
+	return nil
+}
+
+// submitLogin 访问登录页并提交登录表单
+func (s *Session) submitLogin(ctx context.Context) error {
+	login := s.cfg.Login
+	s.mu.Lock()
+	client := s.loginClient
+	s.mu.Unlock()
+	if client == nil {
+		client = &http.Client{Transport: util.NewOutboundTransport(nil), Jar: sessionJar{s}, Timeout: 30 * time.Second}
+	}
+
+	form := url.Values{}
+	var body []byte
+	if login.FormURL != "" {
+		page, err := s.doLoginRequest(ctx, client, http.MethodGet, login.FormURL, nil)
+		if err != nil {
+			return fmt.Errorf("访问登录页失败: %w", err)
+		}
+		body = page
+		if doc, err := goquery.NewDocumentFromReader(bytes.NewReader(page)); err == nil {
+			doc.Find(`input[type="hidden"]`).Each(func(_ int, input *goquery.Selection) {
+				if name, ok := input.Attr("name"); ok && name != "" {
+					form.Set(name, input.AttrOr("value", ""))
+				}
+			})
+		}
+	}
+
+	if login.URL != "" {
+		for key, value := range login.Form {
+			form.Set(key, os.ExpandEnv(value))
+		}
+		submitted, err := s.doLoginRequest(ctx, client, login.Method, login.URL, form)
+		if err != nil {
+			return fmt.Errorf("提交登录失败: %w", err)
+		}
+		body = submitted
+	}
+
+	if login.SuccessContains != "" && !bytes.Contains(body, []byte(login.SuccessContains)) {
+		return fmt.Errorf("登录响应不包含 %q", login.SuccessContains)
+	}
+	if login.SuccessCookie != "" && !s.hasCookie(login.SuccessCookie) {
+		return fmt.Errorf("登录后没有Cookie %s", login.SuccessCookie)
+	}
+	return nil
+}
+
+// doLoginRequest 发送登录相关请求并返回响应内容
+func (s *Session) doLoginRequest(ctx context.Context, client *http.Client, method, rawURL string, form url.Values) ([]byte, error) {
+	var bodyReader io.Reader
+	target := rawURL
+	if form != nil {
+		if method == http.MethodGet {
+			separator := "?"
+			if strings.Contains(target, "?") {
+				separator = "&"
+			}
+			target += separator + form.Encode()
+		} else {
+			bodyReader = strings.NewReader(form.Encode())
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+	if bodyReader != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	for key, value := range s.cfg.Login.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSessionBodyCheck))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("状态码 %d", resp.StatusCode)
+	}
+	return data, nil
+}
+
+// hasCookie 检查会话中是否存在指定名称的Cookie
+func (s *Session) hasCookie(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.cookies {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isExpired 根据配置判断响应是否表示会话过期，需要读取响应内容时会还原响应体
+func (s *Session) isExpired(resp *http.Response) bool {
+	for _, status := range s.cfg.ExpiredStatus {
+		if resp.StatusCode == status {
+			return true
+		}
+	}
+	if s.cfg.ExpiredRedirect != "" && resp.StatusCode >= 300 && resp.StatusCode < 400 &&
+		strings.Contains(resp.Header.Get("Location"), s.cfg.ExpiredRedirect) {
+		return true
+	}
+	if len(s.cfg.ExpiredContains) == 0 || resp.Body == nil {
+		return false
+	}
+
+	prefix, err := io.ReadAll(io.LimitReader(resp.Body, maxSessionBodyCheck))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(prefix), resp.Body), resp.Body}
+	if err != nil {
+		return false
+	}
+	for _, marker := range s.cfg.ExpiredContains {
+		if marker != "" && bytes.Contains(prefix, []byte(marker)) {
+			return true
+		}
+	}
+	return false
+}
+
+// markExpired 记录会话过期
+func (s *Session) markExpired() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loggedIn = false
+	s.expirations++
+	s.lastExpired = time.Now()
+	s.saveLocked()
+}
+
+// withJarCookies 复制请求并用会话中最新的Cookie替换同名Cookie，用于登录后发送或重试请求
+func (s *Session) withJarCookies(req *http.Request) (*http.Request, error) {
+	cloned := req.Clone(req.Context())
+	if req.Body != nil && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		cloned.Body = body
+	}
+
+	cookies := make(map[string]*http.Cookie)
+	var order []string
+	for _, c := range req.Cookies() {
+		if _, ok := cookies[c.Name]; !ok {
+			order = append(order, c.Name)
+		}
+		cookies[c.Name] = c
+	}
+	for _, c := range s.currentJar().Cookies(req.URL) {
+		if _, ok := cookies[c.Name]; !ok {
+			order = append(order, c.Name)
+		}
+		cookies[c.Name] = c
+	}
+	cloned.Header.Del("Cookie")
+	for _, name := range order {
+		cloned.AddCookie(cookies[name])
+	}
+	return cloned, nil
+}
+
+// recordCookies 记录响应设置的Cookie，变化时写入磁盘
+func (s *Session) recordCookies(u *url.URL, cookies []*http.Cookie) {
+	now := time.Now()
+	origin := (&url.URL{Scheme: u.Scheme, Host: u.Host}).String()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changed := false
+	for _, c := range cookies {
+		domain := c.Domain
+		if domain == "" {
+			domain = u.Hostname()
+		}
+		key := strings.ToLower(domain) + ";" + c.Path + ";" + c.Name
+
+		expires := c.Expires
+		if c.MaxAge > 0 {
+			expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+		}
+		if c.MaxAge < 0 || (!expires.IsZero() && expires.Before(now)) {
+			if _, ok := s.cookies[key]; ok {
+				delete(s.cookies, key)
+				changed = true
+			}
+			continue
+		}
+
+		stored := storedCookie{
+			URL:      origin,
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Expires:  expires,
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+		}
+		if previous, ok := s.cookies[key]; !ok || previous.Value != stored.Value {
+			changed = true
+		}
+		s.cookies[key] = stored
+	}
+	if changed {
+		s.saveLocked()
+	}
+}
+
+// load 从磁盘恢复Cookie与登录状态，文件不存在时忽略
+func (s *Session) load() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var file sessionFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, c := range file.Cookies {
+		if !c.Expires.IsZero() && c.Expires.Before(now) {
+			continue
+		}
+		u, err := url.Parse(c.URL)
+		if err != nil {
+			continue
+		}
+		s.jar.SetCookies(u, []*http.Cookie{{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Expires:  c.Expires,
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+		}})
+		domain := c.Domain
+		if domain == "" {
+			domain = u.Hostname()
+		}
+		s.cookies[strings.ToLower(domain)+";"+c.Path+";"+c.Name] = c
+	}
+	s.loggedIn = file.LoggedIn && len(s.cookies) > 0
+	s.lastLogin = file.LastLogin
+	return nil
+}
+
+// saveLocked 把Cookie与登录状态写入磁盘（调用方需持有mu），失败时只记录日志
+func (s *Session) saveLocked() {
+	if s.path == "" {
+		return
+	}
+	file := sessionFile{
+		Cookies:   make([]storedCookie, 0, len(s.cookies)),
+		LoggedIn:  s.loggedIn,
+		LastLogin: s.lastLogin,
+	}
+	for _, c := range s.cookies {
+		file.Cookies = append(file.Cookies, c)
+	}
+	sort.Slice(file.Cookies, func(i, j int) bool {
+		a, b := file.Cookies[i], file.Cookies[j]
+		if a.URL != b.URL {
+			return a.URL < b.URL
+		}
+		return a.Name < b.Name
+	})
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(s.path), 0700)
+	}
+	if err == nil {
+		tmpPath := s.path + ".tmp"
+		if err = os.WriteFile(tmpPath, data, 0600); err == nil {
+			err = os.Rename(tmpPath, s.path)
+		}
+	}
+	if err != nil {
+		log.Printf("[plugin] 保存插件 %s 的会话失败: %v", s.name, err)
+	}
+}
+
+// sessionJar 会话的Cookie Jar，在标准Jar之外记录Cookie用于持久化
+type sessionJar struct {
+	session *Session
+}
+
+// SetCookies 实现http.CookieJar
+func (j sessionJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.session.currentJar().SetCookies(u, cookies)
+	j.session.recordCookies(u, cookies)
+}
+
+// Cookies 实现http.CookieJar
+func (j sessionJar) Cookies(u *url.URL) []*http.Cookie {
+	return j.session.currentJar().Cookies(u)
+}
+
+// sessionTransport 在请求前按需登录，检测到会话过期时重新登录并重试一次
+type sessionTransport struct {
+	session *Session
+	next    http.RoundTripper
+}
+
+// RoundTrip 实现http.RoundTripper
+func (t sessionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	s := t.session
+	// 登录请求不跟随搜索请求取消，避免搜索超时导致登录失败并进入冷却
+	ctx := context.Background()
+	// 登录发生在客户端设置Cookie之后，需要换成登录后的Cookie
+	if s.ensureLogin(ctx) {
+		refreshed, err := s.withJarCookies(req)
+		if err != nil {
+			return nil, err
+		}
+		req = refreshed
+	}
+	seq, _ := s.currentSeq()
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || !s.isExpired(resp) {
+		return resp, err
+	}
+	s.markExpired()
+
+	// 请求体无法重放时不重试
+	if s.cfg.Login == nil || (req.Body != nil && req.GetBody == nil) {
+		return resp, nil
+	}
+	if !s.loginIfStale(ctx, seq) {
+		return resp, nil
+	}
+	retry, err := s.withJarCookies(req)
+	if err != nil {
+		return resp, nil
+	}
+	resp.Body.Close()
+	return t.next.RoundTrip(retry)
+}
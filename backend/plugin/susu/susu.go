@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"io"
 	"math/rand"
-	"net"
 	"net/http"
 	"net/url"
 	"regexp"
@@ -16,6 +15,7 @@ import (
 	"github.com/PuerkitoBio/goquery"
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 	"pansou/util/json"
 )
 
@@ -60,6 +60,8 @@ func init() {
 
 // startCacheCleaner 定期清理缓存
 func startCacheCleaner() {
+	defer plugin.RecoverPanic("susu")
+	
 	ticker := time.NewTicker(1 * time.Hour)
 	defer ticker.Stop()
 	
@@ -133,7 +135,7 @@ func (p *SusuAsyncPlugin) doSearch(client *http.Client, keyword string, ext map[
 	req.Header.Set("Referer", "https://susuifa.com/")
 	
 	// 发送请求（带重试）
-	resp, err := p.doRequestWithRetry(client, req, MaxRetries)
+	resp, err := p.DoWithRetryPolicy(req, client, retryPolicy())
 	if err != nil {
 		return nil, fmt.Errorf("请求失败: %w", err)
 	}
@@ -196,6 +198,7 @@ func (p *SusuAsyncPlugin) doSearch(client *http.Client, keyword string, ext map[
 		
 		go func(index int, s *goquery.Selection) {
 			defer wg.Done()
+			defer plugin.RecoverPanic(p.Name())
 			
 			// 获取信号量
 			semaphore <- struct{}{}
@@ -258,6 +261,7 @@ func (p *SusuAsyncPlugin) doSearch(client *http.Client, keyword string, ext map[
 	
 	// 等待所有goroutine完成
 	go func() {
+		defer plugin.RecoverPanic(p.Name())
 		wg.Wait()
 		close(resultChan)
 		close(errorChan)
@@ -327,6 +331,7 @@ func (p *SusuAsyncPlugin) getLinks(client *http.Client, postID string) ([]model.
 		
 		go func(index int) {
 			defer wgLinks.Done()
+			defer plugin.RecoverPanic(p.Name())
 			
 			link, err := p.getButtonDetail(client, postID, index)
 			if err != nil {
@@ -341,6 +346,7 @@ func (p *SusuAsyncPlugin) getLinks(client *http.Client, postID string) ([]model.
 	
 	// 等待所有goroutine完成
 	go func() {
+		defer plugin.RecoverPanic(p.Name())
 		wgLinks.Wait()
 		close(linkChan)
 	}()
@@ -382,7 +388,7 @@ func (p *SusuAsyncPlugin) getButtonDetail(client *http.Client, postID string, in
 	req.Header.Set("Referer", fmt.Sprintf("https://susuifa.com/download?post_id=%s&index=0&i=%d", postID, index))
 	
 	// 发送请求（带重试）
-	resp, err := p.doRequestWithRetry(client, req, MaxRetries)
+	resp, err := p.DoWithRetryPolicy(req, client, retryPolicy())
 	if err != nil {
 		return model.Link{}, fmt.Errorf("请求失败: %w", err)
 	}
@@ -546,53 +552,14 @@ func (p *SusuAsyncPlugin) determineLinkType(url, name string) string {
 	return linkType
 }
 
-// doRequestWithRetry 发送HTTP请求并支持重试
-func (p *SusuAsyncPlugin) doRequestWithRetry(client *http.Client, req *http.Request, maxRetries int) (*http.Response, error) {
-	var resp *http.Response
-	var err error
-	
-	for i := 0; i <= maxRetries; i++ {
-		// 如果不是第一次尝试，等待一段时间
-		if i > 0 {
-			// 指数退避算法
-			backoff := time.Duration(1<<uint(i-1)) * 500 * time.Millisecond
-			if backoff > 5*time.Second {
-				backoff = 5 * time.Second
-			}
-			time.Sleep(backoff)
-		}
-		
-		// 克隆请求，避免重用同一个请求对象
-		reqClone := req.Clone(req.Context())
-		
-		// 发送请求
-		resp, err = client.Do(reqClone)
-		
-		// 如果请求成功或者是不可重试的错误，则退出循环
-		if err == nil || !isRetriableError(err) {
-			break
-		}
-	}
-	
-	return resp, err
-}
-
-// isRetriableError 判断错误是否可以重试
-func isRetriableError(err error) bool {
-	if err == nil {
-		return false
-	}
-	
-	// 判断是否是网络错误或超时错误
-	if netErr, ok := err.(net.Error); ok {
-		return netErr.Timeout() || netErr.Temporary()
-	}
-	
-	// 其他可能需要重试的错误类型
-	errStr := err.Error()
-	return strings.Contains(errStr, "connection refused") ||
-		   strings.Contains(errStr, "connection reset") ||
-		   strings.Contains(errStr, "EOF")
+// retryPolicy 插件的默认重试策略，尝试次数由MaxRetries决定，搜索接口的POST请求没有副作用，允许重试
+func retryPolicy() util.RetryPolicy {
+	policy := util.DefaultRetryPolicy()
+	policy.MaxAttempts = MaxRetries + 1
+	policy.Backoff = 500 * time.Millisecond
+	policy.MaxBackoff = 5 * time.Second
+	policy.RetryNonIdempotent = true
+	return policy
 }
 
 // md5sum 计算字符串的MD5值的简化版本
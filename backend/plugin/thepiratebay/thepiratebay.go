@@ -14,6 +14,7 @@ import (
 	"github.com/PuerkitoBio/goquery"
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 )
 
 // 常量定义
@@ -33,6 +34,9 @@ const (
 	// 最大分页数（避免无限请求）
 	MaxPages = 30
 	
+	// 深度搜索时的最大分页数
+	DeepMaxPages = 60
+	
 	// HTTP连接池配置 - 针对高并发优化
 	MaxIdleConns        = 200  // 增加全局空闲连接池大小
 	MaxIdleConnsPerHost = 80   // 增加每个主机的空闲连接数，提高连接复用
@@ -95,7 +99,7 @@ func createOptimizedHTTPClient() *http.Client {
 	}
 	
 	return &http.Client{
-		Transport: transport,
+		Transport: util.NewOutboundTransport(transport),
 		Timeout:   DefaultTimeout,
 	}
 }
@@ -108,6 +112,24 @@ func NewThePirateBayPlugin() *ThePirateBayPlugin {
 	}
 }
 
+// Capabilities 声明插件能力：只返回磁力链接
+func (p *ThePirateBayPlugin) Capabilities() plugin.Capabilities {
+	return plugin.Capabilities{
+		CloudTypes:       []string{"magnet"},
+		Categories:       []string{plugin.CategoryFilm, plugin.CategorySoftware, plugin.CategoryMusic, plugin.CategoryBooks},
+		Languages:        []string{"en"},
+		Magnet:           true,
+		TypicalLatencyMs: 3000,
+	}
+}
+
+// ExtSchema 声明支持的ext参数
+func (p *ThePirateBayPlugin) ExtSchema() []plugin.ExtParam {
+	return []plugin.ExtParam{
+		{Name: "title_en", Type: plugin.ExtTypeString, Description: "英文标题，提供时代替关键词进行搜索"},
+	}
+}
+
 // 初始化插件
 func init() {
 	plugin.RegisterGlobalPlugin(NewThePirateBayPlugin())
@@ -118,6 +140,8 @@ func init() {
 
 // startCacheCleaner 定期清理缓存
 func startCacheCleaner() {
+	defer plugin.RecoverPanic("thepiratebay")
+	
 	ticker := time.NewTicker(1 * time.Hour)
 	defer ticker.Stop()
 	
@@ -171,8 +195,8 @@ func (p *ThePirateBayPlugin) searchImpl(client *http.Client, keyword string, ext
 	
 	// 2. 如果有多页，并发搜索其他页面（限制最大页数）
 	maxPagesToSearch := totalPages
-	if maxPagesToSearch > MaxPages {
-		maxPagesToSearch = MaxPages
+	if maxPages := p.PageLimit(ext, MaxPages, DeepMaxPages); maxPagesToSearch > maxPages {
+		maxPagesToSearch = maxPages
 	}
 	
 	if totalPages > 1 && maxPagesToSearch > 1 {
@@ -190,6 +214,7 @@ func (p *ThePirateBayPlugin) searchImpl(client *http.Client, keyword string, ext
 			wg.Add(1)
 			go func(pageNum int) {
 				defer wg.Done()
+				defer plugin.RecoverPanic(p.Name())
 				
 				// 获取信号量
 				semaphore <- struct{}{}
@@ -261,7 +286,7 @@ func (p *ThePirateBayPlugin) searchPage(client *http.Client, encodedKeyword stri
 	req.Header.Set("Referer", "https://tpirbay.xyz/")
 	
 	// 6. 发送HTTP请求（带重试机制）
-	resp, err := p.doRequestWithRetry(req, client)
+	resp, err := p.DoWithRetry(req, client)
 	if err != nil {
 		return nil, 0, fmt.Errorf("[%s] 第%d页搜索请求失败: %w", p.Name(), page, err)
 	}
@@ -471,32 +496,3 @@ func (p *ThePirateBayPlugin) parseUploadTime(timeStr string) time.Time {
 	// 默认返回当前时间
 	return time.Now()
 }
-
-// doRequestWithRetry 带重试机制的HTTP请求 - 参考插件开发指南的最佳实践
-func (p *ThePirateBayPlugin) doRequestWithRetry(req *http.Request, client *http.Client) (*http.Response, error) {
-	maxRetries := 3
-	var lastErr error
-	
-	for i := 0; i < maxRetries; i++ {
-		if i > 0 {
-			// 指数退避重试
-			backoff := time.Duration(1<<uint(i-1)) * 200 * time.Millisecond
-			time.Sleep(backoff)
-		}
-		
-		// 克隆请求避免并发问题
-		reqClone := req.Clone(req.Context())
-		
-		resp, err := client.Do(reqClone)
-		if err == nil && resp.StatusCode == 200 {
-			return resp, nil
-		}
-		
-		if resp != nil {
-			resp.Body.Close()
-		}
-		lastErr = err
-	}
-	
-	return nil, fmt.Errorf("重试 %d 次后仍然失败: %w", maxRetries, lastErr)
-}
\ No newline at end of file
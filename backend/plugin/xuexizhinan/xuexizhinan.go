@@ -72,6 +72,17 @@ func NewXuexizhinanPlugin() *XuexizhinanPlugin {
 	}
 }
 
+// Capabilities 声明插件能力
+func (p *XuexizhinanPlugin) Capabilities() plugin.Capabilities {
+	return plugin.Capabilities{
+		CloudTypes:       []string{"quark", "magnet"},
+		Categories:       []string{plugin.CategoryFilm},
+		Languages:        []string{"zh"},
+		Magnet:           true,
+		TypicalLatencyMs: 2000,
+	}
+}
+
 // 初始化插件
 func init() {
 	plugin.RegisterGlobalPlugin(NewXuexizhinanPlugin())
@@ -82,6 +93,8 @@ func init() {
 
 // startCacheCleaner 定期清理缓存
 func startCacheCleaner() {
+	defer plugin.RecoverPanic("xuexizhinan")
+	
 	ticker := time.NewTicker(6 * time.Hour)
 	defer ticker.Stop()
 	
@@ -204,6 +217,7 @@ func (p *XuexizhinanPlugin) doSearch(client *http.Client, keyword string, ext ma
 		wg.Add(1)
 		go func(url string) {
 			defer wg.Done()
+			defer plugin.RecoverPanic(p.Name())
 			
 			// 获取信号量
 			semaphore <- struct{}{}
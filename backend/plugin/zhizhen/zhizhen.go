@@ -13,6 +13,7 @@ import (
 
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 	"pansou/util/json"
 )
 
@@ -77,7 +78,7 @@ func createOptimizedHTTPClient() *http.Client {
 	}
 
 	return &http.Client{
-		Transport: transport,
+		Transport: util.NewOutboundTransport(transport),
 		Timeout:   DefaultTimeout,
 	}
 }
@@ -140,7 +141,7 @@ func (p *ZhizhenAsyncPlugin) searchImpl(client *http.Client, keyword string, ext
 	req.Header.Set("Cache-Control", "no-cache")
 	
 	// 发送请求
-	resp, err := p.doRequestWithRetry(req, client)
+	resp, err := p.DoWithRetryPolicy(req, client, p.retryPolicy())
 	if err != nil {
 		return nil, fmt.Errorf("[%s] 搜索请求失败: %w", p.Name(), err)
 	}
@@ -412,30 +413,13 @@ func (p *ZhizhenAsyncPlugin) extractPassword(url string) string {
 	return ""
 }
 
-// doRequestWithRetry 带重试的HTTP请求（优化JSON API的重试策略）
-func (p *ZhizhenAsyncPlugin) doRequestWithRetry(req *http.Request, client *http.Client) (*http.Response, error) {
-	maxRetries := 2  // 对于JSON API减少重试次数
-	var lastErr error
-	
-	for i := 0; i < maxRetries; i++ {
-		resp, err := client.Do(req)
-		if err == nil {
-			if resp.StatusCode == http.StatusOK {
-				return resp, nil
-			}
-			resp.Body.Close()
-			lastErr = fmt.Errorf("HTTP状态码: %d", resp.StatusCode)
-		} else {
-			lastErr = err
-		}
-		
-		// JSON API快速重试：只等待很短时间
-		if i < maxRetries-1 {
-			time.Sleep(100 * time.Millisecond) // 从秒级改为100毫秒
-		}
-	}
-	
-	return nil, fmt.Errorf("[%s] 请求失败，重试%d次后仍失败: %w", p.Name(), maxRetries, lastErr)
+// retryPolicy JSON API快速重试：最多尝试2次，只等待很短时间
+func (p *ZhizhenAsyncPlugin) retryPolicy() util.RetryPolicy {
+	policy := util.DefaultRetryPolicy()
+	policy.MaxAttempts = 2
+	policy.Backoff = 100 * time.Millisecond
+	policy.MaxBackoff = 100 * time.Millisecond
+	return policy
 }
 
 // GetPerformanceStats 获取性能统计信息
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"pansou/config"
+	"pansou/model"
+	"pansou/plugin"
+)
+
+// pluginSourceTracker 记录一次搜索中各插件的执行情况。
+// 插件调用统计与熔断器由BaseAsyncPlugin在实际请求时更新，这里不重复记录
+type pluginSourceTracker struct {
+	mu      sync.Mutex
+	sources map[string]model.SourceStatus
+}
+
+// newPluginSourceTracker 创建插件执行情况记录器
+func newPluginSourceTracker() *pluginSourceTracker {
+	return &pluginSourceTracker{
+		sources: make(map[string]model.SourceStatus),
+	}
+}
+
+// skipOpenCircuit 插件熔断中时记为skipped并返回true，调用方不再提交搜索任务
+func (t *pluginSourceTracker) skipOpenCircuit(name string) bool {
+	if !plugin.RejectIfCircuitOpen(name) {
+		return false
+	}
+	t.set(model.SourceStatus{
+		Name:    name,
+		Status:  model.SourceStatusSkipped,
+		Breaker: breakerState(name),
+	})
+	return true
+}
+
+// record 记录插件在本次搜索中的执行情况。
+// AsyncSearch在响应超时后会返回空结果并在后台继续处理，这种情况按超时计入
+func (t *pluginSourceTracker) record(name string, latency time.Duration, results []model.SearchResult, err error) {
+	if err == nil && len(results) == 0 && config.AppConfig != nil &&
+		config.AppConfig.AsyncResponseTimeoutDur > 0 && latency >= config.AppConfig.AsyncResponseTimeoutDur {
+		err = plugin.ErrResponseTimeout
+	}
+
+	source := model.SourceStatus{
+		Name:      name,
+		Status:    model.SourceStatusOK,
+		Results:   len(results),
+		LatencyMs: latency.Milliseconds(),
+		Breaker:   breakerState(name),
+	}
+	switch {
+	case errors.Is(err, plugin.ErrCircuitOpen):
+		// 半开状态的探测请求正在进行，本次调用被熔断器跳过
+		source.Status = model.SourceStatusSkipped
+		source.LatencyMs = 0
+	case err != nil && plugin.CategorizeError(err) == plugin.ErrorCategoryTimeout:
+		source.Status = model.SourceStatusTimeout
+		source.Error = err.Error()
+	case err != nil:
+		source.Status = model.SourceStatusError
+		source.Error = err.Error()
+	case len(results) == 0:
+		source.Status = model.SourceStatusEmpty
+	}
+	t.set(source)
+}
+
+// set 保存插件执行情况
+func (t *pluginSourceTracker) set(source model.SourceStatus) {
+	t.mu.Lock()
+	t.sources[source.Name] = source
+	t.mu.Unlock()
+}
+
+// snapshot 获取所有插件的执行情况（按名称排序），
+// 超过PluginTimeout仍未返回的插件记为timeout
+func (t *pluginSourceTracker) snapshot(plugins []plugin.AsyncSearchPlugin, elapsed time.Duration) []model.SourceStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	sources := make([]model.SourceStatus, 0, len(plugins))
+	for _, p := range plugins {
+		source, ok := t.sources[p.Name()]
+		if !ok {
+			source = model.SourceStatus{
+				Name:      p.Name(),
+				Status:    model.SourceStatusTimeout,
+				LatencyMs: elapsed.Milliseconds(),
+				Breaker:   breakerState(p.Name()),
+			}
+		}
+		sources = append(sources, source)
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		return sources[i].Name < sources[j].Name
+	})
+	return sources
+}
+
+// breakerState 获取插件熔断器状态，未启用熔断时返回空字符串
+func breakerState(name string) string {
+	if status := plugin.GetCircuitBreakerStatus(name); status != nil {
+		return status.State
+	}
+	return ""
+}
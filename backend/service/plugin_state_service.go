@@ -0,0 +1,24 @@
+package service
+
+import (
+	"pansou/plugin"
+	"pansou/util/cache"
+)
+
+// refreshPluginState 插件启用状态或优先级变化后刷新依赖它们的缓存：
+// "所有插件"的缓存键哈希与排序使用的插件等级缓存
+func refreshPluginState(pluginManager *plugin.PluginManager) {
+	if pluginManager != nil {
+		plugins := pluginManager.GetPlugins()
+		names := make([]string, 0, len(plugins))
+		for _, p := range plugins {
+			names = append(names, p.Name())
+		}
+		cache.UpdateAllPluginsHash(names)
+	}
+
+	pluginLevelCache.Range(func(key, value interface{}) bool {
+		pluginLevelCache.Delete(key)
+		return true
+	})
+}
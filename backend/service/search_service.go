@@ -3,8 +3,6 @@ package service
 import (
 	"context"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 	"sort"
 	"strings"
 	"time"
@@ -12,8 +10,10 @@ import (
 	"pansou/config"
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/plugin/remote"
 	"pansou/util"
 	"pansou/util/cache"
+	"pansou/util/index"
 	"pansou/util/pool"
 	"sync"
 	"regexp"
@@ -212,6 +212,12 @@ func NewSearchService(pluginManager *plugin.PluginManager) *SearchService {
 		})
 	}
 
+	// 插件启用状态或优先级变化时失效相关缓存
+	refreshPluginState(pluginManager)
+	plugin.OnPluginStateChange(func() {
+		refreshPluginState(pluginManager)
+	})
+
 	return &SearchService{
 		pluginManager: pluginManager,
 	}
@@ -236,6 +242,9 @@ func injectMainCacheToAsyncPlugins(pluginManager *plugin.PluginManager, mainCach
 		if len(newResults) == 0 {
 			return nil
 		}
+
+		// 后台补全的结果同样写入本地索引
+		addToIndex(newResults)
 		
 		// 🔧 获取现有缓存数据进行合并
 		var finalResults []model.SearchResult
@@ -322,8 +331,8 @@ func injectMainCacheToAsyncPlugins(pluginManager *plugin.PluginManager, mainCach
 		}
 	}
 	
-	// 获取所有插件
-	plugins := pluginManager.GetPlugins()
+	// 获取所有插件（包含已禁用的插件，重新启用后无需再次注入）
+	plugins := pluginManager.GetAllPlugins()
 	
 	// 遍历所有插件，找出异步插件
 	for _, p := range plugins {
@@ -354,8 +363,8 @@ func (s *SearchService) Search(keyword string, channels []string, concurrency in
 	}
 
 	// 插件参数规范化处理
-	if sourceType == "tg" {
-		// 对于只搜索Telegram的请求，忽略插件参数
+	if sourceType == "tg" || sourceType == "index" {
+		// 对于只搜索Telegram或本地索引的请求，忽略插件参数
 		plugins = nil
 	} else if sourceType == "all" || sourceType == "plugin" {
 		// 检查是否为空列表或只包含空字符串
@@ -424,26 +433,47 @@ func (s *SearchService) Search(keyword string, channels []string, concurrency in
 	// 并行获取TG搜索和插件搜索结果
 	var tgResults []model.SearchResult
 	var pluginResults []model.SearchResult
+
+	// 仅检索本地索引时不访问任何上游
+	if sourceType == "index" {
+		indexResults, err := searchIndex(keyword)
+		if err != nil {
+			return model.SearchResponse{}, err
+		}
+		pluginResults = indexResults
+	}
 	
 	var wg sync.WaitGroup
 	var tgErr, pluginErr error
+	var sources []model.SourceStatus
+
+	// 按请求的网盘类型跳过不可能返回这些类型的插件
+	searchPluginSource := sourceType == "all" || sourceType == "plugin"
+	var skippedSources []model.SourceStatus
+	if searchPluginSource && len(cloudTypes) > 0 {
+		var routed bool
+		plugins, skippedSources, routed = s.routePluginsByCloudTypes(plugins, cloudTypes)
+		searchPluginSource = routed
+	}
 	
 	// 如果需要搜索TG
 	if sourceType == "all" || sourceType == "tg" {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
+			defer util.RecoverError("TG搜索", &tgErr)
 			tgResults, tgErr = s.searchTG(keyword, channels, forceRefresh)
 		}()
 	}
 	// 如果需要搜索插件
-	if sourceType == "all" || sourceType == "plugin" {
+	if searchPluginSource {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
+			defer util.RecoverError("插件搜索", &pluginErr)
 			// 对于插件搜索，我们总是希望获取最新的缓存数据
 			// 因此，即使forceRefresh=false，我们也需要确保获取到最新的缓存
-			pluginResults, pluginErr = s.searchPlugins(keyword, plugins, forceRefresh, concurrency, ext)
+			pluginResults, sources, pluginErr = s.searchPlugins(keyword, plugins, forceRefresh, concurrency, ext)
 		}()
 	}
 	
@@ -457,12 +487,19 @@ func (s *SearchService) Search(keyword string, channels []string, concurrency in
 	if pluginErr != nil {
 		return model.SearchResponse{}, pluginErr
 	}
+	sources = append(sources, skippedSources...)
 	
-	// 合并结果
-	allResults := mergeSearchResults(tgResults, pluginResults)
+	var allResults []model.SearchResult
+	if sourceType == "index" {
+		// 本地索引的结果已去重，保持BM25相关度顺序
+		allResults = pluginResults
+	} else {
+		// 合并结果
+		allResults = mergeSearchResults(tgResults, pluginResults)
 
-	// 按照优化后的规则排序结果
-	sortResultsByTimeAndKeywords(allResults)
+		// 按照优化后的规则排序结果
+		sortResultsByTimeAndKeywords(allResults)
+	}
 
 	// 过滤结果，只保留有时间的结果或包含优先关键词的结果或高等级插件结果到Results中
 	filteredForResults := make([]model.SearchResult, 0, len(allResults))
@@ -496,6 +533,7 @@ func (s *SearchService) Search(keyword string, channels []string, concurrency in
 		Total:        total,
 		Results:      filteredForResults, // 使用进一步过滤的结果
 		MergedByType: mergedLinks,
+		Sources:      sources,
 	}
 
 	// 根据resultType过滤返回结果
@@ -511,6 +549,7 @@ func filterResponseByType(response model.SearchResponse, resultType string) mode
 			Total:        response.Total,
 			MergedByType: response.MergedByType,
 			Results:      nil,
+			Sources:      response.Sources,
 		}
 	case "all":
 		return response
@@ -519,6 +558,7 @@ func filterResponseByType(response model.SearchResponse, resultType string) mode
 		return model.SearchResponse{
 			Total:   response.Total,
 			Results: response.Results,
+			Sources: response.Sources,
 		}
 	default:
 		// // 默认返回全部
@@ -527,6 +567,7 @@ func filterResponseByType(response model.SearchResponse, resultType string) mode
 			Total:        response.Total,
 			MergedByType: response.MergedByType,
 			Results:      nil,
+			Sources:      response.Sources,
 		}
 	}
 }
@@ -544,13 +585,15 @@ func sortResultsByTimeAndKeywords(results []model.SearchResult) {
 			TimeScore:    calculateTimeScore(result.Datetime),
 			KeywordScore: getKeywordPriority(result.Title),
 			PluginScore:  getPluginLevelScore(source),
+			ViewsScore:   calculateViewsScore(result.Views),
 			TotalScore:   0, // 稍后计算
 		}
 		
 		// 计算综合得分
 		scores[i].TotalScore = scores[i].TimeScore + 
 							  float64(scores[i].KeywordScore) + 
-							  float64(scores[i].PluginScore)
+							  float64(scores[i].PluginScore) +
+							  float64(scores[i].ViewsScore)
 	}
 	
 	// 2. 按综合得分排序
@@ -582,37 +625,18 @@ func getKeywordPriority(title string) int {
 
 // 搜索单个频道
 func (s *SearchService) searchChannel(keyword string, channel string) ([]model.SearchResult, error) {
-	// 构建搜索URL
-	url := util.BuildSearchURL(channel, keyword, "")
-
-	// 使用全局HTTP客户端（已配置代理）
-	client := util.GetHTTPClient()
-
-	// 创建一个带超时的上下文
-	ctx, cancel := context.WithTimeout(context.Background(), 4*time.Second)
+	// 创建一个带超时的上下文，控制所有镜像尝试的整体耗时
+	ctx, cancel := context.WithTimeout(context.Background(), config.AppConfig.PluginTimeout)
 	defer cancel()
 
-	// 创建请求
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	// 发送请求
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	// 读取响应体
-	body, err := ioutil.ReadAll(resp.Body)
+	// 通过预览镜像池获取页面，单个镜像失败时自动切换（单次请求超时4秒）
+	body, err := util.GetTGMirrorPool().FetchChannelPage(ctx, channel, keyword, "", 4*time.Second)
 	if err != nil {
 		return nil, err
 	}
 
 	// 解析响应
-	results, _, err := util.ParseSearchResults(string(body), channel)
+	results, _, err := util.ParseSearchResults(body, channel)
 	if err != nil {
 		return nil, err
 	}
@@ -928,52 +952,15 @@ func mergeResultsByType(results []model.SearchResult, keyword string, cloudTypes
 		// 提取消息中的链接-标题对应关系
 		linkTitleMap := extractLinkTitlePairs(result.Content)
 		
-		// 如果没有从内容中提取到标题，尝试直接从内容中匹配
-		if len(linkTitleMap) == 0 && len(result.Links) > 0 && !strings.Contains(result.Content, "\n") {
-			// 这是没有换行符的情况，尝试直接匹配
-			content := result.Content
-			
-			// 尝试使用"链接："分割内容
-			parts := strings.Split(content, "链接：")
-			if len(parts) > 1 && len(result.Links) <= len(parts)-1 {
-				// 第一部分是第一个标题
-				titles := make([]string, 0, len(parts))
-				titles = append(titles, cleanTitle(parts[0]))
-				
-				// 处理每个包含链接的部分，提取标题
-				for i := 1; i < len(parts)-1; i++ {
-					part := parts[i]
-					// 找到链接的结束位置
-					linkEnd := -1
-					for j, c := range part {
-						if c == ' ' || c == '窃' || c == '东' || c == '迎' || c == '千' || c == '我' || c == '恋' || c == '将' || c == '野' {
-							linkEnd = j
-							break
-						}
-					}
-					
-					if linkEnd > 0 {
-						// 提取标题
-						title := cleanTitle(part[linkEnd:])
-						titles = append(titles, title)
-					}
-				}
-				
-				// 将标题与链接关联
-				for i, link := range result.Links {
-					if i < len(titles) {
-						linkTitleMap[link.URL] = titles[i]
-					}
-				}
-			}
-		}
-		
 		for _, link := range result.Links {
 			// 尝试从映射中获取该链接对应的标题
 			title := result.Title // 默认使用消息标题
 			
-			// 查找完全匹配的链接
-			if specificTitle, found := linkTitleMap[link.URL]; found && specificTitle != "" {
+			// 解析阶段按频道规则配对的作品标题（ParseSearchResults、ParseMessageText的link_title）优先使用
+			if link.WorkTitle != "" {
+				title = link.WorkTitle
+			} else if specificTitle, found := linkTitleMap[link.URL]; found && specificTitle != "" {
+				// 查找完全匹配的链接
 				title = specificTitle // 如果找到特定标题，则使用它
 			} else {
 				// 如果没有找到完全匹配的链接，尝试查找前缀匹配的链接
@@ -1012,7 +999,7 @@ func mergeResultsByType(results []model.SearchResult, keyword string, cloudTypes
 				// 来自插件：UniqueID格式通常为 "插件名-ID"
 				parts := strings.SplitN(result.UniqueID, "-", 2)
 				if len(parts) >= 1 {
-					source = "plugin:" + parts[0]
+					source = pluginSource(parts[0])
 				}
 			} else {
 				// 无法确定来源，使用默认值
@@ -1027,6 +1014,8 @@ func mergeResultsByType(results []model.SearchResult, keyword string, cloudTypes
 				Datetime: result.Datetime,
 				Source:   source, // 添加数据来源字段
 				Images:   result.Images, // 添加TG消息中的图片链接
+				Views:    result.Views,
+				Size:     result.MaxFileSize(),
 			}
 
 			// 检查是否已存在相同URL的链接
@@ -1148,8 +1137,8 @@ func (s *SearchService) searchTG(keyword string, channels []string, forceRefresh
 			if err == nil && hit {
 				var results []model.SearchResult
 				if err := enhancedTwoLevelCache.GetSerializer().Deserialize(data, &results); err == nil {
-					// 直接返回缓存数据，不检查新鲜度
-					return results, nil
+					// 直接返回缓存数据，不检查新鲜度（合并Bot实时收录的消息）
+					return mergeIngestedResults(keyword, channels, results), nil
 				}
 			}
 		}
@@ -1183,9 +1172,16 @@ func (s *SearchService) searchTG(keyword string, channels []string, forceRefresh
 		}
 	}
 	
-	// 异步缓存结果
+	// 写入本地索引
+	addToIndex(results)
+
+	// 合并Bot实时收录的消息
+	results = mergeIngestedResults(keyword, channels, results)
+
+	// 异步缓存结果（含已收录的消息），并登记缓存以接收之后Bot推送的消息
 	if cacheInitialized && config.AppConfig.CacheEnabled {
 		go func(res []model.SearchResult) {
+			defer util.RecoverBackground("TG搜索缓存写入")
 			ttl := time.Duration(config.AppConfig.CacheTTLMinutes) * time.Minute
 			
 			// 使用增强版缓存
@@ -1195,6 +1191,7 @@ func (s *SearchService) searchTG(keyword string, channels []string, forceRefresh
 					return
 				}
 				enhancedTwoLevelCache.Set(cacheKey, data, ttl)
+				registerTGCacheEntry(cacheKey, keyword, channels, ttl)
 			}
 		}(results)
 	}
@@ -1202,15 +1199,53 @@ func (s *SearchService) searchTG(keyword string, channels []string, forceRefresh
 	return results, nil
 }
 
-// searchPlugins 搜索插件
-func (s *SearchService) searchPlugins(keyword string, plugins []string, forceRefresh bool, concurrency int, ext map[string]interface{}) ([]model.SearchResult, error) {
+// routePluginsByCloudTypes 根据插件声明的能力跳过不可能返回请求网盘类型的插件，
+// 返回实际要搜索的插件列表（未跳过任何插件时原样返回，以保持缓存键不变）、
+// 被跳过插件的执行情况，以及是否还有需要搜索的插件
+func (s *SearchService) routePluginsByCloudTypes(plugins []string, cloudTypes []string) ([]string, []model.SourceStatus, bool) {
+	if s.pluginManager == nil {
+		return plugins, nil, true
+	}
+
+	requested := make(map[string]bool, len(plugins))
+	for _, name := range plugins {
+		if name != "" {
+			requested[strings.ToLower(name)] = true
+		}
+	}
+
+	var kept []string
+	var skipped []model.SourceStatus
+	for _, p := range s.pluginManager.GetPlugins() {
+		if len(requested) > 0 && !requested[strings.ToLower(p.Name())] {
+			continue
+		}
+		if plugin.CanProduceCloudTypes(p, cloudTypes) {
+			kept = append(kept, p.Name())
+			continue
+		}
+		skipped = append(skipped, model.SourceStatus{
+			Name:   p.Name(),
+			Status: model.SourceStatusSkipped,
+			Error:  "不支持请求的网盘类型",
+		})
+	}
+
+	if len(skipped) == 0 {
+		return plugins, nil, true
+	}
+	return kept, skipped, len(kept) > 0
+}
+
+// searchPlugins 搜索插件，同时返回各插件的执行情况（命中缓存时为nil）
+func (s *SearchService) searchPlugins(keyword string, plugins []string, forceRefresh bool, concurrency int, ext map[string]interface{}) ([]model.SearchResult, []model.SourceStatus, error) {
 	// 确保ext不为nil
 	if ext == nil {
 		ext = make(map[string]interface{})
 	}
 	
-	// 生成缓存键
-	cacheKey := cache.GeneratePluginCacheKey(keyword, plugins)
+	// 生成缓存键（非默认搜索深度单独缓存）
+	cacheKey := cache.GeneratePluginCacheKeyWithOptions(keyword, plugins, plugin.GetSearchOptions(ext).CacheKey())
 	
 	
 	// 如果未启用强制刷新，尝试从缓存获取结果
@@ -1231,7 +1266,7 @@ func (s *SearchService) searchPlugins(keyword string, plugins []string, forceRef
 				if err := enhancedTwoLevelCache.GetSerializer().Deserialize(data, &results); err == nil {
 					// 返回缓存数据
 					fmt.Printf("✅ [%s] 命中缓存 结果数: %d\n", keyword,  len(results))
-					return results, nil
+					return results, nil, nil
 				} else {
 					displayKey := cacheKey[:8] + "..."
 					fmt.Printf("❌ [主服务] 缓存反序列化失败: %s(关键词:%s) | 错误: %v\n", displayKey, keyword, err)
@@ -1286,21 +1321,28 @@ func (s *SearchService) searchPlugins(keyword string, plugins []string, forceRef
 		concurrency = config.AppConfig.DefaultConcurrency
 	}
 	
+	// 插件可以读取调用方的截止时间，超过PluginTimeout的结果不再等待
+	ext = plugin.WithSearchDeadline(ext, time.Now().Add(config.AppConfig.PluginTimeout))
+
 	// 使用工作池执行并行搜索
 	tasks := make([]pool.Task, 0, len(availablePlugins))
+	tracker := newPluginSourceTracker()
 	for _, p := range availablePlugins {
 		plugin := p // 创建副本，避免闭包问题
+		// 熔断中的插件直接跳过，不提交任务，不占用工作池
+		if tracker.skipOpenCircuit(plugin.Name()) {
+			continue
+		}
 		tasks = append(tasks, func() interface{} {
 			// 设置主缓存键和当前关键词
 			plugin.SetMainCacheKey(cacheKey)
 			plugin.SetCurrentKeyword(keyword)
 			
 			// 调用异步插件的AsyncSearch方法
-			results, err := plugin.AsyncSearch(keyword, func(client *http.Client, kw string, extParams map[string]interface{}) ([]model.SearchResult, error) {
-				// 使用插件的Search方法作为搜索函数
-				return plugin.Search(kw, extParams)
-			}, cacheKey, ext)
-			
+			start := time.Now()
+			results, err := callPlugin(plugin, keyword, ext)
+			tracker.record(plugin.Name(), time.Since(start), results, err)
+
 			if err != nil {
 				return nil
 			}
@@ -1309,7 +1351,9 @@ func (s *SearchService) searchPlugins(keyword string, plugins []string, forceRef
 	}
 	
 	// 执行搜索任务并获取结果
+	batchStart := time.Now()
 	results := pool.ExecuteBatchWithTimeout(tasks, concurrency, config.AppConfig.PluginTimeout)
+	sources := tracker.snapshot(availablePlugins, time.Since(batchStart))
 	
 	// 合并所有插件的结果，过滤掉无链接的结果
 	var allResults []model.SearchResult
@@ -1325,9 +1369,13 @@ func (s *SearchService) searchPlugins(keyword string, plugins []string, forceRef
 		}
 	}
 	
+	// 写入本地索引
+	addToIndex(allResults)
+
 	// 🔧 恢复主程序缓存更新：确保最终合并结果被正确缓存
 	if cacheInitialized && config.AppConfig.CacheEnabled {
 		go func(res []model.SearchResult, kw string, key string) {
+			defer util.RecoverBackground("插件搜索缓存写入")
 			ttl := time.Duration(config.AppConfig.CacheTTLMinutes) * time.Minute
 			
 			// 使用增强版缓存，确保与异步插件使用相同的序列化器
@@ -1349,10 +1397,52 @@ func (s *SearchService) searchPlugins(keyword string, plugins []string, forceRef
 		}(allResults, keyword, cacheKey)
 	}
 	
-	return allResults, nil
+	return allResults, sources, nil
+}
+
+
+// callPlugin 调用插件的Search方法，插件panic时转换为错误。
+// 插件的Search内部通过AsyncSearch处理插件缓存、响应超时与调用统计，这里不再套一层AsyncSearch，
+// 否则外层会把内层的缓存命中当作一次成功调用
+func callPlugin(p plugin.AsyncSearchPlugin, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
+	return plugin.SafeCall(p.Name(), func() ([]model.SearchResult, error) {
+		return p.Search(keyword, ext)
+	})
+}
+
+// indexSearchLimit 本地索引单次检索返回的最大结果数
+const indexSearchLimit = 1000
+
+// searchIndex 检索本地全文索引
+func searchIndex(keyword string) ([]model.SearchResult, error) {
+	idx := index.Default()
+	if idx == nil {
+		return nil, fmt.Errorf("本地索引未启用，请设置INDEX_ENABLED=true")
+	}
+
+	hits := idx.Search(keyword, indexSearchLimit)
+	results := make([]model.SearchResult, 0, len(hits))
+	for _, hit := range hits {
+		results = append(results, hit.Result)
+	}
+	return results, nil
 }
 
+// addToIndex 异步将结果写入本地全文索引，索引未启用时直接返回
+func addToIndex(results []model.SearchResult) {
+	idx := index.Default()
+	if idx == nil || len(results) == 0 {
+		return
+	}
 
+	// 复制切片，避免调用方后续排序与索引写入并发访问
+	snapshot := make([]model.SearchResult, len(results))
+	copy(snapshot, results)
+	go func() {
+		defer util.RecoverBackground("索引写入")
+		idx.Add(snapshot)
+	}()
+}
 
 // GetPluginManager 获取插件管理器
 func (s *SearchService) GetPluginManager() *plugin.PluginManager {
@@ -1369,6 +1459,7 @@ type ResultScore struct {
 	TimeScore    float64  // 时间得分
 	KeywordScore int      // 关键词得分  
 	PluginScore  int      // 插件等级得分
+	ViewsScore   int      // 浏览次数得分
 	TotalScore   float64  // 综合得分
 }
 
@@ -1386,12 +1477,20 @@ func getResultSource(result model.SearchResult) string {
 		// 来自插件：UniqueID格式通常为 "插件名-ID"
 		parts := strings.SplitN(result.UniqueID, "-", 2)
 		if len(parts) >= 1 {
-			return "plugin:" + parts[0]
+			return pluginSource(parts[0])
 		}
 	}
 	return "unknown"
 }
 
+// pluginSource 插件结果的数据来源，远程实例的插件名本身就是 remote:实例名
+func pluginSource(pluginName string) string {
+	if remote.IsRemote(pluginName) {
+		return pluginName
+	}
+	return "plugin:" + pluginName
+}
+
 // getPluginLevelBySource 根据来源获取插件等级
 func getPluginLevelBySource(source string) int {
 	// 尝试从缓存获取
@@ -1410,6 +1509,12 @@ func getPluginLevelBySource(source string) int {
 		return 3 // TG搜索等同于等级3
 	}
 	
+	if parts[0] == "remote" {
+		level := getPluginPriorityByName(source)
+		pluginLevelCache.Store(source, level)
+		return level
+	}
+	
 	if parts[0] == "plugin" {
 		level := getPluginPriorityByName(parts[1])
 		pluginLevelCache.Store(source, level)
@@ -1450,6 +1555,23 @@ func getPluginLevelScore(source string) int {
 	}
 }
 
+// calculateViewsScore 计算浏览次数得分（仅TG结果有浏览数据），最大90分，
+// 小于时间得分的档差，只在同一时间段的结果之间起作用
+func calculateViewsScore(views int) int {
+	switch {
+	case views >= 100000:
+		return 90
+	case views >= 10000:
+		return 60
+	case views >= 1000:
+		return 30
+	case views >= 100:
+		return 10
+	default:
+		return 0
+	}
+}
+
 // calculateTimeScore 计算时间得分
 func calculateTimeScore(datetime time.Time) float64 {
 	if datetime.IsZero() {
@@ -0,0 +1,363 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+
+	"pansou/config"
+	"pansou/model"
+	"pansou/util"
+	"pansou/util/index"
+)
+
+// ingestedMergeLimit 每次TG搜索最多合并的实时收录结果数
+const ingestedMergeLimit = 200
+
+// maxTGCacheEntries 记录的TG搜索缓存条目上限，超过后新的搜索不再接收实时推送（仍在读取时合并）
+const maxTGCacheEntries = 10000
+
+// tgCacheEntry 已写入结果缓存的TG搜索，Bot推送的消息命中关键词时会写入对应缓存
+type tgCacheEntry struct {
+	keyword   string
+	channels  map[string]bool
+	expiresAt time.Time
+}
+
+var (
+	tgCacheEntriesMu sync.Mutex
+	tgCacheEntries   = make(map[string]*tgCacheEntry)
+
+	// indexDisabledWarning 本地索引未启用时只提示一次
+	indexDisabledWarning sync.Once
+)
+
+// TelegramIngestResult 单条更新的收录结果
+type TelegramIngestResult struct {
+	Channel   string `json:"channel,omitempty"`
+	MessageID string `json:"message_id,omitempty"`
+	Indexed   bool   `json:"indexed"`
+	Links     int    `json:"links"`
+	Reason    string `json:"reason,omitempty"` // 未收录的原因
+}
+
+// IngestTelegramUpdate 处理Bot推送的channel_post/edited_channel_post更新，
+// 将消息解析为SearchResult写入本地索引与已缓存的TG搜索结果。
+// 本地索引未启用时丢弃更新（仍视为处理成功，避免Telegram反复重试）
+func IngestTelegramUpdate(update *model.TelegramUpdate) *TelegramIngestResult {
+	idx := index.Default()
+	if idx == nil {
+		indexDisabledWarning.Do(func() {
+			fmt.Printf("⚠️ [TG收录] 本地索引未启用，Bot推送的频道消息将被丢弃，请设置INDEX_ENABLED=true\n")
+		})
+		return &TelegramIngestResult{Reason: "本地索引未启用，已丢弃"}
+	}
+
+	msg, edited := update.ChannelPost, false
+	if msg == nil {
+		msg, edited = update.EditedChannelPost, true
+	}
+	if msg == nil {
+		return &TelegramIngestResult{Reason: "不是频道消息"}
+	}
+
+	channel := telegramChannelName(msg.Chat)
+	result := &TelegramIngestResult{
+		Channel:   channel,
+		MessageID: strconv.FormatInt(msg.MessageID, 10),
+	}
+
+	if !isIngestChannelAllowed(channel) {
+		result.Reason = "频道不在收录列表中"
+		return result
+	}
+
+	searchResult, ignored := telegramMessageToResult(msg, channel, edited)
+	if ignored || len(searchResult.Links) == 0 {
+		// 编辑后命中忽略规则或不再包含链接的消息需要从索引与缓存中移除
+		idx.Remove(searchResult.UniqueID)
+		if searchResult.Edited {
+			updateTGCacheEntries(searchResult, false)
+		}
+		result.Reason = "消息中没有网盘链接"
+		if ignored {
+			result.Reason = "命中频道忽略规则"
+		}
+		return result
+	}
+
+	idx.Add([]model.SearchResult{searchResult})
+	updateTGCacheEntries(searchResult, true)
+	result.Indexed = true
+	result.Links = len(searchResult.Links)
+	return result
+}
+
+// telegramMessageToResult 将Bot API消息转换为SearchResult，UniqueID与网页抓取结果保持一致以便去重
+func telegramMessageToResult(msg *model.TelegramMessage, channel string, edited bool) (model.SearchResult, bool) {
+	messageID := strconv.FormatInt(msg.MessageID, 10)
+	searchResult := model.SearchResult{
+		MessageID: messageID,
+		UniqueID:  channel + "_" + messageID,
+		Channel:   channel,
+		Datetime:  time.Unix(msg.Date, 0),
+		Edited:    edited || msg.EditDate > 0,
+	}
+
+	// 图片/文件消息的文字在caption中
+	text, entities := msg.Text, msg.Entities
+	if text == "" {
+		text, entities = msg.Caption, msg.CaptionEntities
+	}
+
+	var textLinks []util.TextLink
+	units := utf16.Encode([]rune(text))
+	for _, entity := range entities {
+		switch entity.Type {
+		case "text_link":
+			textLinks = append(textLinks, util.TextLink{URL: entity.URL, Line: utf16LineAt(units, entity.Offset)})
+		case "url":
+			// url实体可能省略协议头，补全后交给链接提取
+			linkURL := utf16Slice(units, entity.Offset, entity.Length)
+			if !strings.Contains(linkURL, "://") {
+				linkURL = "https://" + linkURL
+			}
+			textLinks = append(textLinks, util.TextLink{URL: linkURL, Line: utf16LineAt(units, entity.Offset)})
+		case "hashtag":
+			searchResult.Tags = append(searchResult.Tags, strings.TrimPrefix(utf16Slice(units, entity.Offset, entity.Length), "#"))
+		}
+	}
+
+	title, links, ignored := util.ParseMessageText(channel, text, util.RenderTelegramEntities(text, entities), textLinks)
+	if ignored {
+		return searchResult, true
+	}
+	searchResult.Title = title
+	searchResult.Content = text
+	searchResult.Links = links
+
+	if msg.Document != nil && msg.Document.FileName != "" {
+		searchResult.Files = []model.FileInfo{{Name: msg.Document.FileName, Size: msg.Document.FileSize}}
+	}
+
+	if origin := msg.ForwardOrigin; origin != nil {
+		if origin.Chat != nil {
+			searchResult.ForwardedFrom = origin.Chat.Title
+		} else {
+			searchResult.ForwardedFrom = origin.SenderUserName
+		}
+	} else if msg.ForwardFromChat != nil {
+		searchResult.ForwardedFrom = msg.ForwardFromChat.Title
+	}
+
+	if reply := msg.ReplyToMessage; reply != nil {
+		replyText := reply.Text
+		if replyText == "" {
+			replyText = reply.Caption
+		}
+		searchResult.ReplyTo = &model.ReplyInfo{
+			MessageID: strconv.FormatInt(reply.MessageID, 10),
+			Text:      replyText,
+		}
+	}
+
+	return searchResult, false
+}
+
+// telegramChannelName 获取频道名，公开频道使用用户名，私有频道使用数字ID
+func telegramChannelName(chat model.TelegramChat) string {
+	if chat.Username != "" {
+		return chat.Username
+	}
+	return strconv.FormatInt(chat.ID, 10)
+}
+
+// isIngestChannelAllowed 检查频道是否允许收录
+func isIngestChannelAllowed(channel string) bool {
+	allowed := config.AppConfig.TGBotChannels
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, ch := range allowed {
+		if strings.EqualFold(ch, channel) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeIngestedResults 将本地索引中属于所搜索频道的结果（含Bot实时收录的消息）合并到TG搜索结果中，
+// 覆盖重启前写入、未登记接收推送的TG缓存
+func mergeIngestedResults(keyword string, channels []string, results []model.SearchResult) []model.SearchResult {
+	idx := index.Default()
+	if idx == nil {
+		return results
+	}
+
+	channelSet := make(map[string]bool, len(channels))
+	for _, ch := range channels {
+		if isIngestChannelAllowed(ch) {
+			channelSet[strings.ToLower(ch)] = true
+		}
+	}
+	if len(channelSet) == 0 {
+		return results
+	}
+
+	hits := idx.SearchFunc(keyword, ingestedMergeLimit, func(r model.SearchResult) bool {
+		return r.Channel != "" && channelSet[strings.ToLower(r.Channel)]
+	})
+	if len(hits) == 0 {
+		return results
+	}
+
+	ingested := make([]model.SearchResult, 0, len(hits))
+	for _, hit := range hits {
+		ingested = append(ingested, hit.Result)
+	}
+	return mergeSearchResults(results, ingested)
+}
+
+// registerTGCacheEntry 登记已写入缓存的TG搜索，之后Bot推送的消息会写入该缓存
+func registerTGCacheEntry(cacheKey string, keyword string, channels []string, ttl time.Duration) {
+	channelSet := make(map[string]bool, len(channels))
+	for _, ch := range channels {
+		channelSet[strings.ToLower(ch)] = true
+	}
+
+	tgCacheEntriesMu.Lock()
+	defer tgCacheEntriesMu.Unlock()
+
+	if _, exists := tgCacheEntries[cacheKey]; !exists && len(tgCacheEntries) >= maxTGCacheEntries {
+		now := time.Now()
+		for key, entry := range tgCacheEntries {
+			if now.After(entry.expiresAt) {
+				delete(tgCacheEntries, key)
+			}
+		}
+		if len(tgCacheEntries) >= maxTGCacheEntries {
+			return
+		}
+	}
+	tgCacheEntries[cacheKey] = &tgCacheEntry{
+		keyword:   keyword,
+		channels:  channelSet,
+		expiresAt: time.Now().Add(ttl),
+	}
+}
+
+// updateTGCacheEntries 将Bot推送的消息写入包含该频道且命中关键词的TG搜索缓存，
+// keep为false或编辑后不再命中关键词时从缓存中移除该消息；缓存保持原有过期时间。
+// 只在收集候选缓存项时持有锁，缓存读写在锁外进行，避免阻塞搜索路径上的registerTGCacheEntry
+func updateTGCacheEntries(result model.SearchResult, keep bool) {
+	if !cacheInitialized || enhancedTwoLevelCache == nil || !config.AppConfig.CacheEnabled {
+		return
+	}
+	channel := strings.ToLower(result.Channel)
+	now := time.Now()
+
+	// 缓存项登记后不再修改，锁内只复制指针
+	candidates := make(map[string]*tgCacheEntry)
+	tgCacheEntriesMu.Lock()
+	for key, entry := range tgCacheEntries {
+		if now.After(entry.expiresAt) {
+			delete(tgCacheEntries, key)
+			continue
+		}
+		if entry.channels[channel] {
+			candidates[key] = entry
+		}
+	}
+	tgCacheEntriesMu.Unlock()
+
+	serializer := enhancedTwoLevelCache.GetSerializer()
+	var missed []string
+	for key, entry := range candidates {
+		matched := keep && index.Matches(entry.keyword, result)
+		if !matched && !result.Edited {
+			// 新消息未命中关键词时不会出现在缓存中
+			continue
+		}
+
+		data, hit, err := enhancedTwoLevelCache.Get(key)
+		if err != nil || !hit {
+			missed = append(missed, key)
+			continue
+		}
+		var cached []model.SearchResult
+		if err := serializer.Deserialize(data, &cached); err != nil {
+			continue
+		}
+		updated, changed := upsertResult(cached, result, matched)
+		if !changed {
+			continue
+		}
+		if data, err := serializer.Serialize(updated); err == nil {
+			enhancedTwoLevelCache.Set(key, data, entry.expiresAt.Sub(now))
+		}
+	}
+
+	if len(missed) == 0 {
+		return
+	}
+	// 缓存已失效的登记项，期间被重新登记的保留
+	tgCacheEntriesMu.Lock()
+	for _, key := range missed {
+		if tgCacheEntries[key] == candidates[key] {
+			delete(tgCacheEntries, key)
+		}
+	}
+	tgCacheEntriesMu.Unlock()
+}
+
+// upsertResult 按结果唯一键替换或移除结果，keep为true且不存在时按时间倒序插入最前
+func upsertResult(results []model.SearchResult, result model.SearchResult, keep bool) ([]model.SearchResult, bool) {
+	key := generateResultKey(result)
+	for i := range results {
+		if generateResultKey(results[i]) != key {
+			continue
+		}
+		if keep {
+			results[i] = result
+		} else {
+			results = append(results[:i], results[i+1:]...)
+		}
+		return results, true
+	}
+	if !keep {
+		return results, false
+	}
+	return append([]model.SearchResult{result}, results...), true
+}
+
+// utf16Slice 按UTF-16码元截取文本（Bot API实体偏移以UTF-16计）
+func utf16Slice(units []uint16, offset, length int) string {
+	if offset < 0 || length <= 0 || offset >= len(units) {
+		return ""
+	}
+	end := offset + length
+	if end > len(units) {
+		end = len(units)
+	}
+	return string(utf16.Decode(units[offset:end]))
+}
+
+// utf16LineAt 计算UTF-16偏移所在的行号（从0开始）
+func utf16LineAt(units []uint16, offset int) int {
+	if offset < 0 {
+		return 0
+	}
+	if offset > len(units) {
+		offset = len(units)
+	}
+	line := 0
+	for _, u := range units[:offset] {
+		if u == '\n' {
+			line++
+		}
+	}
+	return line
+}
@@ -0,0 +1,182 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"pansou/config"
+)
+
+const (
+	// maxBlockedInspectBytes 检测验证页时最多读取的响应内容长度
+	maxBlockedInspectBytes = 64 * 1024
+	// tinyPageBytes 小于该长度且只包含脚本或跳转的HTML页面视为可疑页面
+	tinyPageBytes = 512
+)
+
+// BlockedError 上游返回了反爬验证页、拦截页或限流响应
+type BlockedError struct {
+	Host       string // 请求的主机
+	StatusCode int    // 响应状态码
+	Reason     string // 判断依据
+}
+
+// Error 实现error接口
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("%s 被反爬拦截（状态码 %d）: %s", e.Host, e.StatusCode, e.Reason)
+}
+
+// IsBlockedError 判断错误是否为反爬拦截
+func IsBlockedError(err error) bool {
+	var blockedErr *BlockedError
+	return errors.As(err, &blockedErr)
+}
+
+// challengeMarkers 验证页特有的内容，任意状态码下出现都视为被拦截
+var challengeMarkers = []struct {
+	marker string
+	reason string
+}{
+	{"cf_chl_opt", "Cloudflare验证页"},
+	{"<title>just a moment...</title>", "Cloudflare验证页"},
+	{"cf-browser-verification", "Cloudflare验证页"},
+	{"attention required! | cloudflare", "Cloudflare拦截页"},
+	{"checking your browser before accessing", "浏览器检查页"},
+	{"<title>ddos-guard</title>", "DDoS-Guard验证页"},
+	{"_incapsula_resource", "Incapsula拦截页"},
+}
+
+// captchaMarkers 验证码等内容，正常页面中也可能出现，只在错误状态码或异常短小的页面中视为被拦截
+var captchaMarkers = []struct {
+	marker string
+	reason string
+}{
+	{"g-recaptcha", "reCAPTCHA验证码"},
+	{"recaptcha/api.js", "reCAPTCHA验证码"},
+	{"h-captcha", "hCaptcha验证码"},
+	{"hcaptcha.com", "hCaptcha验证码"},
+	{"challenge-platform", "Cloudflare验证页"},
+	{"人机验证", "人机验证页"},
+	{"安全验证", "安全验证页"},
+	{"验证码", "验证码页"},
+	{"access denied", "访问被拒绝"},
+}
+
+// InspectResponse 检查响应是否为反爬验证页、拦截页或限流响应，body为已读取的响应内容（可以只是开头部分）。
+// 未被拦截时返回nil
+func InspectResponse(resp *http.Response, body []byte) *BlockedError {
+	blocked := func(reason string) *BlockedError {
+		host := ""
+		if resp.Request != nil && resp.Request.URL != nil {
+			host = resp.Request.URL.Hostname()
+		}
+		return &BlockedError{Host: host, StatusCode: resp.StatusCode, Reason: reason}
+	}
+
+	if strings.EqualFold(resp.Header.Get("cf-mitigated"), "challenge") {
+		return blocked("Cloudflare验证页")
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return blocked("请求过于频繁")
+	}
+	if len(body) > maxBlockedInspectBytes {
+		body = body[:maxBlockedInspectBytes]
+	}
+	content := strings.ToLower(string(body))
+
+	for _, m := range challengeMarkers {
+		if strings.Contains(content, m.marker) {
+			return blocked(m.reason)
+		}
+	}
+
+	trimmed := strings.TrimSpace(content)
+	tiny := isHTMLResponse(resp) && len(trimmed) < tinyPageBytes
+	hasLinks := strings.Contains(trimmed, "<a ")
+	if resp.StatusCode >= 400 || (tiny && !hasLinks) {
+		for _, m := range captchaMarkers {
+			if strings.Contains(content, m.marker) {
+				return blocked(m.reason)
+			}
+		}
+	}
+
+	// 只有脚本或自动跳转、没有任何链接的极小页面，通常是JS验证或跳转页
+	if tiny && resp.StatusCode == http.StatusOK && trimmed != "" && !hasLinks &&
+		(strings.Contains(trimmed, "<script") || strings.Contains(trimmed, "http-equiv=\"refresh\"")) {
+		return blocked("页面内容异常短小（疑似JS验证或跳转页）")
+	}
+	return nil
+}
+
+// CheckBlocked 读取响应开头部分检查是否被拦截，读取后还原响应体，调用方仍可正常读取完整响应。
+// 只在可能是验证页的响应（限流、403、503、带Cloudflare拦截标记或极小的HTML页面）上读取内容
+func CheckBlocked(resp *http.Response) error {
+	if !mayBeBlocked(resp) {
+		return nil
+	}
+
+	prefix, err := io.ReadAll(io.LimitReader(resp.Body, maxBlockedInspectBytes))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(prefix), resp.Body), resp.Body}
+	if err != nil {
+		return nil
+	}
+	if blockedErr := InspectResponse(resp, prefix); blockedErr != nil {
+		return blockedErr
+	}
+	return nil
+}
+
+// mayBeBlocked 根据状态码与响应头判断是否需要检查响应内容
+func mayBeBlocked(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusForbidden, http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	}
+	if resp.Header.Get("cf-mitigated") != "" {
+		return true
+	}
+	return resp.StatusCode == http.StatusOK && isHTMLResponse(resp) &&
+		resp.ContentLength >= 0 && resp.ContentLength < tinyPageBytes
+}
+
+// isHTMLResponse 响应是否为HTML页面
+func isHTMLResponse(resp *http.Response) bool {
+	return strings.Contains(strings.ToLower(resp.Header.Get("Content-Type")), "text/html")
+}
+
+// blockDetectionEnabled 是否在出站传输层检测验证页（默认开启，BLOCK_DETECTION_ENABLED=false时关闭）
+func blockDetectionEnabled() bool {
+	return config.AppConfig == nil || config.AppConfig.BlockDetectionEnabled
+}
+
+// rateLimitPassthroughKey 请求上下文标记：调用方自行按Retry-After重试429，出站传输层不把429转换为BlockedError
+type rateLimitPassthroughKey struct{}
+
+// withRateLimitPassthrough 标记请求自行处理429响应
+func withRateLimitPassthrough(ctx context.Context) context.Context {
+	return context.WithValue(ctx, rateLimitPassthroughKey{}, true)
+}
+
+// inspectOutboundResponse 启用验证页检测时，把被拦截的响应转换为BlockedError
+func inspectOutboundResponse(req *http.Request, resp *http.Response, err error) (*http.Response, error) {
+	if err != nil || !blockDetectionEnabled() {
+		return resp, err
+	}
+	if resp.StatusCode == http.StatusTooManyRequests && req.Context().Value(rateLimitPassthroughKey{}) != nil {
+		return resp, nil
+	}
+	if blockedErr := CheckBlocked(resp); blockedErr != nil {
+		resp.Body.Close()
+		return nil, blockedErr
+	}
+	return resp, nil
+}
@@ -0,0 +1,113 @@
+package util_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"pansou/util"
+)
+
+func TestInspectResponse(t *testing.T) {
+	bigPage := "<html><body>" + strings.Repeat(`<a href="/s/1">凡人修仙传</a>`, 40) + "登录后输入验证码下载</body></html>"
+
+	tests := []struct {
+		name        string
+		status      int
+		header      http.Header
+		body        string
+		wantBlocked bool
+	}{
+		{"cf-mitigated响应头", http.StatusForbidden, http.Header{"Cf-Mitigated": []string{"challenge"}}, "", true},
+		{"Cloudflare验证页", http.StatusServiceUnavailable, http.Header{"Content-Type": []string{"text/html"}}, "<html><head><title>Just a moment...</title></head><script>window._cf_chl_opt={}</script></html>", true},
+		{"200状态码的验证页", http.StatusOK, http.Header{"Content-Type": []string{"text/html"}}, "<html><title>DDoS-Guard</title></html>", true},
+		{"403验证码页", http.StatusForbidden, http.Header{"Content-Type": []string{"text/html"}}, `<div class="g-recaptcha"></div>`, true},
+		{"429限流", http.StatusTooManyRequests, nil, "", true},
+		{"极小的JS跳转页", http.StatusOK, http.Header{"Content-Type": []string{"text/html"}}, "<html><script>location.href='/?v=1'</script></html>", true},
+		{"普通403", http.StatusForbidden, http.Header{"Content-Type": []string{"application/json"}}, `{"code":403,"msg":"forbidden"}`, false},
+		{"普通503", http.StatusServiceUnavailable, http.Header{"Content-Type": []string{"text/html"}}, "<html><body>服务维护中</body></html>", false},
+		{"正常页面包含验证码字样", http.StatusOK, http.Header{"Content-Type": []string{"text/html"}}, bigPage, false},
+		{"正常JSON", http.StatusOK, http.Header{"Content-Type": []string{"application/json"}}, `{"data":[]}`, false},
+	}
+	for _, tt := range tests {
+		header := tt.header
+		if header == nil {
+			header = http.Header{}
+		}
+		resp := &http.Response{
+			StatusCode: tt.status,
+			Header:     header,
+			Request:    &http.Request{URL: &url.URL{Scheme: "https", Host: "example.test"}},
+		}
+		blockedErr := util.InspectResponse(resp, []byte(tt.body))
+		if got := blockedErr != nil; got != tt.wantBlocked {
+			t.Errorf("%s: 拦截判断为 %v，期望 %v（%v）", tt.name, got, tt.wantBlocked, blockedErr)
+			continue
+		}
+		if blockedErr != nil && (blockedErr.Host != "example.test" || blockedErr.StatusCode != tt.status) {
+			t.Errorf("%s: 错误信息不正确: %+v", tt.name, blockedErr)
+		}
+	}
+}
+
+func TestOutboundTransportDetectsBlockedByDefault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("cf-mitigated", "challenge")
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("<title>Just a moment...</title>"))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: util.NewOutboundTransport(nil)}
+	resp, err := client.Get(server.URL)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("验证页应返回错误")
+	}
+	if !util.IsBlockedError(err) {
+		t.Errorf("应返回BlockedError: %v", err)
+	}
+}
+
+func TestDoWithRetryRetriesRateLimitThroughOutboundTransport(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: util.NewOutboundTransport(nil)}
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := util.DoWithRetry(client, req, util.RetryPolicy{
+		MaxAttempts:   2,
+		Backoff:       time.Millisecond,
+		RetryStatus:   []int{http.StatusTooManyRequests},
+		MaxRetryAfter: time.Second,
+	})
+	if err != nil {
+		t.Fatalf("429应按Retry-After重试，而不是在传输层转换为拦截错误: %v", err)
+	}
+	resp.Body.Close()
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("应请求2次，实际 %d 次", n)
+	}
+
+	// 直接发送的请求遇到429仍返回拦截错误
+	atomic.StoreInt32(&calls, 0)
+	if resp, err := client.Get(server.URL); err == nil {
+		resp.Body.Close()
+		t.Error("未经重试的429应返回拦截错误")
+	} else if !util.IsBlockedError(err) {
+		t.Errorf("应返回BlockedError: %v", err)
+	}
+}
@@ -62,6 +62,11 @@ func GenerateTGCacheKey(keyword string, channels []string) string {
 
 // GeneratePluginCacheKey 为插件搜索生成缓存键
 func GeneratePluginCacheKey(keyword string, plugins []string) string {
+	return GeneratePluginCacheKeyWithOptions(keyword, plugins, "")
+}
+
+// GeneratePluginCacheKeyWithOptions 为插件搜索生成缓存键，options为搜索选项标识（如 depth=deep），为空时与GeneratePluginCacheKey相同
+func GeneratePluginCacheKeyWithOptions(keyword string, plugins []string, options string) string {
 	// 关键词标准化
 	normalizedKeyword := strings.ToLower(strings.TrimSpace(keyword))
 	
@@ -70,6 +75,9 @@ func GeneratePluginCacheKey(keyword string, plugins []string) string {
 	
 	// 生成插件搜索特定的缓存键
 	keyStr := fmt.Sprintf("plugin:%s:%s", normalizedKeyword, pluginsHash)
+	if options != "" {
+		keyStr += ":" + options
+	}
 	hash := md5.Sum([]byte(keyStr))
 	return hex.EncodeToString(hash[:])
 }
@@ -103,6 +111,15 @@ func GenerateCacheKey(keyword string, channels []string, sourceType string, plug
 	return hex.EncodeToString(hash[:])
 }
 
+// UpdateAllPluginsHash 根据当前启用的插件重新计算"所有插件"的哈希值
+// 插件启用状态变化后调用，避免未指定插件的请求命中包含已禁用插件结果的缓存
+func UpdateAllPluginsHash(pluginNames []string) {
+	names := make([]string, len(pluginNames))
+	copy(names, pluginNames)
+	sort.Strings(names)
+	precomputedHashes.Store("all_plugins", calculateListHash(names))
+}
+
 // 获取或计算频道哈希
 func getChannelsHash(channels []string) string {
 	if channels == nil || len(channels) == 0 {
@@ -0,0 +1,376 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+	"pansou/model"
+)
+
+// 链接标题配对策略
+const (
+	LinkTitleMessage    = "message"     // 所有链接使用消息标题
+	LinkTitleLineBefore = "line_before" // 使用链接所在行之前最近的非链接行
+	LinkTitleSameLine   = "same_line"   // 使用链接所在行中链接前的文字，为空时退回line_before
+)
+
+// ChannelRule 单个频道的消息解析规则
+type ChannelRule struct {
+	TitleSelector    string   `json:"title_selector,omitempty"`    // 标题CSS选择器（在消息文本区域内查找），如"b"
+	TitleRegex       string   `json:"title_regex,omitempty"`       // 标题正则，取第一个捕获组
+	TitleLine        int      `json:"title_line,omitempty"`        // 标题所在行（从1开始，跳过空行）
+	PasswordSelector string   `json:"password_selector,omitempty"` // 密码CSS选择器，如".tg-spoiler"
+	PasswordPatterns []string `json:"password_patterns,omitempty"` // 密码正则，取第一个捕获组
+	IgnorePatterns   []string `json:"ignore_patterns,omitempty"`   // 命中任一正则的消息整条忽略（用于过滤广告）
+	LinkTitle        string   `json:"link_title,omitempty"`        // 链接标题配对策略：message、line_before、same_line
+
+	titleRegex      *regexp.Regexp
+	passwordRegexes []*regexp.Regexp
+	ignoreRegexes   []*regexp.Regexp
+}
+
+// channelRulesFile 规则文件结构，频道名"*"表示默认规则
+type channelRulesFile struct {
+	Channels map[string]*ChannelRule `json:"channels"`
+}
+
+var (
+	channelRules     map[string]*ChannelRule
+	channelRulesLock sync.RWMutex
+)
+
+// 链接前常见的标签文字，如"夸克链接："、"网盘地址:"
+var linkLabelPattern = regexp.MustCompile(`[\p{Han}A-Za-z0-9]{0,6}(链接|地址|网盘|下载)\s*[:：]?\s*$`)
+
+// 链接前的标签关键字，标签与标题之间没有分隔（如"凡人修仙传链接："）时只去掉关键字
+var linkKeywordPattern = regexp.MustCompile(`(链接|地址|网盘|下载)\s*[:：]?\s*$`)
+
+// 消息中的URL，遇到非ASCII字符结束（网盘链接不含中文，避免把紧跟在链接后的下一个标题并入链接）
+var lineURLPattern = regexp.MustCompile(`https?://[^\s<>"'\x{80}-\x{10FFFF}]+`)
+
+// 分行用的换行标签
+var brTagPattern = regexp.MustCompile(`(?i)<br\s*/?>`)
+
+// LoadChannelRules 从JSON文件加载频道解析规则，path为空时清空规则
+func LoadChannelRules(path string) error {
+	if path == "" {
+		setChannelRules(nil)
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取频道规则文件失败: %w", err)
+	}
+
+	var file channelRulesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("解析频道规则文件失败: %w", err)
+	}
+
+	rules := make(map[string]*ChannelRule, len(file.Channels))
+	for channel, rule := range file.Channels {
+		if rule == nil {
+			continue
+		}
+		if err := rule.compile(); err != nil {
+			return fmt.Errorf("频道 %s 规则无效: %w", channel, err)
+		}
+		rules[strings.ToLower(channel)] = rule
+	}
+
+	setChannelRules(rules)
+	return nil
+}
+
+// setChannelRules 替换全局规则
+func setChannelRules(rules map[string]*ChannelRule) {
+	channelRulesLock.Lock()
+	channelRules = rules
+	channelRulesLock.Unlock()
+}
+
+// GetChannelRule 获取频道规则，未配置时返回默认规则"*"，都没有时返回nil
+func GetChannelRule(channel string) *ChannelRule {
+	channelRulesLock.RLock()
+	defer channelRulesLock.RUnlock()
+
+	if len(channelRules) == 0 {
+		return nil
+	}
+	if rule, ok := channelRules[strings.ToLower(channel)]; ok {
+		return rule
+	}
+	return channelRules["*"]
+}
+
+// compile 预编译规则中的正则表达式
+func (r *ChannelRule) compile() error {
+	var err error
+	if r.TitleRegex != "" {
+		if r.titleRegex, err = regexp.Compile(r.TitleRegex); err != nil {
+			return fmt.Errorf("title_regex: %w", err)
+		}
+	}
+	for _, pattern := range r.PasswordPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("password_patterns: %w", err)
+		}
+		r.passwordRegexes = append(r.passwordRegexes, re)
+	}
+	for _, pattern := range r.IgnorePatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("ignore_patterns: %w", err)
+		}
+		r.ignoreRegexes = append(r.ignoreRegexes, re)
+	}
+
+	switch r.LinkTitle {
+	case "", LinkTitleMessage, LinkTitleLineBefore, LinkTitleSameLine:
+	default:
+		return fmt.Errorf("未知的link_title策略: %s", r.LinkTitle)
+	}
+	return nil
+}
+
+// ShouldIgnore 检查消息是否命中忽略规则
+func (r *ChannelRule) ShouldIgnore(text string) bool {
+	for _, re := range r.ignoreRegexes {
+		if re.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExtractTitle 按规则提取标题，未命中时返回空字符串
+// 优先级：title_selector > title_regex > title_line
+func (r *ChannelRule) ExtractTitle(textElem *goquery.Selection, lines []string) string {
+	if r.TitleSelector != "" {
+		if title := strings.TrimSpace(textElem.Find(r.TitleSelector).First().Text()); title != "" {
+			return title
+		}
+	}
+
+	if r.titleRegex != nil {
+		match := r.titleRegex.FindStringSubmatch(strings.Join(lines, "\n"))
+		if len(match) > 1 && strings.TrimSpace(match[1]) != "" {
+			return strings.TrimSpace(match[1])
+		}
+	}
+
+	if r.TitleLine > 0 {
+		n := 0
+		for _, line := range lines {
+			if line == "" {
+				continue
+			}
+			n++
+			if n == r.TitleLine {
+				return strings.TrimSpace(strings.TrimPrefix(line, "名称："))
+			}
+		}
+	}
+
+	return ""
+}
+
+// ExtractPassword 按规则提取密码，未命中时返回空字符串
+func (r *ChannelRule) ExtractPassword(textElem *goquery.Selection, text string) string {
+	if r.PasswordSelector != "" {
+		var password string
+		textElem.Find(r.PasswordSelector).EachWithBreak(func(i int, s *goquery.Selection) bool {
+			candidate := strings.TrimSpace(s.Text())
+			if p := r.matchPassword(candidate); p != "" {
+				password = p
+			} else if isPlainPassword(candidate) {
+				password = candidate
+			}
+			return password == ""
+		})
+		if password != "" {
+			return password
+		}
+	}
+
+	return r.matchPassword(text)
+}
+
+// matchPassword 使用密码正则匹配文本
+func (r *ChannelRule) matchPassword(text string) string {
+	for _, re := range r.passwordRegexes {
+		match := re.FindStringSubmatch(text)
+		if len(match) > 1 && strings.TrimSpace(match[1]) != "" {
+			return strings.TrimSpace(match[1])
+		}
+	}
+	return ""
+}
+
+// isPlainPassword 判断文本本身是否像提取码（4-8位字母数字）
+func isPlainPassword(text string) bool {
+	if len(text) < 4 || len(text) > 8 {
+		return false
+	}
+	for _, c := range text {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// applyChannelRule 将规则中的密码与链接标题应用到已提取的链接上
+// password为按规则提取到的密码，lines为按行拆分的消息内容
+func applyChannelRule(rule *ChannelRule, links []model.Link, password string, lines []htmlLine, title string) {
+	// 为缺少密码的链接补充密码
+	if password != "" {
+		for i := range links {
+			if links[i].Password != "" {
+				continue
+			}
+			links[i].Password = password
+			if links[i].Type == "baidu" {
+				links[i].URL = normalizeBaiduPanURL(links[i].URL, password)
+			}
+		}
+	}
+
+	switch rule.LinkTitle {
+	case "":
+		return
+	case LinkTitleMessage:
+		for i := range links {
+			links[i].WorkTitle = title
+		}
+	default:
+		for i := range links {
+			if workTitle := findLinkTitle(lines, links[i].URL, rule.LinkTitle); workTitle != "" {
+				links[i].WorkTitle = workTitle
+			}
+		}
+	}
+}
+
+// htmlLine 消息中的一行：纯文本与该行包含的所有URL
+type htmlLine struct {
+	text string
+	urls []string
+}
+
+// splitHTMLLines 按<br>将消息HTML拆分为行，并收集每行中的链接
+func splitHTMLLines(messageHTML string) []htmlLine {
+	fragments := brTagPattern.Split(messageHTML, -1)
+	lines := make([]htmlLine, 0, len(fragments))
+	for _, fragment := range fragments {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader("<div>" + fragment + "</div>"))
+		if err != nil {
+			continue
+		}
+		line := htmlLine{text: strings.TrimSpace(doc.Text())}
+		doc.Find("a").Each(func(i int, a *goquery.Selection) {
+			if href, ok := a.Attr("href"); ok {
+				line.urls = append(line.urls, href)
+			}
+		})
+		line.urls = append(line.urls, lineURLPattern.FindAllString(line.text, -1)...)
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// findLinkTitle 根据策略查找链接对应的作品标题
+func findLinkTitle(lines []htmlLine, linkURL string, strategy string) string {
+	for i, line := range lines {
+		if !lineContainsLink(line, linkURL) {
+			continue
+		}
+
+		if strategy == LinkTitleSameLine {
+			text := strings.Trim(trimLinkLabel(sameLineText(line.text, linkURL)), " :：-|丨")
+			if text != "" {
+				return text
+			}
+		}
+
+		// 向前查找最近的非链接行
+		for j := i - 1; j >= 0; j-- {
+			if lines[j].text == "" || len(lines[j].urls) > 0 {
+				continue
+			}
+			text := strings.TrimSpace(linkLabelPattern.ReplaceAllString(lines[j].text, ""))
+			text = strings.TrimSpace(strings.TrimPrefix(text, "名称："))
+			if text != "" {
+				return text
+			}
+		}
+		return ""
+	}
+	return ""
+}
+
+// sameLineText 返回行中位于该链接之前、上一个链接之后的文字，使一行中的多个"标题+链接"各自配对；
+// 链接不在行文本中时（如隐藏在"点击获取"文字后面）返回去掉所有URL后的整行文字
+func sameLineText(text string, linkURL string) string {
+	target := stripURLQuery(linkURL)
+	prevEnd := 0
+	for _, loc := range lineURLPattern.FindAllStringIndex(text, -1) {
+		candidate := stripURLQuery(text[loc[0]:loc[1]])
+		if candidate != "" && (strings.HasPrefix(target, candidate) || strings.HasPrefix(candidate, target)) {
+			return text[prevEnd:loc[0]]
+		}
+		prevEnd = loc[1]
+	}
+	return lineURLPattern.ReplaceAllString(text, "")
+}
+
+// trimLinkLabel 去掉链接前的标签文字，标签会吞掉整段文字时只去掉标签关键字
+func trimLinkLabel(text string) string {
+	if trimmed := strings.TrimSpace(linkLabelPattern.ReplaceAllString(text, "")); trimmed != "" {
+		return trimmed
+	}
+	return strings.TrimSpace(linkKeywordPattern.ReplaceAllString(text, ""))
+}
+
+// lineContainsLink 判断行中是否包含该链接（忽略查询参数，兼容标准化后带?pwd=的链接）
+func lineContainsLink(line htmlLine, linkURL string) bool {
+	target := stripURLQuery(linkURL)
+	if target == "" {
+		return false
+	}
+	for _, raw := range line.urls {
+		candidate := stripURLQuery(raw)
+		if candidate == "" {
+			continue
+		}
+		if strings.HasPrefix(target, candidate) || strings.HasPrefix(candidate, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripURLQuery 去掉URL的查询参数并转为小写
+func stripURLQuery(u string) string {
+	if idx := strings.IndexAny(u, "?#"); idx >= 0 {
+		u = u[:idx]
+	}
+	return strings.ToLower(strings.TrimSpace(u))
+}
+
+// htmlToLines 将消息HTML转换为按行分割的纯文本
+func htmlToLines(messageHTML string) []string {
+	htmlLines := splitHTMLLines(messageHTML)
+	lines := make([]string, 0, len(htmlLines))
+	for _, line := range htmlLines {
+		lines = append(lines, line.text)
+	}
+	return lines
+}
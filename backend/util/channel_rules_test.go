@@ -0,0 +1,173 @@
+package util_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"pansou/util"
+)
+
+// loadChannelRule 将规则写入临时文件并加载为频道testchan的规则，测试结束后清空
+func loadChannelRule(t *testing.T, rule string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "channel_rules.json")
+	if err := os.WriteFile(path, []byte(`{"channels": {"testchan": `+rule+`}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := util.LoadChannelRules(path); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { util.LoadChannelRules("") })
+}
+
+func TestChannelRuleTitle(t *testing.T) {
+	tests := []struct {
+		name string
+		rule string
+		text string
+		want string
+	}{
+		{"title_line跳过空行", `{"title_line": 2}`, "🎬 今日更新\n\n凡人修仙传 4K\n夸克：https://pan.quark.cn/s/abc123", "凡人修仙传 4K"},
+		{"title_line去掉名称前缀", `{"title_line": 1}`, "名称：凡人修仙传\nhttps://pan.quark.cn/s/abc123", "凡人修仙传"},
+		{"title_regex取捕获组", `{"title_regex": "名称[:：]\\s*(.+)"}`, "今日更新\n名称：斗罗大陆\nhttps://pan.quark.cn/s/abc123", "斗罗大陆"},
+		{"title_regex优先于title_line", `{"title_regex": "名称[:：]\\s*(.+)", "title_line": 1}`, "今日更新\n名称：斗罗大陆\nhttps://pan.quark.cn/s/abc123", "斗罗大陆"},
+		{"title_regex未命中时使用title_line", `{"title_regex": "片名[:：]\\s*(.+)", "title_line": 2}`, "今日更新\n斗罗大陆\nhttps://pan.quark.cn/s/abc123", "斗罗大陆"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loadChannelRule(t, tt.rule)
+			title, _, _ := util.ParseMessageText("testchan", tt.text, "", nil)
+			if title != tt.want {
+				t.Errorf("标题为 %q，期望 %q", title, tt.want)
+			}
+		})
+	}
+}
+
+func TestChannelRulePasswordPatterns(t *testing.T) {
+	loadChannelRule(t, `{"password_patterns": ["暗号[:：]\\s*([a-z0-9]{4})"]}`)
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"按正则补充提取码", "凡人修仙传\nhttps://pan.quark.cn/s/abc123\n暗号：x9k2", "x9k2"},
+		{"不覆盖链接自带的提取码", "凡人修仙传\nhttps://pan.baidu.com/s/1abcdef?pwd=ab12\n暗号：x9k2", "ab12"},
+		{"未命中时没有提取码", "凡人修仙传\nhttps://pan.quark.cn/s/abc123", ""},
+	}
+	for _, tt := range tests {
+		_, links, _ := util.ParseMessageText("testchan", tt.text, "", nil)
+		if len(links) != 1 || links[0].Password != tt.want {
+			t.Errorf("%s: 链接为 %+v，期望提取码 %q", tt.name, links, tt.want)
+		}
+	}
+}
+
+func TestChannelRuleIgnorePatterns(t *testing.T) {
+	loadChannelRule(t, `{"ignore_patterns": ["推广合作", "^广告"]}`)
+
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"推广合作请联系 @admin\nhttps://pan.quark.cn/s/abc123", true},
+		{"广告：某某机场\nhttps://pan.quark.cn/s/abc123", true},
+		{"凡人修仙传（无广告版）\nhttps://pan.quark.cn/s/abc123", false},
+	}
+	for _, tt := range tests {
+		if _, _, ignored := util.ParseMessageText("testchan", tt.text, "", nil); ignored != tt.want {
+			t.Errorf("%q: 忽略判断为 %v，期望 %v", tt.text, ignored, tt.want)
+		}
+	}
+	if _, _, ignored := util.ParseMessageText("otherchan", "推广合作\nhttps://pan.quark.cn/s/abc123", "", nil); ignored {
+		t.Error("忽略规则不应作用于其他频道")
+	}
+}
+
+func TestChannelRuleLinkTitle(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy string
+		text     string
+		want     map[string]string // 链接 -> 作品标题
+	}{
+		{
+			"message",
+			"message",
+			"凡人修仙传合集\n第一季 https://pan.quark.cn/s/aaa111\n第二季 https://pan.quark.cn/s/bbb222",
+			map[string]string{"https://pan.quark.cn/s/aaa111": "凡人修仙传合集", "https://pan.quark.cn/s/bbb222": "凡人修仙传合集"},
+		},
+		{
+			"line_before",
+			"line_before",
+			"今日更新\n凡人修仙传\nhttps://pan.quark.cn/s/aaa111\n斗罗大陆\n夸克链接：https://pan.quark.cn/s/bbb222",
+			map[string]string{"https://pan.quark.cn/s/aaa111": "凡人修仙传", "https://pan.quark.cn/s/bbb222": "斗罗大陆"},
+		},
+		{
+			"same_line",
+			"same_line",
+			"今日更新\n凡人修仙传 夸克链接：https://pan.quark.cn/s/aaa111\n斗罗大陆 | https://pan.quark.cn/s/bbb222",
+			map[string]string{"https://pan.quark.cn/s/aaa111": "凡人修仙传", "https://pan.quark.cn/s/bbb222": "斗罗大陆"},
+		},
+		{
+			"same_line一行多个链接",
+			"same_line",
+			"凡人修仙传链接：https://pan.quark.cn/s/aaa111斗罗大陆链接：https://pan.quark.cn/s/bbb222",
+			map[string]string{"https://pan.quark.cn/s/aaa111": "凡人修仙传", "https://pan.quark.cn/s/bbb222": "斗罗大陆"},
+		},
+		{
+			"same_line行内没有文字时退回line_before",
+			"same_line",
+			"凡人修仙传\nhttps://pan.quark.cn/s/aaa111",
+			map[string]string{"https://pan.quark.cn/s/aaa111": "凡人修仙传"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loadChannelRule(t, `{"title_line": 1, "link_title": "`+tt.strategy+`"}`)
+			_, links, _ := util.ParseMessageText("testchan", tt.text, "", nil)
+			if len(links) != len(tt.want) {
+				t.Fatalf("应提取 %d 个链接，实际 %+v", len(tt.want), links)
+			}
+			for _, link := range links {
+				if want := tt.want[link.URL]; link.WorkTitle != want {
+					t.Errorf("%s 的作品标题为 %q，期望 %q", link.URL, link.WorkTitle, want)
+				}
+			}
+		})
+	}
+}
+
+func TestChannelRuleSameLineOnChannelPage(t *testing.T) {
+	loadChannelRule(t, `{"link_title": "same_line"}`)
+
+	page := `<div class="tgme_widget_message_wrap"><div class="tgme_widget_message" data-post="testchan/7">
+<div class="tgme_widget_message_bubble"><div class="tgme_widget_message_text js-message_text">凡人修仙传链接：<a href="https://pan.quark.cn/s/aaa111">https://pan.quark.cn/s/aaa111</a>斗罗大陆链接：<a href="https://pan.quark.cn/s/bbb222">https://pan.quark.cn/s/bbb222</a></div>
+<div class="tgme_widget_message_footer"><span class="tgme_widget_message_meta"><a class="tgme_widget_message_date"><time datetime="2025-01-02T03:04:05+00:00">03:04</time></a></span></div>
+</div></div></div>`
+	results, _, err := util.ParseSearchResults(page, "testchan")
+	if err != nil || len(results) != 1 {
+		t.Fatalf("应解析出1条消息: %v %+v", err, results)
+	}
+	want := map[string]string{"https://pan.quark.cn/s/aaa111": "凡人修仙传", "https://pan.quark.cn/s/bbb222": "斗罗大陆"}
+	for _, link := range results[0].Links {
+		if link.WorkTitle != want[link.URL] {
+			t.Errorf("%s 的作品标题为 %q，期望 %q", link.URL, link.WorkTitle, want[link.URL])
+		}
+	}
+}
+
+func TestLoadChannelRulesRejectsInvalidRules(t *testing.T) {
+	for _, rule := range []string{`{"title_regex": "("}`, `{"password_patterns": ["["]}`, `{"ignore_patterns": ["*"]}`, `{"link_title": "nearest"}`} {
+		path := filepath.Join(t.TempDir(), "channel_rules.json")
+		if err := os.WriteFile(path, []byte(`{"channels": {"testchan": `+rule+`}}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := util.LoadChannelRules(path); err == nil {
+			t.Errorf("无效规则应加载失败: %s", rule)
+		}
+	}
+	util.LoadChannelRules("")
+}
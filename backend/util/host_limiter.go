@@ -0,0 +1,452 @@
+package util
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"pansou/config"
+)
+
+// HostLimit 单个主机的出站请求限制，值为0表示不限制
+type HostLimit struct {
+	RPS         float64 // 每秒请求数
+	Burst       int     // 突发请求数
+	MaxInFlight int     // 最大并发请求数
+}
+
+// HostLimitStats 单个主机的限流与排队统计
+type HostLimitStats struct {
+	Host        string  `json:"host"`
+	Rule        string  `json:"rule"` // 命中的规则，如 example.com、*.example.com 或 *
+	RPS         float64 `json:"rps"`
+	Burst       int     `json:"burst"`
+	MaxInFlight int     `json:"max_in_flight"`
+	InFlight    int     `json:"in_flight"`   // 当前进行中的请求数
+	Waiting     int     `json:"waiting"`     // 当前排队中的请求数
+	Requests    int64   `json:"requests"`    // 累计放行的请求数
+	Queued      int64   `json:"queued"`      // 累计需要排队的请求数
+	Canceled    int64   `json:"canceled"`    // 排队期间被取消或超时的请求数
+	AvgWaitMs   float64 `json:"avg_wait_ms"` // 排队请求的平均等待时间
+	MaxWaitMs   int64   `json:"max_wait_ms"`
+	Backoffs    int64   `json:"backoffs"`            // 按Retry-After暂停的次数
+	PausedMs    int64   `json:"paused_ms,omitempty"` // 剩余暂停时间
+}
+
+const (
+	// maxHostBackoff 按Retry-After暂停主机的最长时间
+	maxHostBackoff = time.Minute
+	// hostBucketIdleTTL 通配规则（*、*.域名）为单个主机创建的令牌桶空闲超过该时间后回收
+	hostBucketIdleTTL = 10 * time.Minute
+	// hostBucketSweepInterval 回收空闲令牌桶的最小间隔
+	hostBucketSweepInterval = time.Minute
+)
+
+// hostLimitRule 限流规则
+type hostLimitRule struct {
+	pattern string
+	limit   HostLimit
+}
+
+// hostBucket 单个主机的令牌桶与并发槽
+type hostBucket struct {
+	rule  string
+	limit HostLimit
+	slots chan struct{} // MaxInFlight为0时为nil
+
+	mu          sync.Mutex
+	tokens      float64
+	last        time.Time
+	lastUsed    time.Time
+	pausedUntil time.Time // 按Retry-After暂停到该时间
+	waiting     int
+	requests    int64
+	queued      int64
+	canceled    int64
+	backoffs    int64
+	totalWait   time.Duration
+	maxWait     time.Duration
+}
+
+// HostLimiter 按主机调度出站请求：限制每秒请求数、突发数与最大并发
+type HostLimiter struct {
+	rules []hostLimitRule
+
+	mu        sync.Mutex
+	buckets   map[string]*hostBucket
+	lastSweep time.Time
+}
+
+var (
+	hostLimiter     *HostLimiter
+	hostLimiterLock sync.RWMutex
+)
+
+// initHostLimiter 根据配置初始化全局主机限流器
+func initHostLimiter() {
+	var entries []string
+	if config.AppConfig != nil {
+		entries = config.AppConfig.HostRateLimits
+	}
+
+	limiter, errs := NewHostLimiter(entries)
+	for _, err := range errs {
+		fmt.Printf("⚠️ 忽略无效的主机限流规则: %v\n", err)
+	}
+
+	hostLimiterLock.Lock()
+	hostLimiter = limiter
+	hostLimiterLock.Unlock()
+}
+
+// GetHostLimiter 获取全局主机限流器
+func GetHostLimiter() *HostLimiter {
+	hostLimiterLock.RLock()
+	limiter := hostLimiter
+	hostLimiterLock.RUnlock()
+	if limiter != nil {
+		return limiter
+	}
+
+	initHostLimiter()
+	hostLimiterLock.RLock()
+	defer hostLimiterLock.RUnlock()
+	return hostLimiter
+}
+
+// NewHostLimiter 创建主机限流器，规则格式：主机=每秒请求数:突发数:最大并发。
+// 主机可以是完整主机名、*.域名（匹配所有子域名）或*（每个未匹配的主机单独使用该限制，空闲后回收），
+// 后两项可省略，如 example.com=2 表示每秒2个请求、突发2个、不限并发
+func NewHostLimiter(entries []string) (*HostLimiter, []error) {
+	limiter := &HostLimiter{buckets: make(map[string]*hostBucket)}
+	var errs []error
+	for _, entry := range entries {
+		rule, err := parseHostLimitRule(entry)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		limiter.rules = append(limiter.rules, rule)
+	}
+	return limiter, errs
+}
+
+// parseHostLimitRule 解析单条限流规则
+func parseHostLimitRule(entry string) (hostLimitRule, error) {
+	parts := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+		return hostLimitRule{}, fmt.Errorf("%s: 格式应为 主机=每秒请求数:突发数:最大并发", entry)
+	}
+
+	rule := hostLimitRule{pattern: strings.ToLower(strings.TrimSpace(parts[0]))}
+	values := strings.Split(parts[1], ":")
+	if len(values) > 3 {
+		return hostLimitRule{}, fmt.Errorf("%s: 参数过多", entry)
+	}
+
+	var err error
+	if rule.limit.RPS, err = strconv.ParseFloat(strings.TrimSpace(values[0]), 64); err != nil || rule.limit.RPS < 0 {
+		return hostLimitRule{}, fmt.Errorf("%s: 无效的每秒请求数", entry)
+	}
+	if len(values) > 1 {
+		if rule.limit.Burst, err = strconv.Atoi(strings.TrimSpace(values[1])); err != nil || rule.limit.Burst < 0 {
+			return hostLimitRule{}, fmt.Errorf("%s: 无效的突发数", entry)
+		}
+	}
+	if len(values) > 2 {
+		if rule.limit.MaxInFlight, err = strconv.Atoi(strings.TrimSpace(values[2])); err != nil || rule.limit.MaxInFlight < 0 {
+			return hostLimitRule{}, fmt.Errorf("%s: 无效的最大并发", entry)
+		}
+	}
+
+	// 突发数至少为1，否则令牌桶永远无法放行
+	if rule.limit.Burst == 0 {
+		rule.limit.Burst = int(rule.limit.RPS)
+		if rule.limit.Burst < 1 {
+			rule.limit.Burst = 1
+		}
+	}
+	return rule, nil
+}
+
+// Enabled 是否配置了任何限流规则
+func (l *HostLimiter) Enabled() bool {
+	return len(l.rules) > 0
+}
+
+// matchRule 查找主机匹配的规则：完整主机名优先，其次最长的*.域名，最后*
+func (l *HostLimiter) matchRule(host string) (hostLimitRule, bool) {
+	var best hostLimitRule
+	found := false
+	for _, rule := range l.rules {
+		switch {
+		case rule.pattern == host:
+			return rule, true
+		case strings.HasPrefix(rule.pattern, "*.") && strings.HasSuffix(host, rule.pattern[1:]):
+			if !found || best.pattern == "*" || len(rule.pattern) > len(best.pattern) {
+				best, found = rule, true
+			}
+		case rule.pattern == "*":
+			if !found {
+				best, found = rule, true
+			}
+		}
+	}
+	return best, found
+}
+
+// bucket 获取主机的令牌桶，没有匹配规则时返回nil（不缓存，避免未受限的主机占用内存）
+func (l *HostLimiter) bucket(host string) *hostBucket {
+	host = strings.ToLower(host)
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) >= hostBucketSweepInterval {
+		l.sweep(now)
+	}
+	if b, ok := l.buckets[host]; ok {
+		return b
+	}
+
+	rule, ok := l.matchRule(host)
+	if !ok {
+		return nil
+	}
+
+	b := &hostBucket{
+		rule:     rule.pattern,
+		limit:    rule.limit,
+		tokens:   float64(rule.limit.Burst),
+		last:     now,
+		lastUsed: now,
+	}
+	if rule.limit.MaxInFlight > 0 {
+		b.slots = make(chan struct{}, rule.limit.MaxInFlight)
+	}
+	l.buckets[host] = b
+	return b
+}
+
+// sweep 回收通配规则创建的空闲令牌桶（调用方持有l.mu），完整主机名规则的令牌桶保留以便查看统计
+func (l *HostLimiter) sweep(now time.Time) {
+	l.lastSweep = now
+	for host, b := range l.buckets {
+		if b.rule != host && b.idle(now) {
+			delete(l.buckets, host)
+		}
+	}
+}
+
+// idle 判断令牌桶是否空闲：无进行中与排队的请求、未暂停、令牌已回满且超过hostBucketIdleTTL未使用
+func (b *hostBucket) idle(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.waiting > 0 || len(b.slots) > 0 || now.Before(b.pausedUntil) || now.Sub(b.lastUsed) < hostBucketIdleTTL {
+		return false
+	}
+	if b.limit.RPS <= 0 {
+		return true
+	}
+	return b.tokens+now.Sub(b.last).Seconds()*b.limit.RPS >= float64(b.limit.Burst)
+}
+
+// acquire 等待令牌与并发槽，返回释放函数
+func (b *hostBucket) acquire(req *http.Request) (func(), error) {
+	start := time.Now()
+	ctx := req.Context()
+
+	b.mu.Lock()
+	b.waiting++
+	b.lastUsed = start
+	delay := b.reserve(start)
+	b.mu.Unlock()
+
+	waited := false
+	if delay > 0 {
+		waited = true
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			b.cancel(true)
+			return nil, ctx.Err()
+		}
+	}
+
+	release := func() {}
+	if b.slots != nil {
+		select {
+		case b.slots <- struct{}{}:
+		default:
+			waited = true
+			select {
+			case b.slots <- struct{}{}:
+			case <-ctx.Done():
+				b.cancel(false)
+				return nil, ctx.Err()
+			}
+		}
+		var once sync.Once
+		release = func() {
+			once.Do(func() { <-b.slots })
+		}
+	}
+
+	wait := time.Since(start)
+	b.mu.Lock()
+	b.waiting--
+	b.requests++
+	if waited {
+		b.queued++
+		b.totalWait += wait
+		if wait > b.maxWait {
+			b.maxWait = wait
+		}
+	}
+	b.mu.Unlock()
+	return release, nil
+}
+
+// reserve 从令牌桶取出一个令牌，返回需要等待的时间（调用方持有锁），主机暂停期间至少等到暂停结束
+func (b *hostBucket) reserve(now time.Time) time.Duration {
+	var delay time.Duration
+	if b.limit.RPS > 0 {
+		b.tokens += now.Sub(b.last).Seconds() * b.limit.RPS
+		if b.tokens > float64(b.limit.Burst) {
+			b.tokens = float64(b.limit.Burst)
+		}
+		b.last = now
+		b.tokens--
+		if b.tokens < 0 {
+			delay = time.Duration(-b.tokens / b.limit.RPS * float64(time.Second))
+		}
+	}
+	if paused := b.pausedUntil.Sub(now); paused > delay {
+		delay = paused
+	}
+	return delay
+}
+
+// backoff 主机返回429/503并带有Retry-After时暂停该主机的后续请求，暂停时间不超过maxHostBackoff
+func (b *hostBucket) backoff(resp *http.Response) {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return
+	}
+	wait, ok := parseRetryAfter(resp.Header.Get("Retry-After"))
+	if !ok || wait <= 0 {
+		return
+	}
+	if wait > maxHostBackoff {
+		wait = maxHostBackoff
+	}
+
+	until := time.Now().Add(wait)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if until.After(b.pausedUntil) {
+		b.pausedUntil = until
+		b.backoffs++
+	}
+}
+
+// cancel 记录排队期间被取消的请求，refund为true时归还已预订的令牌
+func (b *hostBucket) cancel(refund bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.waiting--
+	b.canceled++
+	if refund && b.limit.RPS > 0 {
+		b.tokens++
+	}
+}
+
+// Stats 获取所有已访问主机的限流统计（按主机名排序）
+func (l *HostLimiter) Stats() []HostLimitStats {
+	l.mu.Lock()
+	buckets := make(map[string]*hostBucket, len(l.buckets))
+	for host, b := range l.buckets {
+		buckets[host] = b
+	}
+	l.mu.Unlock()
+
+	now := time.Now()
+	stats := make([]HostLimitStats, 0, len(buckets))
+	for host, b := range buckets {
+		b.mu.Lock()
+		s := HostLimitStats{
+			Host:        host,
+			Rule:        b.rule,
+			RPS:         b.limit.RPS,
+			Burst:       b.limit.Burst,
+			MaxInFlight: b.limit.MaxInFlight,
+			InFlight:    len(b.slots),
+			Waiting:     b.waiting,
+			Requests:    b.requests,
+			Queued:      b.queued,
+			Canceled:    b.canceled,
+			MaxWaitMs:   b.maxWait.Milliseconds(),
+			Backoffs:    b.backoffs,
+		}
+		if now.Before(b.pausedUntil) {
+			s.PausedMs = b.pausedUntil.Sub(now).Milliseconds()
+		}
+		if b.queued > 0 {
+			s.AvgWaitMs = float64(b.totalWait.Milliseconds()) / float64(b.queued)
+		}
+		b.mu.Unlock()
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Host < stats[j].Host
+	})
+	return stats
+}
+
+// RoundTrip 按请求主机排队后通过next发送请求，未配置规则或主机不受限时直接发送
+func (l *HostLimiter) RoundTrip(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+	if !l.Enabled() {
+		return next.RoundTrip(req)
+	}
+
+	b := l.bucket(req.URL.Hostname())
+	if b == nil {
+		return next.RoundTrip(req)
+	}
+
+	release, err := b.acquire(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	b.backoff(resp)
+	// 响应体读取完毕并关闭后才释放并发槽
+	resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// releaseOnClose 关闭时释放并发槽的响应体
+type releaseOnClose struct {
+	io.ReadCloser
+	release func()
+}
+
+// Close 关闭响应体并释放并发槽
+func (r *releaseOnClose) Close() error {
+	err := r.ReadCloser.Close()
+	r.release()
+	return err
+}
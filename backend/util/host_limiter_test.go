@@ -0,0 +1,229 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestHostLimiter 创建限流器，规则无效时终止测试
+func newTestHostLimiter(t *testing.T, entries ...string) *HostLimiter {
+	t.Helper()
+	limiter, errs := NewHostLimiter(entries)
+	if len(errs) > 0 {
+		t.Fatalf("规则无效: %v", errs)
+	}
+	return limiter
+}
+
+// statusTransport 返回指定状态码与响应头的传输层
+func statusTransport(status int, header http.Header) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if header == nil {
+			header = http.Header{}
+		}
+		return &http.Response{
+			StatusCode: status,
+			Header:     header,
+			Body:       io.NopCloser(strings.NewReader("ok")),
+			Request:    req,
+		}, nil
+	})
+}
+
+// limitedGet 通过限流器发送请求并关闭响应体
+func limitedGet(t *testing.T, limiter *HostLimiter, ctx context.Context, rawURL string, next http.RoundTripper) error {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := limiter.RoundTrip(req, next)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func TestHostBucketReserve(t *testing.T) {
+	now := time.Now()
+	b := &hostBucket{limit: HostLimit{RPS: 2, Burst: 2}, tokens: 2, last: now}
+
+	if d := b.reserve(now); d != 0 {
+		t.Errorf("突发范围内的请求不应等待: %v", d)
+	}
+	if d := b.reserve(now); d != 0 {
+		t.Errorf("突发范围内的请求不应等待: %v", d)
+	}
+	if d := b.reserve(now); d != 500*time.Millisecond {
+		t.Errorf("令牌耗尽后应等待1/RPS，实际 %v", d)
+	}
+	if d := b.reserve(now); d != time.Second {
+		t.Errorf("排队的请求应依次顺延，实际 %v", d)
+	}
+
+	// 令牌按时间回填，但不超过突发数
+	b = &hostBucket{limit: HostLimit{RPS: 2, Burst: 2}, tokens: 0, last: now}
+	if d := b.reserve(now.Add(time.Minute)); d != 0 {
+		t.Errorf("回填后的请求不应等待: %v", d)
+	}
+	if b.tokens != 1 {
+		t.Errorf("令牌数不应超过突发数，实际剩余 %v", b.tokens)
+	}
+
+	// 暂停期间至少等到暂停结束
+	b = &hostBucket{limit: HostLimit{}, pausedUntil: now.Add(3 * time.Second)}
+	if d := b.reserve(now); d != 3*time.Second {
+		t.Errorf("暂停期间应等待到暂停结束，实际 %v", d)
+	}
+}
+
+func TestHostLimiterAcquireRate(t *testing.T) {
+	limiter := newTestHostLimiter(t, "a.test=20:2")
+	next := statusTransport(http.StatusOK, nil)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limitedGet(t, limiter, context.Background(), "https://a.test/", next); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("超过突发数的请求应等待令牌，实际耗时 %v", elapsed)
+	}
+
+	stats := limiter.Stats()
+	if len(stats) != 1 || stats[0].Requests != 3 || stats[0].Queued != 1 || stats[0].Waiting != 0 {
+		t.Errorf("统计不正确: %+v", stats)
+	}
+}
+
+func TestHostLimiterAcquireCanceled(t *testing.T) {
+	limiter := newTestHostLimiter(t, "a.test=1:1:1")
+	next := statusTransport(http.StatusOK, nil)
+
+	// 未关闭响应体时并发槽不释放
+	req, _ := http.NewRequest(http.MethodGet, "https://a.test/", nil)
+	resp, err := limiter.RoundTrip(req, next)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := limitedGet(t, limiter, ctx, "https://a.test/", next); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("等待令牌时超时应返回超时错误: %v", err)
+	}
+
+	b := limiter.bucket("a.test")
+	b.mu.Lock()
+	tokens := b.tokens
+	b.mu.Unlock()
+	if tokens < -0.01 {
+		t.Errorf("取消的请求应归还令牌，实际剩余 %v", tokens)
+	}
+
+	resp.Body.Close()
+	if len(b.slots) != 0 {
+		t.Error("关闭响应体后应释放并发槽")
+	}
+
+	stats := limiter.Stats()
+	if len(stats) != 1 || stats[0].Canceled != 1 || stats[0].Waiting != 0 || stats[0].Requests != 1 {
+		t.Errorf("统计不正确: %+v", stats)
+	}
+}
+
+func TestHostLimiterRetryAfterBackoff(t *testing.T) {
+	limiter := newTestHostLimiter(t, "*=0:1")
+
+	header := http.Header{"Retry-After": []string{"1"}}
+	if err := limitedGet(t, limiter, context.Background(), "https://a.test/", statusTransport(http.StatusTooManyRequests, header)); err != nil {
+		t.Fatal(err)
+	}
+
+	// 暂停期间请求排队，超过截止时间时放弃
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := limitedGet(t, limiter, ctx, "https://a.test/", statusTransport(http.StatusOK, nil)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("主机暂停期间请求应等待: %v", err)
+	}
+
+	// 其他主机不受影响
+	start := time.Now()
+	if err := limitedGet(t, limiter, context.Background(), "https://b.test/", statusTransport(http.StatusOK, nil)); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("未暂停的主机不应等待，实际 %v", elapsed)
+	}
+
+	start = time.Now()
+	if err := limitedGet(t, limiter, context.Background(), "https://a.test/", statusTransport(http.StatusOK, nil)); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 500*time.Millisecond {
+		t.Errorf("应等到Retry-After结束，实际只等待 %v", elapsed)
+	}
+
+	for _, s := range limiter.Stats() {
+		if s.Host == "a.test" && s.Backoffs != 1 {
+			t.Errorf("暂停次数应为1: %+v", s)
+		}
+	}
+
+	// 非429/503的Retry-After不暂停主机
+	if err := limitedGet(t, limiter, context.Background(), "https://c.test/", statusTransport(http.StatusOK, header)); err != nil {
+		t.Fatal(err)
+	}
+	if b := limiter.bucket("c.test"); !b.pausedUntil.IsZero() {
+		t.Error("成功响应的Retry-After不应暂停主机")
+	}
+}
+
+func TestHostLimiterSweepIdleBuckets(t *testing.T) {
+	limiter := newTestHostLimiter(t, "a.test=1:1", "*=1:1")
+	next := statusTransport(http.StatusOK, nil)
+
+	for _, rawURL := range []string{"https://a.test/", "https://b.test/", "https://c.test/"} {
+		if err := limitedGet(t, limiter, context.Background(), rawURL, next); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 未到空闲时间不回收
+	limiter.mu.Lock()
+	limiter.sweep(time.Now().Add(time.Second))
+	count := len(limiter.buckets)
+	limiter.mu.Unlock()
+	if count != 3 {
+		t.Fatalf("未空闲的令牌桶不应回收，剩余 %d 个", count)
+	}
+
+	// 通配规则创建的令牌桶空闲后回收，完整主机名规则的令牌桶保留
+	limiter.mu.Lock()
+	limiter.sweep(time.Now().Add(hostBucketIdleTTL + time.Second))
+	_, kept := limiter.buckets["a.test"]
+	count = len(limiter.buckets)
+	limiter.mu.Unlock()
+	if !kept || count != 1 {
+		t.Errorf("应只保留完整主机名规则的令牌桶，剩余 %d 个", count)
+	}
+}
+
+func TestHostLimiterUnmatchedHostNotCached(t *testing.T) {
+	limiter := newTestHostLimiter(t, "a.test=1:1")
+
+	if err := limitedGet(t, limiter, context.Background(), "https://other.test/", statusTransport(http.StatusOK, nil)); err != nil {
+		t.Fatal(err)
+	}
+	limiter.mu.Lock()
+	_, cached := limiter.buckets["other.test"]
+	limiter.mu.Unlock()
+	if cached {
+		t.Error("未匹配规则的主机不应缓存")
+	}
+}
@@ -18,6 +18,38 @@ var httpClient *http.Client
 
 // InitHTTPClient 初始化HTTP客户端
 func InitHTTPClient() {
+	proxyURL := ""
+	if config.AppConfig.UseProxy {
+		proxyURL = config.AppConfig.ProxyURL
+	}
+
+	// 初始化按主机限流器
+	initHostLimiter()
+
+	// 初始化自定义DNS解析器
+	initResolver()
+
+	// 初始化代理池，配置了代理池时TG请求使用代理池（可通过tg_tag指定标签）代替PROXY
+	initProxyPool()
+	transport := NewTransport(proxyURL)
+	if pool := GetProxyPool(); pool != nil {
+		transport = NewTransport("")
+		transport.Proxy = PoolProxyFunc(pool.TGTag())
+	}
+
+	// 创建客户端
+	httpClient = &http.Client{
+		Transport: NewOutboundTransport(transport),
+		Timeout:   time.Duration(60) * time.Second,
+	}
+
+	// 初始化TG预览镜像池（依赖全局客户端）
+	initTGMirrorPool()
+}
+
+// NewTransport 创建优化后的传输配置，proxyAddr为空时直连
+// 支持socks5://与http(s)://两种代理
+func NewTransport(proxyAddr string) *http.Transport {
 	// 创建传输配置
 	transport := &http.Transport{
 		// 启用HTTP/2
@@ -44,9 +76,14 @@ func InitHTTPClient() {
 		}).DialContext,
 	}
 
+	// 直连时使用自定义DNS解析（未配置时使用系统解析），使用代理时由代理解析目标主机
+	if proxyAddr == "" {
+		transport.DialContext = ResolvingDialContext(transport.DialContext)
+	}
+
 	// 如果配置了代理，设置代理
-	if config.AppConfig.UseProxy {
-		proxyURL, err := url.Parse(config.AppConfig.ProxyURL)
+	if proxyAddr != "" {
+		proxyURL, err := url.Parse(proxyAddr)
 		if err == nil {
 			// 根据代理类型设置不同的处理方式
 			if proxyURL.Scheme == "socks5" {
@@ -64,11 +101,7 @@ func InitHTTPClient() {
 		}
 	}
 
-	// 创建客户端
-	httpClient = &http.Client{
-		Transport: transport,
-		Timeout:   time.Duration(60) * time.Second,
-	}
+	return transport
 }
 
 // GetHTTPClient 获取HTTP客户端
@@ -113,9 +146,14 @@ func FetchHTML(targetURL string) (string, error) {
 	return string(body), nil
 }
 
-// BuildSearchURL 构建搜索URL
+// BuildSearchURL 构建搜索URL（使用首个配置的预览镜像）
 func BuildSearchURL(channel string, keyword string, nextPageParam string) string {
-	baseURL := "https://t.me/s/" + channel
+	return buildChannelURL(GetTGMirrorPool().Primary().BaseURL, channel, keyword, nextPageParam)
+}
+
+// buildChannelURL 基于预览镜像地址构建频道搜索URL
+func buildChannelURL(mirrorBase string, channel string, keyword string, nextPageParam string) string {
+	baseURL := mirrorBase + channel
 	if keyword != "" {
 		baseURL += "?q=" + url.QueryEscape(keyword)
 		if nextPageParam != "" {
@@ -0,0 +1,491 @@
+package index
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"pansou/model"
+)
+
+// BM25参数与字段权重
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+
+	titleWeight   = 3.0 // 标题词项权重
+	tagWeight     = 2.0 // 标签、文件名词项权重
+	contentWeight = 1.0 // 正文词项权重
+
+	indexFileName = "index.gob"
+)
+
+// Hit 索引命中结果
+type Hit struct {
+	Result model.SearchResult
+	Score  float64
+}
+
+// Stats 索引统计信息
+type Stats struct {
+	Documents int       `json:"documents"`
+	Terms     int       `json:"terms"`
+	MaxDocs   int       `json:"max_docs"`
+	Path      string    `json:"path"`
+	LastSave  time.Time `json:"last_save"`
+	Dirty     bool      `json:"dirty"`
+}
+
+// document 索引中的单个文档
+type document struct {
+	result  model.SearchResult
+	addedAt time.Time
+	length  float64            // 加权后的文档长度
+	terms   map[string]float64 // 词项 -> 加权词频
+}
+
+// storedDocument 持久化到磁盘的文档结构（倒排表在加载时重建）
+type storedDocument struct {
+	Result  model.SearchResult
+	AddedAt time.Time
+}
+
+// Index 基于BM25的内存倒排索引，支持持久化到磁盘
+type Index struct {
+	mu          sync.RWMutex
+	docs        map[string]*document
+	postings    map[string]map[string]float64 // 词项 -> 文档键 -> 加权词频
+	totalLength float64
+	maxDocs     int
+	path        string
+	dirty       bool
+	lastSave    time.Time
+
+	saveMu sync.Mutex // 串行化Save，避免定期保存与Close同时写同一个临时文件
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+// NewIndex 创建索引实例，path为空时仅在内存中维护
+func NewIndex(path string, maxDocs int) *Index {
+	return &Index{
+		docs:     make(map[string]*document),
+		postings: make(map[string]map[string]float64),
+		maxDocs:  maxDocs,
+		path:     path,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// documentKey 生成文档唯一键，规则与服务层结果去重保持一致
+func documentKey(result model.SearchResult) string {
+	if result.UniqueID != "" {
+		return result.UniqueID
+	}
+	if result.MessageID != "" {
+		return result.MessageID
+	}
+	return fmt.Sprintf("title_%s_%s", result.Title, result.Channel)
+}
+
+// analyze 计算文档的加权词频
+func analyze(result model.SearchResult) map[string]float64 {
+	terms := make(map[string]float64)
+	for _, t := range Tokenize(result.Title) {
+		terms[t] += titleWeight
+	}
+	for _, tag := range result.Tags {
+		for _, t := range Tokenize(tag) {
+			terms[t] += tagWeight
+		}
+	}
+	for _, t := range Tokenize(result.Content) {
+		terms[t] += contentWeight
+	}
+	for _, f := range result.Files {
+		for _, t := range Tokenize(f.Name) {
+			terms[t] += tagWeight
+		}
+	}
+	return terms
+}
+
+// Add 将搜索结果加入索引，已存在的文档会被更新
+func (idx *Index) Add(results []model.SearchResult) int {
+	if len(results) == 0 {
+		return 0
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	now := time.Now()
+	added := 0
+	for _, result := range results {
+		// 没有链接的结果对检索没有意义
+		if len(result.Links) == 0 {
+			continue
+		}
+		idx.addLocked(documentKey(result), result, now)
+		added++
+	}
+
+	if added > 0 {
+		idx.dirty = true
+		idx.evictLocked()
+	}
+	return added
+}
+
+// Remove 按文档键删除文档，返回文档是否存在
+func (idx *Index) Remove(key string) bool {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if _, exists := idx.docs[key]; !exists {
+		return false
+	}
+	idx.removeLocked(key)
+	idx.dirty = true
+	return true
+}
+
+// addLocked 写入单个文档（调用方需持有写锁）
+func (idx *Index) addLocked(key string, result model.SearchResult, addedAt time.Time) {
+	if _, exists := idx.docs[key]; exists {
+		idx.removeLocked(key)
+	}
+
+	terms := analyze(result)
+	if len(terms) == 0 {
+		return
+	}
+
+	doc := &document{
+		result:  result,
+		addedAt: addedAt,
+		terms:   terms,
+	}
+	for term, tf := range terms {
+		doc.length += tf
+		posting, ok := idx.postings[term]
+		if !ok {
+			posting = make(map[string]float64)
+			idx.postings[term] = posting
+		}
+		posting[key] = tf
+	}
+
+	idx.docs[key] = doc
+	idx.totalLength += doc.length
+}
+
+// removeLocked 删除单个文档（调用方需持有写锁）
+func (idx *Index) removeLocked(key string) {
+	doc, ok := idx.docs[key]
+	if !ok {
+		return
+	}
+	for term := range doc.terms {
+		if posting, ok := idx.postings[term]; ok {
+			delete(posting, key)
+			if len(posting) == 0 {
+				delete(idx.postings, term)
+			}
+		}
+	}
+	idx.totalLength -= doc.length
+	delete(idx.docs, key)
+}
+
+// evictLocked 超出容量时淘汰最早加入的文档，一次淘汰到容量的90%以避免频繁排序
+func (idx *Index) evictLocked() {
+	if idx.maxDocs <= 0 || len(idx.docs) <= idx.maxDocs {
+		return
+	}
+
+	type keyTime struct {
+		key     string
+		addedAt time.Time
+	}
+	ordered := make([]keyTime, 0, len(idx.docs))
+	for key, doc := range idx.docs {
+		ordered = append(ordered, keyTime{key, doc.addedAt})
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		return ordered[i].addedAt.Before(ordered[j].addedAt)
+	})
+
+	target := idx.maxDocs * 9 / 10
+	for i := 0; i < len(ordered) && len(idx.docs) > target; i++ {
+		idx.removeLocked(ordered[i].key)
+	}
+}
+
+// Search 检索关键词，所有查询词项都必须命中（与插件关键词过滤的AND语义一致），
+// 命中文档按BM25得分降序返回
+func (idx *Index) Search(query string, limit int) []Hit {
+	return idx.SearchFunc(query, limit, nil)
+}
+
+// SearchFunc 检索关键词，filter不为nil时只返回filter返回true的文档
+func (idx *Index) SearchFunc(query string, limit int, filter func(model.SearchResult) bool) []Hit {
+	queryTerms := uniqueTokens(TokenizeQuery(query))
+	if len(queryTerms) == 0 {
+		return nil
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	if len(idx.docs) == 0 {
+		return nil
+	}
+
+	// 从最短的倒排表开始求交集
+	postings := make([]map[string]float64, 0, len(queryTerms))
+	for _, term := range queryTerms {
+		posting, ok := idx.postings[term]
+		if !ok {
+			return nil
+		}
+		postings = append(postings, posting)
+	}
+	sort.Slice(postings, func(i, j int) bool {
+		return len(postings[i]) < len(postings[j])
+	})
+
+	docCount := float64(len(idx.docs))
+	avgLength := idx.totalLength / docCount
+
+	hits := make([]Hit, 0, len(postings[0]))
+	for key := range postings[0] {
+		doc := idx.docs[key]
+		if filter != nil && !filter(doc.result) {
+			continue
+		}
+
+		score := 0.0
+		matched := true
+		for _, posting := range postings {
+			tf, ok := posting[key]
+			if !ok {
+				matched = false
+				break
+			}
+			df := float64(len(posting))
+			idf := math.Log(1 + (docCount-df+0.5)/(df+0.5))
+			norm := tf + bm25K1*(1-bm25B+bm25B*doc.length/avgLength)
+			score += idf * tf * (bm25K1 + 1) / norm
+		}
+		if matched {
+			hits = append(hits, Hit{Result: doc.result, Score: score})
+		}
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score == hits[j].Score {
+			return hits[i].Result.Datetime.After(hits[j].Result.Datetime)
+		}
+		return hits[i].Score > hits[j].Score
+	})
+
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// Matches 判断单条结果是否命中关键词，匹配语义与Search一致（所有查询词项都必须命中）
+func Matches(query string, result model.SearchResult) bool {
+	queryTerms := uniqueTokens(TokenizeQuery(query))
+	if len(queryTerms) == 0 {
+		return false
+	}
+	terms := analyze(result)
+	for _, term := range queryTerms {
+		if _, ok := terms[term]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Len 返回索引文档数
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.docs)
+}
+
+// GetStats 获取索引统计信息
+func (idx *Index) GetStats() Stats {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return Stats{
+		Documents: len(idx.docs),
+		Terms:     len(idx.postings),
+		MaxDocs:   idx.maxDocs,
+		Path:      idx.path,
+		LastSave:  idx.lastSave,
+		Dirty:     idx.dirty,
+	}
+}
+
+// Load 从磁盘加载索引，文件不存在时视为空索引
+func (idx *Index) Load() error {
+	if idx.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(idx.path, indexFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("读取索引文件失败: %w", err)
+	}
+
+	var stored []storedDocument
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&stored); err != nil {
+		return fmt.Errorf("解析索引文件失败: %w", err)
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for _, sd := range stored {
+		idx.addLocked(documentKey(sd.Result), sd.Result, sd.AddedAt)
+	}
+	idx.evictLocked()
+	idx.lastSave = time.Now()
+	return nil
+}
+
+// Save 将索引写入磁盘（先写临时文件再重命名，避免写入中断导致文件损坏）
+func (idx *Index) Save() error {
+	if idx.path == "" {
+		return nil
+	}
+
+	idx.saveMu.Lock()
+	defer idx.saveMu.Unlock()
+
+	// 在同一把锁内生成快照并清除dirty，快照之后的修改会重新标记dirty，不会丢失
+	idx.mu.Lock()
+	if !idx.dirty {
+		idx.mu.Unlock()
+		return nil
+	}
+	stored := make([]storedDocument, 0, len(idx.docs))
+	for _, doc := range idx.docs {
+		stored = append(stored, storedDocument{Result: doc.result, AddedAt: doc.addedAt})
+	}
+	idx.dirty = false
+	idx.mu.Unlock()
+
+	if err := idx.writeFile(stored); err != nil {
+		// 写入失败时恢复dirty，等待下次保存
+		idx.mu.Lock()
+		idx.dirty = true
+		idx.mu.Unlock()
+		return err
+	}
+
+	idx.mu.Lock()
+	idx.lastSave = time.Now()
+	idx.mu.Unlock()
+	return nil
+}
+
+// writeFile 序列化文档并写入索引文件（调用方需持有saveMu）
+func (idx *Index) writeFile(stored []storedDocument) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(stored); err != nil {
+		return fmt.Errorf("序列化索引失败: %w", err)
+	}
+
+	if err := os.MkdirAll(idx.path, 0755); err != nil {
+		return fmt.Errorf("创建索引目录失败: %w", err)
+	}
+	filename := filepath.Join(idx.path, indexFileName)
+	tmpFile := filename + ".tmp"
+	if err := os.WriteFile(tmpFile, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("写入索引文件失败: %w", err)
+	}
+	if err := os.Rename(tmpFile, filename); err != nil {
+		return fmt.Errorf("替换索引文件失败: %w", err)
+	}
+	return nil
+}
+
+// StartAutoSave 启动后台定期保存
+func (idx *Index) StartAutoSave(interval time.Duration) {
+	if idx.path == "" || interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := idx.Save(); err != nil {
+					fmt.Printf("❌ [索引] 定期保存失败: %v\n", err)
+				}
+			case <-idx.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Close 停止后台保存并将索引写入磁盘
+func (idx *Index) Close() error {
+	idx.stopOnce.Do(func() {
+		close(idx.stopChan)
+	})
+	return idx.Save()
+}
+
+// 全局索引实例
+var (
+	defaultIndex     *Index
+	defaultIndexLock sync.RWMutex
+)
+
+// Init 初始化全局索引：加载磁盘数据并启动定期保存
+func Init(path string, maxDocs int, saveInterval time.Duration) (*Index, error) {
+	idx := NewIndex(path, maxDocs)
+	if err := idx.Load(); err != nil {
+		return nil, err
+	}
+	idx.StartAutoSave(saveInterval)
+
+	defaultIndexLock.Lock()
+	defaultIndex = idx
+	defaultIndexLock.Unlock()
+	return idx, nil
+}
+
+// Default 获取全局索引实例，未初始化时返回nil
+func Default() *Index {
+	defaultIndexLock.RLock()
+	defer defaultIndexLock.RUnlock()
+	return defaultIndex
+}
+
+// Close 关闭全局索引
+func Close() error {
+	if idx := Default(); idx != nil {
+		return idx.Close()
+	}
+	return nil
+}
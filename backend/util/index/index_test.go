@@ -0,0 +1,128 @@
+package index_test
+
+import (
+	"reflect"
+	"testing"
+
+	"pansou/model"
+	"pansou/util/index"
+)
+
+// doc 构造带链接的测试文档
+func doc(id, title, content string) model.SearchResult {
+	return model.SearchResult{
+		UniqueID: id,
+		Title:    title,
+		Content:  content,
+		Links:    []model.Link{{Type: "quark", URL: "https://pan.quark.cn/s/" + id}},
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"凡人修仙", []string{"凡人", "人修", "修仙", "凡", "人", "修", "仙"}},
+		{"剑", []string{"剑"}},
+		{"Go 编程 2024", []string{"go", "编程", "编", "程", "2024"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := index.Tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %v，期望 %v", tt.text, got, tt.want)
+		}
+	}
+
+	if got, want := index.TokenizeQuery("凡人修仙"), []string{"凡人", "人修", "修仙"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TokenizeQuery = %v，期望 %v", got, want)
+	}
+}
+
+func TestSearchSingleCJKCharacter(t *testing.T) {
+	idx := index.NewIndex("", 0)
+	idx.Add([]model.SearchResult{doc("a", "流浪地球", ""), doc("b", "三体", "")})
+
+	hits := idx.Search("球", 10)
+	if len(hits) != 1 || hits[0].Result.UniqueID != "a" {
+		t.Fatalf("单字查询应命中包含该字的文档: %+v", hits)
+	}
+}
+
+func TestSearchBM25Ranking(t *testing.T) {
+	idx := index.NewIndex("", 0)
+	idx.Add([]model.SearchResult{
+		doc("content", "合集", "这里有流浪地球的资源"),
+		doc("title", "流浪地球 4K", ""),
+		doc("other", "三体", "科幻小说"),
+	})
+
+	hits := idx.Search("流浪地球", 10)
+	if len(hits) != 2 {
+		t.Fatalf("命中 %d 个文档，期望 2 个", len(hits))
+	}
+	if hits[0].Result.UniqueID != "title" {
+		t.Errorf("标题命中应排在正文命中之前: %s", hits[0].Result.UniqueID)
+	}
+	if hits[0].Score <= hits[1].Score {
+		t.Errorf("得分未按降序排列: %v >= %v", hits[1].Score, hits[0].Score)
+	}
+
+	if hits := idx.Search("流浪 三体", 10); len(hits) != 0 {
+		t.Errorf("所有查询词项都必须命中，实际命中 %d 个", len(hits))
+	}
+}
+
+func TestMatches(t *testing.T) {
+	result := doc("a", "流浪地球 4K", "国语中字")
+	result.Tags = []string{"科幻"}
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"流浪地球", true},
+		{"地球 国语", true},
+		{"科幻", true},
+		{"流浪 三体", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := index.Matches(tt.query, result); got != tt.want {
+			t.Errorf("Matches(%q) = %v，期望 %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	idx := index.NewIndex(dir, 0)
+	idx.Add([]model.SearchResult{doc("a", "流浪地球", "科幻"), doc("b", "三体", "刘慈欣")})
+	if err := idx.Save(); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+	if idx.GetStats().Dirty {
+		t.Error("保存后不应为dirty")
+	}
+
+	// 保存后的修改重新标记dirty，下次保存时写入
+	idx.Add([]model.SearchResult{doc("c", "球状闪电", "刘慈欣")})
+	if !idx.GetStats().Dirty {
+		t.Error("保存后新增文档应标记dirty")
+	}
+	if err := idx.Close(); err != nil {
+		t.Fatalf("关闭失败: %v", err)
+	}
+
+	loaded := index.NewIndex(dir, 0)
+	if err := loaded.Load(); err != nil {
+		t.Fatalf("加载失败: %v", err)
+	}
+	if loaded.Len() != 3 {
+		t.Fatalf("加载了 %d 个文档，期望 3 个", loaded.Len())
+	}
+	hits := loaded.Search("刘慈欣", 10)
+	if len(hits) != 2 {
+		t.Errorf("加载后检索命中 %d 个文档，期望 2 个", len(hits))
+	}
+}
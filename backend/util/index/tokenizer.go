@@ -0,0 +1,92 @@
+package index
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Tokenize 将文档文本切分为索引词项
+// 中文等CJK连续片段同时生成单字与二元组(bigram)，使单字查询也能命中；
+// 英文、数字片段按单词切分并统一转为小写
+func Tokenize(text string) []string {
+	return tokenize(text, true)
+}
+
+// TokenizeQuery 将查询文本切分为检索词项
+// CJK连续片段只按二元组切分（单字片段保留单字），多字查询不会因单字匹配而放宽条件
+func TokenizeQuery(text string) []string {
+	return tokenize(text, false)
+}
+
+// tokenize 切分文本，unigrams为true时CJK多字片段额外生成单字词项
+func tokenize(text string, unigrams bool) []string {
+	if text == "" {
+		return nil
+	}
+
+	tokens := make([]string, 0, len(text)/2)
+	var word []rune
+	var cjk []rune
+
+	flushWord := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, string(word))
+			word = word[:0]
+		}
+	}
+	flushCJK := func() {
+		switch {
+		case len(cjk) == 1:
+			tokens = append(tokens, string(cjk))
+		case len(cjk) > 1:
+			for i := 0; i+1 < len(cjk); i++ {
+				tokens = append(tokens, string(cjk[i:i+2]))
+			}
+			if unigrams {
+				for _, r := range cjk {
+					tokens = append(tokens, string(r))
+				}
+			}
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+
+	return tokens
+}
+
+// isCJK 判断字符是否属于中日韩文字
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		unicode.Is(unicode.Hangul, r)
+}
+
+// uniqueTokens 对词项去重并保持原有顺序
+func uniqueTokens(tokens []string) []string {
+	seen := make(map[string]bool, len(tokens))
+	result := make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		if !seen[t] {
+			seen[t] = true
+			result = append(result, t)
+		}
+	}
+	return result
+}
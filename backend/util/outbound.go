@@ -0,0 +1,87 @@
+package util
+
+import (
+	"net/http"
+	"sync"
+)
+
+// OutboundInterceptor 出站请求拦截器，next为实际发送请求的传输层。
+// 用于测试时录制或回放上游响应
+type OutboundInterceptor func(req *http.Request, next http.RoundTripper) (*http.Response, error)
+
+var (
+	outboundInterceptor     OutboundInterceptor
+	outboundInterceptorLock sync.RWMutex
+)
+
+// SetOutboundInterceptor 设置全局出站请求拦截器，nil表示取消拦截
+func SetOutboundInterceptor(interceptor OutboundInterceptor) {
+	outboundInterceptorLock.Lock()
+	outboundInterceptor = interceptor
+	outboundInterceptorLock.Unlock()
+}
+
+// getOutboundInterceptor 获取全局出站请求拦截器
+func getOutboundInterceptor() OutboundInterceptor {
+	outboundInterceptorLock.RLock()
+	defer outboundInterceptorLock.RUnlock()
+	return outboundInterceptor
+}
+
+// roundTripperFunc 将函数适配为http.RoundTripper
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip 实现http.RoundTripper
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// outboundTransport 所有出站请求共用的传输层：先经过拦截器，再按主机限流
+type outboundTransport struct {
+	base http.RoundTripper
+}
+
+var (
+	defaultOutboundBase     *http.Transport
+	defaultOutboundBaseOnce sync.Once
+)
+
+// NewOutboundTransport 包装传输层，使请求经过全局出站请求拦截器与主机限流器，
+// 并让未指定拨号函数与代理的*http.Transport使用自定义DNS解析。规则在请求时读取，未配置时直接透传
+func NewOutboundTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = getDefaultOutboundBase()
+	}
+	if _, ok := base.(*outboundTransport); ok {
+		return base
+	}
+	if transport, ok := base.(*http.Transport); ok {
+		useResolver(transport)
+	}
+	return &outboundTransport{base: base}
+}
+
+// getDefaultOutboundBase 获取未指定传输层时共用的传输层（http.DefaultTransport的副本，不修改全局默认传输层）
+func getDefaultOutboundBase() *http.Transport {
+	defaultOutboundBaseOnce.Do(func() {
+		defaultOutboundBase = http.DefaultTransport.(*http.Transport).Clone()
+		defaultOutboundBase.DialContext = ResolvingDialContext(defaultOutboundBase.DialContext)
+	})
+	return defaultOutboundBase
+}
+
+// RoundTrip 实现http.RoundTripper
+func (t *outboundTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if interceptor := getOutboundInterceptor(); interceptor != nil {
+		return interceptor(req, roundTripperFunc(t.send))
+	}
+	return t.send(req)
+}
+
+// send 按主机限流后发送请求，使用代理池时统计所用代理的成功与失败，并把验证页转换为BlockedError（可通过BLOCK_DETECTION_ENABLED关闭）
+func (t *outboundTransport) send(req *http.Request) (*http.Response, error) {
+	resp, err := roundTripWithProxyPool(req, func(req *http.Request) (*http.Response, error) {
+		return GetHostLimiter().RoundTrip(req, t.base)
+	})
+	return inspectOutboundResponse(req, resp, err)
+}
@@ -0,0 +1,32 @@
+package util
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+)
+
+// RecoverBackground 在后台任务中以defer调用，恢复panic并记录调用栈，避免整个进程退出
+//
+//	go func() {
+//		defer util.RecoverBackground("缓存写入")
+//		...
+//	}()
+func RecoverBackground(task string) {
+	if r := recover(); r != nil {
+		log.Printf("[panic] 后台任务 %s panic: %v\n%s", task, r, debug.Stack())
+	}
+}
+
+// RecoverError 与RecoverBackground相同，但会把panic转换为错误写入*errp，用于调用方需要得知任务失败的场景
+//
+//	go func() {
+//		defer util.RecoverError("TG搜索", &tgErr)
+//		...
+//	}()
+func RecoverError(task string, errp *error) {
+	if r := recover(); r != nil {
+		log.Printf("[panic] 任务 %s panic: %v\n%s", task, r, debug.Stack())
+		*errp = fmt.Errorf("%s panic: %v", task, r)
+	}
+}
@@ -1,6 +1,8 @@
 package util
 
 import (
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -166,8 +168,8 @@ func ParseSearchResults(html string, channel string) ([]model.SearchResult, stri
 			return
 		}
 		
-		// 获取消息文本元素
-		messageTextElem := messageDiv.Find(".tgme_widget_message_text")
+		// 获取消息文本元素（排除回复预览中的原消息文本）
+		messageTextElem := messageDiv.Find(".tgme_widget_message_text").Not(".js-message_reply_text")
 		
 		// 获取消息文本的HTML内容
 		messageHTML, _ := messageTextElem.Html()
@@ -175,8 +177,21 @@ func ParseSearchResults(html string, channel string) ([]model.SearchResult, stri
 		// 获取消息的纯文本内容
 		messageText := messageTextElem.Text()
 		
-		// 提取标题
+		// 获取频道解析规则（未配置时为nil）
+		rule := GetChannelRule(channel)
+		
+		// 命中忽略规则的消息（如广告）直接跳过
+		if rule != nil && rule.ShouldIgnore(messageText) {
+			return
+		}
+		
+		// 提取标题，优先使用频道规则
 		title := extractTitle(messageHTML, messageText)
+		if rule != nil {
+			if ruleTitle := rule.ExtractTitle(messageTextElem, htmlToLines(messageHTML)); ruleTitle != "" {
+				title = ruleTitle
+			}
+		}
 		
 		// 提取网盘链接 - 使用更精确的方法
 		var links []model.Link
@@ -479,6 +494,12 @@ func ParseSearchResults(html string, channel string) ([]model.SearchResult, stri
 			}
 		}
 		
+		// 应用频道规则中的密码与链接标题配对
+		if rule != nil && len(links) > 0 {
+			password := rule.ExtractPassword(messageTextElem, messageText)
+			applyChannelRule(rule, links, password, splitHTMLLines(messageHTML), title)
+		}
+		
 		// 提取标签
 		var tags []string
 		messageTextElem.Find("a[href^='?q=%23']").Each(func(i int, a *goquery.Selection) {
@@ -520,15 +541,20 @@ func ParseSearchResults(html string, channel string) ([]model.SearchResult, stri
 		// 只有包含链接的消息才添加到结果中
 		if len(links) > 0 {
 			results = append(results, model.SearchResult{
-				MessageID: messageID,
-				UniqueID:  uniqueID,
-				Channel:   channel,
-				Datetime:  datetime,
-				Title:     title,
-				Content:   messageText,
-				Links:     links,
-				Tags:      tags,
-				Images:    images,
+				MessageID:     messageID,
+				UniqueID:      uniqueID,
+				Channel:       channel,
+				Datetime:      datetime,
+				Title:         title,
+				Content:       messageText,
+				Links:         links,
+				Tags:          tags,
+				Images:        images,
+				Views:         ParseViewCount(messageDiv.Find(".tgme_widget_message_views").First().Text()),
+				ForwardedFrom: extractForwardedFrom(messageDiv),
+				Files:         extractMessageFiles(messageBubble),
+				ReplyTo:       extractReplyInfo(messageDiv),
+				Edited:        isMessageEdited(messageDiv),
 			})
 		}
 	})
@@ -536,6 +562,231 @@ func ParseSearchResults(html string, channel string) ([]model.SearchResult, stri
 	return results, nextPageParam, nil
 }
 
+// TextLink 纯文本消息中的链接实体（如Bot API的text_link），Line为链接所在行号（从0开始）
+type TextLink struct {
+	URL  string
+	Line int
+}
+
+// ParseMessageText 解析纯文本消息（如Bot API推送的频道消息），返回标题与网盘链接
+// messageHTML为按格式实体渲染的HTML（见RenderTelegramEntities），为空时由纯文本生成，供频道规则的选择器使用；
+// textLinks为文本中不可见的链接（如"点击获取"背后的地址）；命中频道忽略规则时ignored为true
+func ParseMessageText(channel string, text string, messageHTML string, textLinks []TextLink) (title string, links []model.Link, ignored bool) {
+	rule := GetChannelRule(channel)
+	if rule != nil && rule.ShouldIgnore(text) {
+		return "", nil, true
+	}
+
+	// 构造与网页版消息结构一致的文本元素，使title_selector、password_selector同样生效
+	if messageHTML == "" {
+		messageHTML = RenderTelegramEntities(text, nil)
+	}
+	textElem := &goquery.Selection{}
+	if doc, err := goquery.NewDocumentFromReader(strings.NewReader("<div>" + messageHTML + "</div>")); err == nil {
+		textElem = doc.Find("body > div").First()
+	}
+
+	// 按行拆分，并记录每行包含的链接
+	rawLines := strings.Split(text, "\n")
+	lines := make([]htmlLine, len(rawLines))
+	lineTexts := make([]string, len(rawLines))
+	for i, raw := range rawLines {
+		lineTexts[i] = strings.TrimSpace(raw)
+		lines[i] = htmlLine{
+			text: lineTexts[i],
+			urls: lineURLPattern.FindAllString(raw, -1),
+		}
+	}
+	for _, tl := range textLinks {
+		if tl.Line >= 0 && tl.Line < len(lines) {
+			lines[tl.Line].urls = append(lines[tl.Line].urls, tl.URL)
+		}
+	}
+
+	// 提取标题，优先使用频道规则
+	title = extractTitle("", text)
+	if rule != nil {
+		if ruleTitle := rule.ExtractTitle(textElem, lineTexts); ruleTitle != "" {
+			title = ruleTitle
+		}
+	}
+
+	// 收集文本中的网盘链接与不可见的链接实体
+	candidates := ExtractNetDiskLinks(text)
+	for _, tl := range textLinks {
+		if isSupportedLink(tl.URL) {
+			candidates = append(candidates, tl.URL)
+		}
+	}
+
+	foundLinks := make(map[string]bool)
+	for _, linkURL := range candidates {
+		linkType := GetLinkType(linkURL)
+		password := ExtractPassword(text, linkURL)
+		normalizedURL := normalizeLinkURL(linkType, linkURL, password)
+		if foundLinks[normalizedURL] {
+			continue
+		}
+		foundLinks[normalizedURL] = true
+		links = append(links, model.Link{
+			Type:     linkType,
+			URL:      normalizedURL,
+			Password: password,
+		})
+	}
+
+	if rule != nil && len(links) > 0 {
+		applyChannelRule(rule, links, rule.ExtractPassword(textElem, text), lines, title)
+	}
+	return title, links, false
+}
+
+// normalizeLinkURL 按网盘类型标准化链接
+func normalizeLinkURL(linkType string, linkURL string, password string) string {
+	switch linkType {
+	case "baidu":
+		return normalizeBaiduPanURL(linkURL, password)
+	case "tianyi":
+		return normalizeTianyiPanURL(linkURL, password)
+	case "uc":
+		return normalizeUCPanURL(linkURL, password)
+	case "123":
+		return normalize123PanURL(linkURL, password)
+	case "115":
+		return normalize115PanURL(linkURL, password)
+	case "aliyun":
+		return CleanAliyunPanURL(linkURL)
+	default:
+		return linkURL
+	}
+}
+
+// ParseViewCount 解析TG浏览次数文本，如"987"、"1.2K"、"3.4M"
+func ParseViewCount(text string) int {
+	text = strings.ToUpper(strings.TrimSpace(text))
+	if text == "" {
+		return 0
+	}
+
+	multiplier := 1.0
+	switch {
+	case strings.HasSuffix(text, "K"):
+		multiplier = 1e3
+		text = strings.TrimSuffix(text, "K")
+	case strings.HasSuffix(text, "M"):
+		multiplier = 1e6
+		text = strings.TrimSuffix(text, "M")
+	case strings.HasSuffix(text, "B"):
+		multiplier = 1e9
+		text = strings.TrimSuffix(text, "B")
+	}
+
+	value, err := strconv.ParseFloat(strings.ReplaceAll(text, ",", ""), 64)
+	if err != nil || value < 0 {
+		return 0
+	}
+	return int(value * multiplier)
+}
+
+// fileSizePattern 文件大小文本，如"1.5 GB"、"700MB"、"4GiB"
+var fileSizePattern = regexp.MustCompile(`(?i)^\s*([\d.,]+)\s*([KMGTP]?I?B?)\s*$`)
+
+// ParseFileSize 解析文件大小文本为字节数，无法解析时返回0
+func ParseFileSize(text string) int64 {
+	match := fileSizePattern.FindStringSubmatch(text)
+	if match == nil {
+		return 0
+	}
+
+	value, err := strconv.ParseFloat(strings.ReplaceAll(match[1], ",", ""), 64)
+	if err != nil || value < 0 {
+		return 0
+	}
+
+	unit := strings.ToUpper(match[2])
+	multiplier := 1.0
+	if unit != "" {
+		switch unit[0] {
+		case 'K':
+			multiplier = 1 << 10
+		case 'M':
+			multiplier = 1 << 20
+		case 'G':
+			multiplier = 1 << 30
+		case 'T':
+			multiplier = 1 << 40
+		case 'P':
+			multiplier = 1 << 50
+		}
+	}
+	return int64(value * multiplier)
+}
+
+// extractForwardedFrom 提取"Forwarded from"转发来源名称
+func extractForwardedFrom(messageDiv *goquery.Selection) string {
+	forwarded := messageDiv.Find(".tgme_widget_message_forwarded_from").First()
+	if forwarded.Length() == 0 {
+		return ""
+	}
+
+	if name := strings.TrimSpace(forwarded.Find(".tgme_widget_message_forwarded_from_name").First().Text()); name != "" {
+		return name
+	}
+
+	// 隐藏来源的转发没有名称元素，去掉前缀后使用整段文本
+	text := strings.TrimSpace(forwarded.Text())
+	return strings.TrimSpace(strings.TrimPrefix(text, "Forwarded from"))
+}
+
+// extractMessageFiles 提取消息中的文件附件名称和大小
+func extractMessageFiles(messageBubble *goquery.Selection) []model.FileInfo {
+	var files []model.FileInfo
+	messageBubble.Find(".tgme_widget_message_document").Each(func(i int, doc *goquery.Selection) {
+		name := strings.TrimSpace(doc.Find(".tgme_widget_message_document_title").First().Text())
+		sizeText := strings.TrimSpace(doc.Find(".tgme_widget_message_document_extra").First().Text())
+		if name == "" && sizeText == "" {
+			return
+		}
+		files = append(files, model.FileInfo{
+			Name:     name,
+			Size:     ParseFileSize(sizeText),
+			SizeText: sizeText,
+		})
+	})
+	return files
+}
+
+// extractReplyInfo 提取回复的原消息预览
+func extractReplyInfo(messageDiv *goquery.Selection) *model.ReplyInfo {
+	reply := messageDiv.Find(".tgme_widget_message_reply").First()
+	if reply.Length() == 0 {
+		return nil
+	}
+
+	info := &model.ReplyInfo{
+		Author: strings.TrimSpace(reply.Find(".tgme_widget_message_author_name").First().Text()),
+		Text:   strings.TrimSpace(reply.Find(".js-message_reply_text").First().Text()),
+	}
+
+	// 原消息链接格式：https://t.me/频道名/消息ID
+	if href, exists := reply.Attr("href"); exists {
+		if idx := strings.LastIndex(href, "/"); idx >= 0 && idx < len(href)-1 {
+			info.MessageID = strings.Split(href[idx+1:], "?")[0]
+		}
+	}
+
+	if info.Author == "" && info.Text == "" && info.MessageID == "" {
+		return nil
+	}
+	return info
+}
+
+// isMessageEdited 检查消息元信息中是否带有编辑标记
+func isMessageEdited(messageDiv *goquery.Selection) bool {
+	meta := strings.ToLower(messageDiv.Find(".tgme_widget_message_meta").First().Text())
+	return strings.Contains(meta, "edited")
+}
+
 // extractImageURLFromStyle 从CSS样式字符串中提取background-image的URL
 func extractImageURLFromStyle(style string) string {
 	// 查找background-image:url('...') 或 background-image:url("...")
@@ -0,0 +1,105 @@
+package util_test
+
+import (
+	"testing"
+
+	"pansou/model"
+	"pansou/util"
+)
+
+func TestParseViewCount(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"987", 987},
+		{" 1.2K ", 1200},
+		{"3.4M", 3400000},
+		{"1.5k", 1500},
+		{"2B", 2000000000},
+		{"1,024", 1024},
+		{"", 0},
+		{"views", 0},
+		{"-5", 0},
+	}
+	for _, tt := range tests {
+		if got := util.ParseViewCount(tt.text); got != tt.want {
+			t.Errorf("ParseViewCount(%q) = %d，期望 %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParseFileSize(t *testing.T) {
+	tests := []struct {
+		text string
+		want int64
+	}{
+		{"1.5 GB", 1536 << 20},
+		{"700MB", 700 << 20},
+		{"4GiB", 4 << 30},
+		{"1,024 KB", 1 << 20},
+		{"512 kb", 512 << 10},
+		{"2 TB", 2 << 40},
+		{"120 B", 120},
+		{"300", 300},
+		{"", 0},
+		{"1.5 GB 视频", 0},
+		{"大约1GB", 0},
+	}
+	for _, tt := range tests {
+		if got := util.ParseFileSize(tt.text); got != tt.want {
+			t.Errorf("ParseFileSize(%q) = %d，期望 %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+// messagePage 包含转发、回复、文件附件与编辑标记的t.me/s/频道页面
+const messagePage = `<html><body>
+<div class="tgme_widget_message_wrap"><div class="tgme_widget_message" data-post="testchan/101">
+<div class="tgme_widget_message_bubble">
+<div class="tgme_widget_message_forwarded_from accent_color">Forwarded from <a class="tgme_widget_message_forwarded_from_name" href="https://t.me/source">资源分享</a></div>
+<a class="tgme_widget_message_reply" href="https://t.me/testchan/99?single"><div class="tgme_widget_message_author"><span class="tgme_widget_message_author_name">资源小助手</span></div><div class="tgme_widget_message_text js-message_reply_text">上一期合集</div></a>
+<div class="tgme_widget_message_document_wrap"><div class="tgme_widget_message_document"><div class="tgme_widget_message_document_title">凡人修仙传.mkv</div><div class="tgme_widget_message_document_extra">1.5 GB</div></div></div>
+<div class="tgme_widget_message_text js-message_text">凡人修仙传 4K<br/>夸克：<a href="https://pan.quark.cn/s/abc123">https://pan.quark.cn/s/abc123</a></div>
+<div class="tgme_widget_message_footer"><div class="tgme_widget_message_info"><span class="tgme_widget_message_views">1.2K</span><span class="tgme_widget_message_meta">edited <a class="tgme_widget_message_date" href="https://t.me/testchan/101"><time datetime="2025-01-02T03:04:05+00:00">03:04</time></a></span></div></div>
+</div></div></div>
+<div class="tgme_widget_message_wrap"><div class="tgme_widget_message" data-post="testchan/102">
+<div class="tgme_widget_message_bubble">
+<div class="tgme_widget_message_forwarded_from">Forwarded from 隐藏用户</div>
+<div class="tgme_widget_message_text js-message_text">凡人修仙传 第二季<br/><a href="https://pan.quark.cn/s/def456">https://pan.quark.cn/s/def456</a></div>
+<div class="tgme_widget_message_footer"><div class="tgme_widget_message_info"><span class="tgme_widget_message_views">987</span><span class="tgme_widget_message_meta"><a class="tgme_widget_message_date" href="https://t.me/testchan/102"><time datetime="2025-01-03T03:04:05+00:00">03:04</time></a></span></div></div>
+</div></div></div>
+</body></html>`
+
+func TestParseSearchResultsMessageMetadata(t *testing.T) {
+	results, _, err := util.ParseSearchResults(messagePage, "testchan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("应解析出2条消息，实际 %d 条", len(results))
+	}
+
+	first := results[0]
+	if first.Views != 1200 || first.ForwardedFrom != "资源分享" || !first.Edited {
+		t.Errorf("浏览次数、转发来源或编辑标记不正确: views=%d forwarded=%q edited=%v", first.Views, first.ForwardedFrom, first.Edited)
+	}
+	wantFile := model.FileInfo{Name: "凡人修仙传.mkv", Size: 1536 << 20, SizeText: "1.5 GB"}
+	if len(first.Files) != 1 || first.Files[0] != wantFile {
+		t.Errorf("文件附件不正确: %+v", first.Files)
+	}
+	if first.ReplyTo == nil || *first.ReplyTo != (model.ReplyInfo{MessageID: "99", Author: "资源小助手", Text: "上一期合集"}) {
+		t.Errorf("回复信息不正确: %+v", first.ReplyTo)
+	}
+	if first.Content != "凡人修仙传 4K夸克：https://pan.quark.cn/s/abc123" {
+		t.Errorf("消息内容不应包含回复预览: %q", first.Content)
+	}
+
+	second := results[1]
+	if second.Views != 987 || second.ForwardedFrom != "隐藏用户" || second.Edited {
+		t.Errorf("浏览次数、转发来源或编辑标记不正确: views=%d forwarded=%q edited=%v", second.Views, second.ForwardedFrom, second.Edited)
+	}
+	if second.ReplyTo != nil || len(second.Files) != 0 {
+		t.Errorf("没有回复与附件的消息不应带有这些字段: %+v %+v", second.ReplyTo, second.Files)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"sync"
 	"time"
+
+	"pansou/util"
 )
 
 // Task 表示一个工作任务
@@ -70,7 +72,7 @@ func (p *WorkerPool) startWorkers() {
 					}
 					
 					// 执行任务并发送结果
-					result := task()
+					result := runTask(task)
 					p.results <- result
 					
 				case <-p.ctx.Done():
@@ -81,6 +83,12 @@ func (p *WorkerPool) startWorkers() {
 	}
 }
 
+// runTask 执行任务，任务panic时返回nil，保证每个任务都有对应的结果
+func runTask(task Task) (result interface{}) {
+	defer util.RecoverBackground("工作池任务")
+	return task()
+}
+
 // Submit 提交一个任务到工作池
 func (p *WorkerPool) Submit(task Task) {
 	p.taskQueue <- task
@@ -0,0 +1,505 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"pansou/config"
+)
+
+// 代理池默认配置
+const (
+	defaultProxyCheckURL      = "https://www.gstatic.com/generate_204"
+	defaultProxyCheckInterval = 60 * time.Second
+	defaultProxyCheckTimeout  = 10 * time.Second
+	defaultProxyMaxFailures   = 3
+	defaultProxyStickyTTL     = 10 * time.Minute
+)
+
+// ProxyPoolConfig 代理池配置文件结构
+type ProxyPoolConfig struct {
+	Proxies       []ProxyEntry `json:"proxies" yaml:"proxies"`
+	CheckURL      string       `json:"check_url,omitempty" yaml:"check_url,omitempty"`           // 健康检查地址，默认 https://www.gstatic.com/generate_204
+	CheckInterval int          `json:"check_interval,omitempty" yaml:"check_interval,omitempty"` // 健康检查间隔（秒），默认60，负数表示不检查
+	MaxFailures   int          `json:"max_failures,omitempty" yaml:"max_failures,omitempty"`     // 连续失败多少次后剔除，默认3
+	Sticky        bool         `json:"sticky,omitempty" yaml:"sticky,omitempty"`                 // 同一主机的请求固定使用同一个代理
+	StickyTTL     int          `json:"sticky_ttl,omitempty" yaml:"sticky_ttl,omitempty"`         // 固定分配的有效期（秒），默认600
+	TGTag         string       `json:"tg_tag,omitempty" yaml:"tg_tag,omitempty"`                 // TG搜索使用的代理标签，空表示使用所有代理
+}
+
+// ProxyEntry 代理池中的单个代理
+type ProxyEntry struct {
+	URL    string   `json:"url" yaml:"url"`                           // 代理地址，支持 http://、https://、socks5://
+	Weight int      `json:"weight,omitempty" yaml:"weight,omitempty"` // 权重，默认1
+	Tags   []string `json:"tags,omitempty" yaml:"tags,omitempty"`     // 标签，插件或TG可以指定只使用某个标签的代理
+}
+
+// ProxyStats 单个代理的健康状态与使用统计
+type ProxyStats struct {
+	URL                 string    `json:"url"` // 隐去密码
+	Tags                []string  `json:"tags,omitempty"`
+	Weight              int       `json:"weight"`
+	Healthy             bool      `json:"healthy"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	Requests            int64     `json:"requests"` // 经过该代理的请求数
+	Errors              int64     `json:"errors"`   // 请求失败次数（不含HTTP错误状态码）
+	LastError           string    `json:"last_error,omitempty"`
+	LastCheck           time.Time `json:"last_check,omitempty"`
+	CheckLatencyMs      int64     `json:"check_latency_ms"` // 最近一次健康检查耗时
+}
+
+// pooledProxy 代理池中的代理及其状态
+type pooledProxy struct {
+	url    *url.URL
+	weight int
+	tags   map[string]bool
+
+	checkClient *http.Client // 健康检查专用客户端，每个代理复用一个
+
+	mu           sync.Mutex
+	healthy      bool
+	failures     int
+	requests     int64
+	errors       int64
+	lastError    string
+	lastCheck    time.Time
+	checkLatency time.Duration
+}
+
+// stickyAssignment 主机固定使用的代理
+type stickyAssignment struct {
+	proxy   *pooledProxy
+	expires time.Time
+}
+
+// ProxyPool 出站代理池：按权重轮换代理，可按主机固定分配，
+// 连续失败的代理被剔除，健康检查成功后恢复
+type ProxyPool struct {
+	proxies       []*pooledProxy
+	checkURL      string
+	checkInterval time.Duration
+	maxFailures   int
+	sticky        bool
+	stickyTTL     time.Duration
+	tgTag         string
+
+	mu          sync.Mutex
+	assignments map[string]stickyAssignment
+	rnd         *rand.Rand
+
+	stop      chan struct{}
+	startOnce sync.Once
+	closeOnce sync.Once
+}
+
+var (
+	proxyPool     *ProxyPool
+	proxyPoolLock sync.RWMutex
+)
+
+// initProxyPool 根据配置初始化全局代理池，配置无效时记录日志并不启用
+func initProxyPool() {
+	if config.AppConfig == nil || config.AppConfig.ProxyPoolPath == "" {
+		return
+	}
+	pool, err := LoadProxyPool(config.AppConfig.ProxyPoolPath)
+	if err != nil {
+		fmt.Printf("⚠️ 代理池配置加载失败: %v\n", err)
+		return
+	}
+	pool.Start()
+
+	proxyPoolLock.Lock()
+	if proxyPool != nil {
+		proxyPool.Close()
+	}
+	proxyPool = pool
+	proxyPoolLock.Unlock()
+}
+
+// GetProxyPool 获取全局代理池，未配置时返回nil
+func GetProxyPool() *ProxyPool {
+	proxyPoolLock.RLock()
+	defer proxyPoolLock.RUnlock()
+	return proxyPool
+}
+
+// LoadProxyPool 从JSON/YAML文件加载代理池配置
+func LoadProxyPool(path string) (*ProxyPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取代理池配置失败: %w", err)
+	}
+
+	var cfg ProxyPoolConfig
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &cfg)
+	default:
+		err = json.Unmarshal(data, &cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析代理池配置失败: %w", err)
+	}
+	return NewProxyPool(cfg)
+}
+
+// NewProxyPool 创建代理池，无效的代理记录日志并跳过
+func NewProxyPool(cfg ProxyPoolConfig) (*ProxyPool, error) {
+	pool := &ProxyPool{
+		checkURL:      cfg.CheckURL,
+		checkInterval: time.Duration(cfg.CheckInterval) * time.Second,
+		maxFailures:   cfg.MaxFailures,
+		sticky:        cfg.Sticky,
+		stickyTTL:     time.Duration(cfg.StickyTTL) * time.Second,
+		tgTag:         strings.TrimSpace(cfg.TGTag),
+		assignments:   make(map[string]stickyAssignment),
+		rnd:           rand.New(rand.NewSource(time.Now().UnixNano())),
+		stop:          make(chan struct{}),
+	}
+	if pool.checkURL == "" {
+		pool.checkURL = defaultProxyCheckURL
+	}
+	if cfg.CheckInterval == 0 {
+		pool.checkInterval = defaultProxyCheckInterval
+	}
+	if pool.maxFailures <= 0 {
+		pool.maxFailures = defaultProxyMaxFailures
+	}
+	if pool.stickyTTL <= 0 {
+		pool.stickyTTL = defaultProxyStickyTTL
+	}
+
+	for _, entry := range cfg.Proxies {
+		proxyURL, err := url.Parse(strings.TrimSpace(entry.URL))
+		if err != nil || proxyURL.Host == "" {
+			fmt.Printf("⚠️ 忽略无效的代理地址: %q\n", entry.URL)
+			continue
+		}
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			fmt.Printf("⚠️ 忽略不支持的代理协议: %q\n", proxyURL.Scheme)
+			continue
+		}
+
+		proxy := &pooledProxy{url: proxyURL, weight: entry.Weight, tags: make(map[string]bool), healthy: true}
+		proxy.checkClient = newProxyCheckClient(proxyURL)
+		if proxy.weight <= 0 {
+			proxy.weight = 1
+		}
+		for _, tag := range entry.Tags {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				proxy.tags[tag] = true
+			}
+		}
+		pool.proxies = append(pool.proxies, proxy)
+	}
+
+	if len(pool.proxies) == 0 {
+		return nil, errors.New("代理池中没有有效的代理")
+	}
+	if pool.tgTag != "" && !pool.HasTag(pool.tgTag) {
+		return nil, fmt.Errorf("没有标签为 %s 的代理（tg_tag）", pool.tgTag)
+	}
+	return pool, nil
+}
+
+// HasTag 检查代理池中是否有指定标签的代理
+func (p *ProxyPool) HasTag(tag string) bool {
+	for _, proxy := range p.proxies {
+		if proxy.tags[tag] {
+			return true
+		}
+	}
+	return false
+}
+
+// TGTag 返回TG搜索使用的代理标签
+func (p *ProxyPool) TGTag() string {
+	return p.tgTag
+}
+
+// Select 为访问host的请求选择代理，tag为空表示从所有代理中选择。
+// 优先选择健康的代理，指定标签的代理都被剔除时仍从中选择，避免请求绕过代理直连
+func (p *ProxyPool) Select(tag, host string) (*url.URL, error) {
+	var candidates, ejected []*pooledProxy
+	for _, proxy := range p.proxies {
+		if tag != "" && !proxy.tags[tag] {
+			continue
+		}
+		if proxy.isHealthy() {
+			candidates = append(candidates, proxy)
+		} else {
+			ejected = append(ejected, proxy)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = ejected
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("没有标签为 %s 的代理", tag)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := tag + "|" + host
+	now := time.Now()
+	if p.sticky {
+		if assignment, ok := p.assignments[key]; ok {
+			if now.Before(assignment.expires) && assignment.proxy.isHealthy() {
+				return assignment.proxy.url, nil
+			}
+			delete(p.assignments, key)
+		}
+	}
+
+	// 按权重随机选择
+	total := 0
+	for _, proxy := range candidates {
+		total += proxy.weight
+	}
+	chosen := candidates[len(candidates)-1]
+	n := p.rnd.Intn(total)
+	for _, proxy := range candidates {
+		if n < proxy.weight {
+			chosen = proxy
+			break
+		}
+		n -= proxy.weight
+	}
+
+	if p.sticky {
+		p.assignments[key] = stickyAssignment{proxy: chosen, expires: now.Add(p.stickyTTL)}
+	}
+	return chosen.url, nil
+}
+
+// ProxyFunc 返回用于http.Transport.Proxy的代理选择函数，每个请求单独选择代理
+func (p *ProxyPool) ProxyFunc(tag string) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		proxyURL, err := p.Select(tag, req.URL.Hostname())
+		if err != nil {
+			return nil, err
+		}
+		if choice, ok := req.Context().Value(proxyChoiceKey{}).(*proxyChoice); ok {
+			choice.set(p.find(proxyURL))
+		}
+		return proxyURL, nil
+	}
+}
+
+// PoolProxyFunc 返回使用全局代理池的代理选择函数，在请求时读取代理池，未配置代理池时直连
+func PoolProxyFunc(tag string) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		pool := GetProxyPool()
+		if pool == nil {
+			return nil, nil
+		}
+		return pool.ProxyFunc(tag)(req)
+	}
+}
+
+// find 根据地址查找代理
+func (p *ProxyPool) find(proxyURL *url.URL) *pooledProxy {
+	for _, proxy := range p.proxies {
+		if proxy.url == proxyURL {
+			return proxy
+		}
+	}
+	return nil
+}
+
+// Stats 返回所有代理的健康状态与使用统计
+func (p *ProxyPool) Stats() []ProxyStats {
+	stats := make([]ProxyStats, 0, len(p.proxies))
+	for _, proxy := range p.proxies {
+		proxy.mu.Lock()
+		item := ProxyStats{
+			URL:                 proxy.url.Redacted(),
+			Weight:              proxy.weight,
+			Healthy:             proxy.healthy,
+			ConsecutiveFailures: proxy.failures,
+			Requests:            proxy.requests,
+			Errors:              proxy.errors,
+			LastError:           proxy.lastError,
+			LastCheck:           proxy.lastCheck,
+			CheckLatencyMs:      proxy.checkLatency.Milliseconds(),
+		}
+		proxy.mu.Unlock()
+		for tag := range proxy.tags {
+			item.Tags = append(item.Tags, tag)
+		}
+		sort.Strings(item.Tags)
+		stats = append(stats, item)
+	}
+	return stats
+}
+
+// Start 启动定期健康检查，check_interval为负数时不检查，重复调用无效
+func (p *ProxyPool) Start() {
+	if p.checkInterval <= 0 {
+		return
+	}
+	p.startOnce.Do(p.startChecks)
+}
+
+// startChecks 启动健康检查协程
+func (p *ProxyPool) startChecks() {
+	go func() {
+		defer RecoverBackground("代理池健康检查")
+		ticker := time.NewTicker(p.checkInterval)
+		defer ticker.Stop()
+		p.CheckAll()
+		for {
+			select {
+			case <-ticker.C:
+				p.CheckAll()
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close 停止健康检查，可以重复调用
+func (p *ProxyPool) Close() {
+	p.closeOnce.Do(func() {
+		close(p.stop)
+	})
+}
+
+// CheckAll 并发检查所有代理，并清理失效的固定分配
+func (p *ProxyPool) CheckAll() {
+	var wg sync.WaitGroup
+	for _, proxy := range p.proxies {
+		wg.Add(1)
+		go func(proxy *pooledProxy) {
+			defer wg.Done()
+			defer RecoverBackground("代理健康检查")
+			p.check(proxy)
+		}(proxy)
+	}
+	wg.Wait()
+	p.pruneAssignments()
+}
+
+// pruneAssignments 删除已过期、代理已被剔除或已不在池中的固定分配
+func (p *ProxyPool) pruneAssignments() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	for key, assignment := range p.assignments {
+		if !now.Before(assignment.expires) || !assignment.proxy.isHealthy() || p.find(assignment.proxy.url) != assignment.proxy {
+			delete(p.assignments, key)
+		}
+	}
+}
+
+// newProxyCheckClient 创建通过指定代理访问健康检查地址的客户端（不复用连接，每次检查都重新连接代理）
+func newProxyCheckClient(proxyURL *url.URL) *http.Client {
+	transport := NewTransport("")
+	transport.Proxy = http.ProxyURL(proxyURL)
+	transport.DisableKeepAlives = true
+	return &http.Client{Transport: transport, Timeout: defaultProxyCheckTimeout}
+}
+
+// check 通过代理访问健康检查地址，能收到响应即视为代理可用
+func (p *ProxyPool) check(proxy *pooledProxy) {
+	start := time.Now()
+	resp, err := proxy.checkClient.Get(p.checkURL)
+	if err == nil {
+		resp.Body.Close()
+	}
+
+	proxy.mu.Lock()
+	proxy.lastCheck = time.Now()
+	proxy.checkLatency = time.Since(start)
+	proxy.mu.Unlock()
+	p.report(proxy, err, false)
+}
+
+// report 记录代理的一次请求或健康检查结果，连续失败达到阈值时剔除，成功时恢复
+func (p *ProxyPool) report(proxy *pooledProxy, err error, counted bool) {
+	proxy.mu.Lock()
+	defer proxy.mu.Unlock()
+	if counted {
+		proxy.requests++
+	}
+	if err == nil {
+		proxy.failures = 0
+		proxy.healthy = true
+		return
+	}
+	if counted {
+		proxy.errors++
+	}
+	proxy.failures++
+	proxy.lastError = err.Error()
+	if proxy.failures >= p.maxFailures && proxy.healthy {
+		proxy.healthy = false
+		fmt.Printf("⚠️ 代理 %s 连续失败 %d 次，已剔除: %v\n", proxy.url.Redacted(), proxy.failures, err)
+	}
+}
+
+// isHealthy 代理是否未被剔除
+func (proxy *pooledProxy) isHealthy() bool {
+	proxy.mu.Lock()
+	defer proxy.mu.Unlock()
+	return proxy.healthy
+}
+
+// proxyChoiceKey 请求上下文中记录所选代理的键
+type proxyChoiceKey struct{}
+
+// proxyChoice 记录请求实际使用的代理，用于请求结束后统计代理的成功与失败
+type proxyChoice struct {
+	mu    sync.Mutex
+	proxy *pooledProxy
+}
+
+// set 记录所选代理
+func (c *proxyChoice) set(proxy *pooledProxy) {
+	c.mu.Lock()
+	c.proxy = proxy
+	c.mu.Unlock()
+}
+
+// get 获取所选代理
+func (c *proxyChoice) get() *pooledProxy {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.proxy
+}
+
+// roundTripWithProxyPool 发送请求并把请求结果计入所用代理的统计，未配置代理池时直接发送。
+// 请求被调用方取消或超时不计为代理失败
+func roundTripWithProxyPool(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	pool := GetProxyPool()
+	if pool == nil {
+		return next(req)
+	}
+
+	choice := &proxyChoice{}
+	resp, err := next(req.WithContext(context.WithValue(req.Context(), proxyChoiceKey{}, choice)))
+	if proxy := choice.get(); proxy != nil {
+		if err != nil && req.Context().Err() != nil {
+			return resp, err
+		}
+		pool.report(proxy, err, true)
+	}
+	return resp, err
+}
@@ -0,0 +1,467 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+	"pansou/config"
+)
+
+// 解析器默认配置
+const (
+	defaultDNSCacheTTL  = 5 * time.Minute
+	dnsQueryTimeout     = 5 * time.Second
+	maxDNSResponseBytes = 64 * 1024
+)
+
+// DialFunc 与http.Transport.DialContext相同的拨号函数
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// ResolverConfig 自定义DNS解析配置
+type ResolverConfig struct {
+	Upstreams []string      // 上游解析器，按顺序尝试：https://.../dns-query（DNS-over-HTTPS）、udp://IP:端口 或 IP（默认53端口）
+	Hosts     []string      // 静态解析，格式：主机=IP，主机可以是*.域名，多个IP用|分隔
+	CacheTTL  time.Duration // 解析结果缓存时间，0使用默认值
+}
+
+// dnsUpstream 单个上游解析器
+type dnsUpstream interface {
+	lookup(ctx context.Context, host string) ([]net.IP, error)
+	String() string
+}
+
+// dnsCacheEntry 解析结果缓存
+type dnsCacheEntry struct {
+	ips     []net.IP
+	expires time.Time
+}
+
+// dnsCall 进行中的解析，同一主机的并发解析共享结果
+type dnsCall struct {
+	done chan struct{}
+	ips  []net.IP
+	err  error
+}
+
+// Resolver 出站请求使用的自定义DNS解析器：静态解析优先，其次按顺序查询上游解析器，结果按TTL缓存
+type Resolver struct {
+	hosts     map[string][]net.IP
+	upstreams []dnsUpstream
+	ttl       time.Duration
+
+	mu       sync.Mutex
+	cache    map[string]dnsCacheEntry
+	inflight map[string]*dnsCall
+}
+
+var (
+	resolver     *Resolver
+	resolverLock sync.RWMutex
+)
+
+// initResolver 根据配置初始化全局DNS解析器，未配置上游与静态解析时使用系统解析
+func initResolver() {
+	var cfg ResolverConfig
+	if config.AppConfig != nil {
+		cfg = ResolverConfig{
+			Upstreams: config.AppConfig.DNSResolvers,
+			Hosts:     config.AppConfig.DNSHosts,
+			CacheTTL:  config.AppConfig.DNSCacheTTL,
+		}
+	}
+
+	r, errs := NewResolver(cfg)
+	for _, err := range errs {
+		fmt.Printf("⚠️ 忽略无效的DNS配置: %v\n", err)
+	}
+	if r != nil && len(r.hosts) == 0 && len(r.upstreams) == 0 {
+		r = nil
+	}
+	SetResolver(r)
+}
+
+// SetResolver 设置全局DNS解析器，nil表示使用系统解析
+func SetResolver(r *Resolver) {
+	resolverLock.Lock()
+	resolver = r
+	resolverLock.Unlock()
+}
+
+// GetResolver 获取全局DNS解析器，未配置时返回nil
+func GetResolver() *Resolver {
+	resolverLock.RLock()
+	defer resolverLock.RUnlock()
+	return resolver
+}
+
+// NewResolver 创建DNS解析器，无效的上游或静态解析记录错误并跳过
+func NewResolver(cfg ResolverConfig) (*Resolver, []error) {
+	r := &Resolver{
+		hosts:    make(map[string][]net.IP),
+		ttl:      cfg.CacheTTL,
+		cache:    make(map[string]dnsCacheEntry),
+		inflight: make(map[string]*dnsCall),
+	}
+	if r.ttl <= 0 {
+		r.ttl = defaultDNSCacheTTL
+	}
+
+	var errs []error
+	for _, entry := range cfg.Hosts {
+		host, ips, err := parseDNSHost(entry)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		r.hosts[host] = ips
+	}
+	for _, entry := range cfg.Upstreams {
+		upstream, err := parseDNSUpstream(entry)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		r.upstreams = append(r.upstreams, upstream)
+	}
+	return r, errs
+}
+
+// parseDNSHost 解析静态解析条目：主机=IP1|IP2
+func parseDNSHost(entry string) (string, []net.IP, error) {
+	parts := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+		return "", nil, fmt.Errorf("静态解析格式应为 主机=IP: %q", entry)
+	}
+	host := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(parts[0]), "."))
+
+	var ips []net.IP
+	for _, value := range strings.Split(parts[1], "|") {
+		ip := net.ParseIP(strings.TrimSpace(value))
+		if ip == nil {
+			return "", nil, fmt.Errorf("静态解析的IP无效: %q", entry)
+		}
+		ips = append(ips, ip)
+	}
+	return host, ips, nil
+}
+
+// parseDNSUpstream 解析上游解析器地址
+func parseDNSUpstream(entry string) (dnsUpstream, error) {
+	entry = strings.TrimSpace(entry)
+	switch {
+	case strings.HasPrefix(entry, "https://") || strings.HasPrefix(entry, "http://"):
+		return newDoHUpstream(entry), nil
+	case strings.HasPrefix(entry, "udp://"):
+		entry = strings.TrimPrefix(entry, "udp://")
+	}
+
+	addr := entry
+	if _, _, err := net.SplitHostPort(entry); err != nil {
+		addr = net.JoinHostPort(entry, "53")
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) == nil {
+		return nil, fmt.Errorf("DNS解析器地址无效（应为IP、IP:端口、udp://IP:端口或DoH地址）: %q", entry)
+	}
+	return newUDPUpstream(addr), nil
+}
+
+// LookupHost 解析主机名，返回的IP中IPv4在前
+func (r *Resolver) LookupHost(ctx context.Context, host string) ([]net.IP, error) {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+	if ips, ok := r.staticLookup(host); ok {
+		return ips, nil
+	}
+	if len(r.upstreams) == 0 {
+		return lookupSystem(ctx, host)
+	}
+
+	r.mu.Lock()
+	if entry, ok := r.cache[host]; ok && time.Now().Before(entry.expires) {
+		r.mu.Unlock()
+		return entry.ips, nil
+	}
+	if call, ok := r.inflight[host]; ok {
+		r.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.ips, call.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	call := &dnsCall{done: make(chan struct{})}
+	r.inflight[host] = call
+	r.mu.Unlock()
+
+	// 解析结果供所有等待者使用，不跟随单个请求取消
+	call.ips, call.err = r.lookupUpstreams(host)
+
+	r.mu.Lock()
+	delete(r.inflight, host)
+	if call.err == nil {
+		r.cache[host] = dnsCacheEntry{ips: call.ips, expires: time.Now().Add(r.ttl)}
+	}
+	r.mu.Unlock()
+	close(call.done)
+	return call.ips, call.err
+}
+
+// staticLookup 查找静态解析，完整主机名优先于最长的*.域名
+func (r *Resolver) staticLookup(host string) ([]net.IP, bool) {
+	if ips, ok := r.hosts[host]; ok {
+		return ips, true
+	}
+	for domain := host; ; {
+		dot := strings.IndexByte(domain, '.')
+		if dot < 0 {
+			return nil, false
+		}
+		domain = domain[dot+1:]
+		if ips, ok := r.hosts["*."+domain]; ok {
+			return ips, true
+		}
+	}
+}
+
+// lookupUpstreams 按顺序查询上游解析器，返回第一个成功的结果
+func (r *Resolver) lookupUpstreams(host string) ([]net.IP, error) {
+	var errs []string
+	for _, upstream := range r.upstreams {
+		ctx, cancel := context.WithTimeout(context.Background(), dnsQueryTimeout)
+		ips, err := upstream.lookup(ctx, host)
+		cancel()
+		if err == nil && len(ips) > 0 {
+			return sortIPv4First(ips), nil
+		}
+		if err == nil {
+			err = errors.New("没有解析结果")
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", upstream, err))
+	}
+	return nil, fmt.Errorf("解析 %s 失败: %s", host, strings.Join(errs, "; "))
+}
+
+// DialContext 包装拨号函数：先用解析器解析主机名，再依次连接解析得到的IP
+func (r *Resolver) DialContext(next DialFunc) DialFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil || net.ParseIP(host) != nil {
+			return next(ctx, network, addr)
+		}
+		// 只配置了静态解析时，其他主机保持原有的拨号方式
+		if _, ok := r.staticLookup(strings.ToLower(strings.TrimSuffix(host, "."))); !ok && len(r.upstreams) == 0 {
+			return next(ctx, network, addr)
+		}
+		ips, err := r.LookupHost(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+
+		var lastErr error
+		for _, ip := range ips {
+			conn, err := next(ctx, network, net.JoinHostPort(ip.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		return nil, lastErr
+	}
+}
+
+// ResolvingDialContext 包装拨号函数，在拨号时读取全局DNS解析器，未配置时直接使用next。
+// next为nil时使用默认拨号器
+func ResolvingDialContext(next DialFunc) DialFunc {
+	if next == nil {
+		next = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if r := GetResolver(); r != nil {
+			return r.DialContext(next)(ctx, network, addr)
+		}
+		return next(ctx, network, addr)
+	}
+}
+
+// useResolver 让未指定拨号函数的传输层使用全局DNS解析器（在拨号时读取，未配置时使用系统解析）。
+// 已有拨号函数的传输层保持不变，重复调用不会重复包装；设置了代理的传输层由代理解析目标主机（如socks5远程解析）
+func useResolver(transport *http.Transport) {
+	if transport.DialContext != nil || transport.Dial != nil || transport.Proxy != nil {
+		return
+	}
+	transport.DialContext = ResolvingDialContext(nil)
+}
+
+// lookupSystem 使用系统解析器解析主机名
+func lookupSystem(ctx context.Context, host string) ([]net.IP, error) {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	ips := make([]net.IP, 0, len(addrs))
+	for _, addr := range addrs {
+		ips = append(ips, addr.IP)
+	}
+	return sortIPv4First(ips), nil
+}
+
+// sortIPv4First 把IPv4地址排在IPv6之前
+func sortIPv4First(ips []net.IP) []net.IP {
+	sorted := make([]net.IP, 0, len(ips))
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			sorted = append(sorted, ip)
+		}
+	}
+	for _, ip := range ips {
+		if ip.To4() == nil {
+			sorted = append(sorted, ip)
+		}
+	}
+	return sorted
+}
+
+// udpUpstream 通过UDP查询的上游解析器
+type udpUpstream struct {
+	addr     string
+	resolver *net.Resolver
+}
+
+// newUDPUpstream 创建只向addr发送查询的UDP解析器
+func newUDPUpstream(addr string) *udpUpstream {
+	return &udpUpstream{
+		addr: addr,
+		resolver: &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, network, addr)
+			},
+		},
+	}
+}
+
+// lookup 查询主机的A与AAAA记录
+func (u *udpUpstream) lookup(ctx context.Context, host string) ([]net.IP, error) {
+	addrs, err := u.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	ips := make([]net.IP, 0, len(addrs))
+	for _, addr := range addrs {
+		ips = append(ips, addr.IP)
+	}
+	return ips, nil
+}
+
+// String 返回解析器地址
+func (u *udpUpstream) String() string {
+	return "udp://" + u.addr
+}
+
+// dohUpstream DNS-over-HTTPS上游解析器（RFC 8484，POST application/dns-message）
+type dohUpstream struct {
+	url    string
+	client *http.Client
+}
+
+// newDoHUpstream 创建DoH解析器，DoH服务自身的地址使用系统解析（建议使用IP或未被污染的域名）
+func newDoHUpstream(rawURL string) *dohUpstream {
+	return &dohUpstream{
+		url:    rawURL,
+		client: &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone(), Timeout: dnsQueryTimeout},
+	}
+}
+
+// lookup 查询A记录，没有A记录时查询AAAA记录
+func (d *dohUpstream) lookup(ctx context.Context, host string) ([]net.IP, error) {
+	ips, err := d.query(ctx, host, dnsmessage.TypeA)
+	if err == nil && len(ips) > 0 {
+		return ips, nil
+	}
+	ipv6, err6 := d.query(ctx, host, dnsmessage.TypeAAAA)
+	if err6 == nil && len(ipv6) > 0 {
+		return ipv6, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, err6
+}
+
+// query 发送单个DNS查询
+func (d *dohUpstream) query(ctx context.Context, host string, qtype dnsmessage.Type) ([]net.IP, error) {
+	name, err := dnsmessage.NewName(host + ".")
+	if err != nil {
+		return nil, err
+	}
+	msg := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: uint16(rand.Intn(1 << 16)), RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: name, Type: qtype, Class: dnsmessage.ClassINET}},
+	}
+	packed, err := msg.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.url, bytes.NewReader(packed))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("状态码 %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxDNSResponseBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	var reply dnsmessage.Message
+	if err := reply.Unpack(body); err != nil {
+		return nil, fmt.Errorf("解析DNS响应失败: %w", err)
+	}
+	if reply.RCode != dnsmessage.RCodeSuccess {
+		return nil, fmt.Errorf("DNS响应错误: %v", reply.RCode)
+	}
+
+	var ips []net.IP
+	for _, answer := range reply.Answers {
+		switch body := answer.Body.(type) {
+		case *dnsmessage.AResource:
+			ips = append(ips, net.IP(body.A[:]))
+		case *dnsmessage.AAAAResource:
+			ips = append(ips, net.IP(body.AAAA[:]))
+		}
+	}
+	return ips, nil
+}
+
+// String 返回解析器地址
+func (d *dohUpstream) String() string {
+	return d.url
+}
@@ -0,0 +1,180 @@
+package util_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+	"pansou/util"
+)
+
+// answerDNS 构造DNS响应：example.test的子域名解析到127.0.0.1，其他域名返回NXDOMAIN
+func answerDNS(t *testing.T, query []byte) []byte {
+	t.Helper()
+	var msg dnsmessage.Message
+	if err := msg.Unpack(query); err != nil {
+		t.Errorf("解析DNS查询失败: %v", err)
+		return nil
+	}
+	reply := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: msg.ID, Response: true, RecursionAvailable: true},
+		Questions: msg.Questions,
+	}
+	for _, q := range msg.Questions {
+		if q.Name.String() != "cdn.example.test." {
+			reply.RCode = dnsmessage.RCodeNameError
+			continue
+		}
+		if q.Type == dnsmessage.TypeA {
+			reply.Answers = append(reply.Answers, dnsmessage.Resource{
+				Header: dnsmessage.ResourceHeader{Name: q.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60},
+				Body:   &dnsmessage.AResource{A: [4]byte{127, 0, 0, 1}},
+			})
+		}
+	}
+	packed, err := reply.Pack()
+	if err != nil {
+		t.Errorf("打包DNS响应失败: %v", err)
+	}
+	return packed
+}
+
+// startUDPResolver 启动本地UDP解析器，返回地址与收到的查询数
+func startUDPResolver(t *testing.T) (string, *int32) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("监听UDP失败: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	var queries int32
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(&queries, 1)
+			conn.WriteTo(answerDNS(t, buf[:n]), addr)
+		}
+	}()
+	return conn.LocalAddr().String(), &queries
+}
+
+// startDoHResolver 启动本地DoH解析器
+func startDoHResolver(t *testing.T) (string, *int32) {
+	var queries int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&queries, 1)
+		if r.Header.Get("Content-Type") != "application/dns-message" {
+			http.Error(w, "bad content type", http.StatusBadRequest)
+			return
+		}
+		query, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/dns-message")
+		w.Write(answerDNS(t, query))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL + "/dns-query", &queries
+}
+
+func TestResolverUpstreams(t *testing.T) {
+	udpAddr, udpQueries := startUDPResolver(t)
+	dohURL, dohQueries := startDoHResolver(t)
+
+	tests := []struct {
+		name     string
+		upstream string
+		queries  *int32
+	}{
+		{"udp", "udp://" + udpAddr, udpQueries},
+		{"doh", dohURL, dohQueries},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, errs := util.NewResolver(util.ResolverConfig{Upstreams: []string{tt.upstream}})
+			if len(errs) > 0 {
+				t.Fatalf("创建解析器失败: %v", errs)
+			}
+
+			ips, err := r.LookupHost(context.Background(), "cdn.example.test")
+			if err != nil {
+				t.Fatalf("解析失败: %v", err)
+			}
+			if len(ips) == 0 || !ips[0].Equal(net.IPv4(127, 0, 0, 1)) {
+				t.Fatalf("解析结果 = %v，期望 127.0.0.1", ips)
+			}
+
+			// 缓存期内不再查询上游
+			before := atomic.LoadInt32(tt.queries)
+			if _, err := r.LookupHost(context.Background(), "CDN.example.test."); err != nil {
+				t.Fatalf("再次解析失败: %v", err)
+			}
+			if after := atomic.LoadInt32(tt.queries); after != before {
+				t.Errorf("缓存期内查询了上游 %d 次", after-before)
+			}
+
+			if _, err := r.LookupHost(context.Background(), "missing.example.test"); err == nil {
+				t.Error("不存在的域名应解析失败")
+			}
+		})
+	}
+}
+
+func TestResolverFallbackAndHosts(t *testing.T) {
+	udpAddr, _ := startUDPResolver(t)
+	r, errs := util.NewResolver(util.ResolverConfig{
+		// 第一个解析器不可用时使用下一个
+		Upstreams: []string{"http://127.0.0.1:1/dns-query", udpAddr},
+		Hosts:     []string{"static.test=10.0.0.1|10.0.0.2", "*.wild.test=10.0.0.3", "bad"},
+	})
+	if len(errs) != 1 {
+		t.Errorf("无效的静态解析应返回1个错误，实际 %v", errs)
+	}
+
+	cases := map[string]string{
+		"static.test":      "10.0.0.1",
+		"a.b.wild.test":    "10.0.0.3",
+		"cdn.example.test": "127.0.0.1",
+	}
+	for host, want := range cases {
+		ips, err := r.LookupHost(context.Background(), host)
+		if err != nil {
+			t.Fatalf("解析 %s 失败: %v", host, err)
+		}
+		if ips[0].String() != want {
+			t.Errorf("解析 %s = %v，期望 %s", host, ips, want)
+		}
+	}
+}
+
+func TestResolvingTransport(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Host)
+	}))
+	defer origin.Close()
+	_, port, _ := net.SplitHostPort(origin.Listener.Addr().String())
+
+	r, _ := util.NewResolver(util.ResolverConfig{Hosts: []string{"poisoned.test=127.0.0.1"}})
+	util.SetResolver(r)
+	defer util.SetResolver(nil)
+
+	// 插件自建的传输层经过NewOutboundTransport后同样使用自定义解析
+	client := &http.Client{Transport: util.NewOutboundTransport(&http.Transport{})}
+	resp, err := client.Get("http://poisoned.test:" + port + "/")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "poisoned.test:"+port {
+		t.Errorf("Host = %q，期望保留原主机名", body)
+	}
+}
@@ -0,0 +1,237 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"pansou/config"
+)
+
+// RetryPolicy HTTP请求的重试策略
+type RetryPolicy struct {
+	MaxAttempts        int           // 最多尝试次数（含首次请求），小于等于1时不重试
+	Backoff            time.Duration // 首次重试前的等待时间，之后每次翻倍
+	MaxBackoff         time.Duration // 单次等待时间上限，0表示不限制
+	Jitter             float64       // 等待时间随机抖动比例（0~1），避免大量请求同时重试
+	RetryStatus        []int         // 需要重试的HTTP状态码
+	RetryNonIdempotent bool          // 是否重试POST等非幂等请求（搜索接口等无副作用的POST可以开启）
+	MaxRetryAfter      time.Duration // 遵循Retry-After响应头的最长等待时间，超过时不再重试，0表示忽略Retry-After
+}
+
+// HTTPStatusError 请求返回了非2xx状态码
+type HTTPStatusError struct {
+	StatusCode int
+}
+
+// Error 实现error接口
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("HTTP状态码: %d", e.StatusCode)
+}
+
+// DefaultRetryPolicy 返回全局默认重试策略（由RETRY_*环境变量配置）
+func DefaultRetryPolicy() RetryPolicy {
+	if config.AppConfig == nil {
+		return RetryPolicy{
+			MaxAttempts:   3,
+			Backoff:       200 * time.Millisecond,
+			MaxBackoff:    5 * time.Second,
+			Jitter:        0.2,
+			RetryStatus:   []int{429, 500, 502, 503, 504},
+			MaxRetryAfter: 10 * time.Second,
+		}
+	}
+	return RetryPolicy{
+		MaxAttempts:   config.AppConfig.RetryMaxAttempts,
+		Backoff:       config.AppConfig.RetryBackoff,
+		MaxBackoff:    config.AppConfig.RetryMaxBackoff,
+		Jitter:        config.AppConfig.RetryJitter,
+		RetryStatus:   append([]int(nil), config.AppConfig.RetryStatus...),
+		MaxRetryAfter: config.AppConfig.RetryMaxRetryAfter,
+	}
+}
+
+// DoWithRetry 按重试策略发送请求，只有2xx响应视为成功。
+// 网络错误与RetryStatus中的状态码会重试，其他状态码返回HTTPStatusError，验证页返回BlockedError，均不重试。
+// 非幂等请求只在RetryNonIdempotent开启且请求体可以重放时重试
+func DoWithRetry(client *http.Client, req *http.Request, policy RetryPolicy) (*http.Response, error) {
+	attempts := policy.MaxAttempts
+	if attempts < 1 || !canRetry(req, policy) {
+		attempts = 1
+	}
+	ctx := req.Context()
+	// 429由这里按Retry-After重试，出站传输层不提前转换为BlockedError
+	req = req.WithContext(withRateLimitPassthrough(ctx))
+
+	var lastErr error
+	tried := 0
+	for i := 0; i < attempts; i++ {
+		tried++
+		attemptReq, err := cloneRequest(req)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := client.Do(attemptReq)
+		if err != nil {
+			if IsBlockedError(err) || ctx.Err() != nil {
+				return nil, err
+			}
+			lastErr = err
+			if i == attempts-1 || !sleepContext(ctx, policy.backoff(i)) {
+				break
+			}
+			continue
+		}
+
+		// 429本身可以按Retry-After重试，其他状态码的验证页直接返回
+		if resp.StatusCode != http.StatusTooManyRequests {
+			if blockedErr := CheckBlocked(resp); blockedErr != nil {
+				resp.Body.Close()
+				return nil, blockedErr
+			}
+		}
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return resp, nil
+		}
+
+		wait, retryable := policy.retryDelay(resp, i)
+		if !retryable || i == attempts-1 {
+			if resp.StatusCode == http.StatusTooManyRequests {
+				if blockedErr := CheckBlocked(resp); blockedErr != nil {
+					resp.Body.Close()
+					return nil, blockedErr
+				}
+			}
+			resp.Body.Close()
+			statusErr := &HTTPStatusError{StatusCode: resp.StatusCode}
+			if tried == 1 {
+				return nil, statusErr
+			}
+			return nil, fmt.Errorf("重试 %d 次后仍然失败: %w", tried, statusErr)
+		}
+		resp.Body.Close()
+		lastErr = &HTTPStatusError{StatusCode: resp.StatusCode}
+		if !sleepContext(ctx, wait) {
+			break
+		}
+	}
+
+	if tried == 1 {
+		return nil, lastErr
+	}
+	return nil, fmt.Errorf("重试 %d 次后仍然失败: %w", tried, lastErr)
+}
+
+// canRetry 判断请求是否允许重试：幂等请求可以重试，非幂等请求需要策略允许且请求体可以重放
+func canRetry(req *http.Request, policy RetryPolicy) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+	default:
+		if !policy.RetryNonIdempotent && req.Header.Get("Idempotency-Key") == "" {
+			return false
+		}
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// cloneRequest 为每次尝试复制请求，并重新获取请求体
+func cloneRequest(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("重放请求体失败: %w", err)
+		}
+		clone.Body = body
+	}
+	return clone, nil
+}
+
+// backoff 计算第attempt次请求失败后的等待时间（指数退避加随机抖动）
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	wait := p.Backoff
+	for i := 0; i < attempt && (p.MaxBackoff <= 0 || wait < p.MaxBackoff); i++ {
+		wait *= 2
+	}
+	if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+		wait = p.MaxBackoff
+	}
+	if p.Jitter > 0 && wait > 0 {
+		jitter := p.Jitter
+		if jitter > 1 {
+			jitter = 1
+		}
+		wait = time.Duration(float64(wait) * (1 - jitter + 2*jitter*rand.Float64()))
+	}
+	return wait
+}
+
+// retryDelay 判断响应状态码是否需要重试并计算等待时间，Retry-After超过上限时不重试
+func (p RetryPolicy) retryDelay(resp *http.Response, attempt int) (time.Duration, bool) {
+	retryable := false
+	for _, status := range p.RetryStatus {
+		if status == resp.StatusCode {
+			retryable = true
+			break
+		}
+	}
+	if !retryable {
+		return 0, false
+	}
+
+	wait := p.backoff(attempt)
+	if p.MaxRetryAfter > 0 {
+		if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			if retryAfter > p.MaxRetryAfter {
+				return 0, false
+			}
+			if retryAfter > wait {
+				wait = retryAfter
+			}
+		}
+	}
+	return wait, true
+}
+
+// parseRetryAfter 解析Retry-After响应头（秒数或HTTP日期）
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		wait := time.Until(at)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// sleepContext 等待指定时间，请求被取消或等待结束前就会超过截止时间时返回false
+func sleepContext(ctx context.Context, wait time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+		return false
+	}
+	if wait <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,195 @@
+package util_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"pansou/util"
+)
+
+// statusServer 按顺序返回给定状态码，超出部分返回200，并记录每次请求的到达时间
+type statusServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	arrivals []time.Time
+	bodies   []string
+}
+
+func newStatusServer(t *testing.T, header http.Header, statuses ...int) *statusServer {
+	t.Helper()
+	s := &statusServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		n := len(s.arrivals)
+		s.arrivals = append(s.arrivals, time.Now())
+		s.bodies = append(s.bodies, string(body))
+		s.mu.Unlock()
+
+		if n < len(statuses) {
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(statuses[n])
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *statusServer) calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.arrivals)
+}
+
+func TestDoWithRetryCapsBackoff(t *testing.T) {
+	server := newStatusServer(t, nil, 503, 503, 503, 503)
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err := util.DoWithRetry(server.Client(), req, util.RetryPolicy{
+		MaxAttempts: 4,
+		Backoff:     40 * time.Millisecond,
+		MaxBackoff:  60 * time.Millisecond,
+		RetryStatus: []int{503},
+	})
+	var statusErr *util.HTTPStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != 503 {
+		t.Fatalf("重试用尽后应返回最后一次的状态码错误: %v", err)
+	}
+	if server.calls() != 4 {
+		t.Fatalf("应请求4次，实际 %d 次", server.calls())
+	}
+
+	// 等待时间依次为40ms、80ms→60ms、160ms→60ms
+	want := []time.Duration{40 * time.Millisecond, 60 * time.Millisecond, 60 * time.Millisecond}
+	for i, min := range want {
+		gap := server.arrivals[i+1].Sub(server.arrivals[i])
+		if gap < min || gap > min+70*time.Millisecond {
+			t.Errorf("第%d次重试前等待了 %v，期望约 %v", i+1, gap, min)
+		}
+	}
+}
+
+func TestDoWithRetryHonorsRetryAfter(t *testing.T) {
+	server := newStatusServer(t, http.Header{"Retry-After": []string{"1"}}, 503)
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	start := time.Now()
+	resp, err := util.DoWithRetry(server.Client(), req, util.RetryPolicy{
+		MaxAttempts:   2,
+		Backoff:       time.Millisecond,
+		RetryStatus:   []int{503},
+		MaxRetryAfter: 2 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("应按Retry-After等待1秒后重试，实际等待 %v", elapsed)
+	}
+	if server.calls() != 2 {
+		t.Errorf("应请求2次，实际 %d 次", server.calls())
+	}
+}
+
+func TestDoWithRetryRetryAfterExceedsLimit(t *testing.T) {
+	server := newStatusServer(t, http.Header{"Retry-After": []string{"30"}}, 503)
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	start := time.Now()
+	_, err := util.DoWithRetry(server.Client(), req, util.RetryPolicy{
+		MaxAttempts:   3,
+		Backoff:       time.Millisecond,
+		RetryStatus:   []int{503},
+		MaxRetryAfter: time.Second,
+	})
+	var statusErr *util.HTTPStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != 503 {
+		t.Fatalf("Retry-After超过上限时应直接返回状态码错误: %v", err)
+	}
+	if server.calls() != 1 {
+		t.Errorf("Retry-After超过上限时不应重试，实际请求 %d 次", server.calls())
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("不应等待Retry-After，实际耗时 %v", elapsed)
+	}
+}
+
+func TestDoWithRetryNonIdempotent(t *testing.T) {
+	tests := []struct {
+		name               string
+		retryNonIdempotent bool
+		wantCalls          int
+	}{
+		{"默认不重试POST", false, 1},
+		{"开启RetryNonIdempotent后重试并重放请求体", true, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newStatusServer(t, nil, 503, 503, 503)
+			req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{"kw":"凡人修仙传"}`))
+			_, err := util.DoWithRetry(server.Client(), req, util.RetryPolicy{
+				MaxAttempts:        3,
+				Backoff:            time.Millisecond,
+				RetryStatus:        []int{503},
+				RetryNonIdempotent: tt.retryNonIdempotent,
+			})
+			if err == nil {
+				t.Fatal("所有尝试均返回503时应失败")
+			}
+			if server.calls() != tt.wantCalls {
+				t.Fatalf("应请求 %d 次，实际 %d 次", tt.wantCalls, server.calls())
+			}
+			for i, body := range server.bodies {
+				if body != `{"kw":"凡人修仙传"}` {
+					t.Errorf("第%d次请求的请求体为 %q", i+1, body)
+				}
+			}
+		})
+	}
+}
+
+func TestDoWithRetryStatusList(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		wantCalls int
+		wantErr   bool
+	}{
+		{"列表内的状态码重试后成功", 502, 2, false},
+		{"列表外的状态码不重试", 500, 1, true},
+		{"4xx不重试", 404, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newStatusServer(t, nil, tt.status)
+			req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+			resp, err := util.DoWithRetry(server.Client(), req, util.RetryPolicy{
+				MaxAttempts: 3,
+				Backoff:     time.Millisecond,
+				RetryStatus: []int{429, 502},
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("错误为 %v，期望出错: %v", err, tt.wantErr)
+			}
+			if err == nil {
+				resp.Body.Close()
+			} else {
+				var statusErr *util.HTTPStatusError
+				if !errors.As(err, &statusErr) || statusErr.StatusCode != tt.status {
+					t.Errorf("应返回状态码 %d 的错误: %v", tt.status, err)
+				}
+			}
+			if server.calls() != tt.wantCalls {
+				t.Errorf("应请求 %d 次，实际 %d 次", tt.wantCalls, server.calls())
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package util
+
+import (
+	"html"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"pansou/model"
+)
+
+// telegramEntityTags Bot API格式实体对应的HTML标签，与网页版消息使用的标签保持一致
+var telegramEntityTags = map[string]string{
+	"bold":          "b",
+	"italic":        "i",
+	"underline":     "u",
+	"strikethrough": "s",
+	"spoiler":       "span",
+	"code":          "code",
+	"pre":           "pre",
+	"blockquote":    "blockquote",
+	"text_link":     "a",
+	"url":           "a",
+	"hashtag":       "a",
+}
+
+// entitySpan 实体覆盖的UTF-16区间及其开闭标签
+type entitySpan struct {
+	start, end int
+	open       string
+	close      string
+}
+
+// RenderTelegramEntities 将Bot API消息文本按格式实体渲染为HTML，
+// 结构与网页版.tgme_widget_message_text的内容一致（换行为<br/>），使频道规则中的选择器对Bot推送的消息同样生效
+func RenderTelegramEntities(text string, entities []model.TelegramEntity) string {
+	units := utf16.Encode([]rune(text))
+
+	spans := make([]entitySpan, 0, len(entities))
+	for _, entity := range entities {
+		tag, ok := telegramEntityTags[entity.Type]
+		if !ok || entity.Offset < 0 || entity.Length <= 0 || entity.Offset >= len(units) {
+			continue
+		}
+		end := min(entity.Offset+entity.Length, len(units))
+		open := "<" + tag + ">"
+		switch entity.Type {
+		case "spoiler":
+			open = `<span class="tg-spoiler">`
+		case "text_link":
+			open = `<a href="` + html.EscapeString(entity.URL) + `">`
+		case "url":
+			open = `<a href="` + html.EscapeString(string(utf16.Decode(units[entity.Offset:end]))) + `">`
+		case "hashtag":
+			// 与网页版标签链接格式一致（?q=%23标签）
+			open = `<a href="?q=` + html.EscapeString(url.QueryEscape(string(utf16.Decode(units[entity.Offset:end])))) + `">`
+		}
+		spans = append(spans, entitySpan{start: entity.Offset, end: end, open: open, close: "</" + tag + ">"})
+	}
+	// 起点相同时外层（更长）的实体先打开
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].start != spans[j].start {
+			return spans[i].start < spans[j].start
+		}
+		return spans[i].end > spans[j].end
+	})
+
+	var b strings.Builder
+	var stack []entitySpan
+	next := 0
+	for pos := 0; ; {
+		// 关闭在当前位置结束的实体（未正确嵌套的实体随外层一起关闭）
+		for len(stack) > 0 && stack[len(stack)-1].end <= pos {
+			b.WriteString(stack[len(stack)-1].close)
+			stack = stack[:len(stack)-1]
+		}
+		if pos >= len(units) {
+			break
+		}
+		for next < len(spans) && spans[next].start <= pos {
+			b.WriteString(spans[next].open)
+			stack = append(stack, spans[next])
+			next++
+		}
+
+		// 代理对占两个UTF-16码元
+		n := 1
+		if utf16.IsSurrogate(rune(units[pos])) && pos+1 < len(units) {
+			n = 2
+		}
+		if char := string(utf16.Decode(units[pos : pos+n])); char == "\n" {
+			b.WriteString("<br/>")
+		} else {
+			b.WriteString(html.EscapeString(char))
+		}
+		pos += n
+	}
+	return b.String()
+}
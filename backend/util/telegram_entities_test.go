@@ -0,0 +1,77 @@
+package util_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"pansou/model"
+	"pansou/util"
+)
+
+// entity 按子串在文本中的位置构造实体，偏移与长度以UTF-16码元计
+func entity(text, sub, entityType string) model.TelegramEntity {
+	i := strings.Index(text, sub)
+	return model.TelegramEntity{
+		Type:   entityType,
+		Offset: len(utf16.Encode([]rune(text[:i]))),
+		Length: len(utf16.Encode([]rune(sub))),
+	}
+}
+
+func TestRenderTelegramEntities(t *testing.T) {
+	link := entity("🎬 点击获取", "点击获取", "text_link")
+	link.URL = "https://pan.quark.cn/s/abc?a=1&b=2"
+
+	tests := []struct {
+		name     string
+		text     string
+		entities []model.TelegramEntity
+		want     string
+	}{
+		{"纯文本转义与换行", "a<b>\n&c", nil, "a&lt;b&gt;<br/>&amp;c"},
+		{"代理对之后的偏移", "🎬 凡人修仙传", []model.TelegramEntity{entity("🎬 凡人修仙传", "凡人修仙传", "bold")}, "🎬 <b>凡人修仙传</b>"},
+		{
+			"嵌套实体",
+			"凡人修仙传 4K",
+			[]model.TelegramEntity{entity("凡人修仙传 4K", "4K", "italic"), entity("凡人修仙传 4K", "凡人修仙传 4K", "bold")},
+			"<b>凡人修仙传 <i>4K</i></b>",
+		},
+		{"剧透", "暗号 x9k2", []model.TelegramEntity{entity("暗号 x9k2", "x9k2", "spoiler")}, `暗号 <span class="tg-spoiler">x9k2</span>`},
+		{"文字链接", "🎬 点击获取", []model.TelegramEntity{link}, `🎬 <a href="https://pan.quark.cn/s/abc?a=1&amp;b=2">点击获取</a>`},
+		{"越界实体被忽略", "abc", []model.TelegramEntity{{Type: "bold", Offset: 5, Length: 2}}, "abc"},
+	}
+	for _, tt := range tests {
+		if got := util.RenderTelegramEntities(tt.text, tt.entities); got != tt.want {
+			t.Errorf("%s: 得到 %q，期望 %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseMessageTextChannelSelectors(t *testing.T) {
+	rules := filepath.Join(t.TempDir(), "channel_rules.json")
+	content := `{"channels": {"botchannel": {"title_selector": "b", "password_selector": ".tg-spoiler"}}}`
+	if err := os.WriteFile(rules, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := util.LoadChannelRules(rules); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { util.LoadChannelRules("") })
+
+	text := "🎬 今日更新\n凡人修仙传 4K\n夸克：https://pan.quark.cn/s/abc123\n暗号 x9k2"
+	entities := []model.TelegramEntity{entity(text, "凡人修仙传 4K", "bold"), entity(text, "x9k2", "spoiler")}
+
+	title, links, ignored := util.ParseMessageText("botchannel", text, util.RenderTelegramEntities(text, entities), nil)
+	if ignored {
+		t.Fatal("消息不应被忽略")
+	}
+	if title != "凡人修仙传 4K" {
+		t.Errorf("title_selector应从渲染的HTML中提取标题，实际 %q", title)
+	}
+	if len(links) != 1 || links[0].Password != "x9k2" {
+		t.Errorf("password_selector应从剧透中提取密码: %+v", links)
+	}
+}
@@ -0,0 +1,292 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"pansou/config"
+)
+
+// 官方TG预览地址
+const officialTGMirror = "https://t.me/s/"
+
+// 冷却时间最多放大到基础冷却时间的倍数
+const maxTGMirrorCooldownFactor = 16
+
+// TGMirror TG频道预览镜像（官方地址、自建反代或兼容的镜像前端）
+type TGMirror struct {
+	BaseURL string // 预览地址前缀，频道名直接拼接在后面，如 https://t.me/s/
+	Proxy   string // 该镜像专用代理，空表示使用全局代理，direct表示强制直连
+
+	client *http.Client
+
+	// 健康状态（由所属镜像池的锁保护）
+	consecutiveFailures int
+	cooldownUntil       time.Time
+	lastError           string
+	lastSuccess         time.Time
+	totalSuccess        int64
+	totalFailure        int64
+}
+
+// TGMirrorStatus 镜像健康状态
+type TGMirrorStatus struct {
+	BaseURL             string    `json:"base_url"`
+	Proxy               string    `json:"proxy,omitempty"`
+	Healthy             bool      `json:"healthy"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	CooldownUntil       time.Time `json:"cooldown_until,omitempty"`
+	LastError           string    `json:"last_error,omitempty"`
+	LastSuccess         time.Time `json:"last_success,omitempty"`
+	TotalSuccess        int64     `json:"total_success"`
+	TotalFailure        int64     `json:"total_failure"`
+}
+
+// TGMirrorPool 预览镜像池，按配置顺序优先使用健康的镜像，失败的镜像进入冷却期
+type TGMirrorPool struct {
+	mu       sync.Mutex
+	mirrors  []*TGMirror
+	cooldown time.Duration
+}
+
+var (
+	tgMirrorPool     *TGMirrorPool
+	tgMirrorPoolLock sync.RWMutex
+)
+
+// initTGMirrorPool 根据配置初始化全局镜像池
+func initTGMirrorPool() {
+	entries := []string{officialTGMirror}
+	cooldown := 60 * time.Second
+	if config.AppConfig != nil {
+		if len(config.AppConfig.TGMirrors) > 0 {
+			entries = config.AppConfig.TGMirrors
+		}
+		if config.AppConfig.TGMirrorCooldown > 0 {
+			cooldown = config.AppConfig.TGMirrorCooldown
+		}
+	}
+
+	pool := NewTGMirrorPool(entries, cooldown)
+
+	tgMirrorPoolLock.Lock()
+	tgMirrorPool = pool
+	tgMirrorPoolLock.Unlock()
+}
+
+// GetTGMirrorPool 获取全局镜像池
+func GetTGMirrorPool() *TGMirrorPool {
+	tgMirrorPoolLock.RLock()
+	pool := tgMirrorPool
+	tgMirrorPoolLock.RUnlock()
+	if pool != nil {
+		return pool
+	}
+
+	// 未初始化时使用官方地址
+	initTGMirrorPool()
+	tgMirrorPoolLock.RLock()
+	defer tgMirrorPoolLock.RUnlock()
+	return tgMirrorPool
+}
+
+// NewTGMirrorPool 创建镜像池，entries格式：地址 或 地址|代理
+func NewTGMirrorPool(entries []string, cooldown time.Duration) *TGMirrorPool {
+	pool := &TGMirrorPool{cooldown: cooldown}
+	for _, entry := range entries {
+		if mirror := parseTGMirror(entry); mirror != nil {
+			pool.mirrors = append(pool.mirrors, mirror)
+		}
+	}
+	if len(pool.mirrors) == 0 {
+		pool.mirrors = append(pool.mirrors, parseTGMirror(officialTGMirror))
+	}
+	return pool
+}
+
+// parseTGMirror 解析单个镜像配置
+func parseTGMirror(entry string) *TGMirror {
+	parts := strings.SplitN(strings.TrimSpace(entry), "|", 2)
+	baseURL := strings.TrimSpace(parts[0])
+	if baseURL == "" {
+		return nil
+	}
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+
+	mirror := &TGMirror{BaseURL: baseURL}
+	if len(parts) == 2 {
+		mirror.Proxy = strings.TrimSpace(parts[1])
+	}
+
+	switch mirror.Proxy {
+	case "":
+		// 使用全局客户端（已配置全局代理），在请求时获取
+	case "direct":
+		mirror.client = &http.Client{Transport: NewOutboundTransport(NewTransport("")), Timeout: 60 * time.Second}
+	default:
+		mirror.client = &http.Client{Transport: NewOutboundTransport(NewTransport(mirror.Proxy)), Timeout: 60 * time.Second}
+	}
+	return mirror
+}
+
+// Client 获取该镜像使用的HTTP客户端
+func (m *TGMirror) Client() *http.Client {
+	if m.client != nil {
+		return m.client
+	}
+	return GetHTTPClient()
+}
+
+// BuildSearchURL 构建该镜像上的频道搜索URL
+func (m *TGMirror) BuildSearchURL(channel string, keyword string, nextPageParam string) string {
+	return buildChannelURL(m.BaseURL, channel, keyword, nextPageParam)
+}
+
+// Primary 返回首个配置的镜像
+func (p *TGMirrorPool) Primary() *TGMirror {
+	return p.mirrors[0]
+}
+
+// Candidates 返回本次请求的镜像尝试顺序：健康镜像按配置顺序在前，
+// 冷却中的镜像按冷却结束时间排在后面，保证所有镜像都失败时仍有兜底
+func (p *TGMirrorPool) Candidates() []*TGMirror {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	healthy := make([]*TGMirror, 0, len(p.mirrors))
+	var cooling []*TGMirror
+	for _, m := range p.mirrors {
+		if now.Before(m.cooldownUntil) {
+			cooling = append(cooling, m)
+		} else {
+			healthy = append(healthy, m)
+		}
+	}
+
+	// 冷却中的镜像按恢复时间升序
+	sort.Slice(cooling, func(i, j int) bool {
+		return cooling[i].cooldownUntil.Before(cooling[j].cooldownUntil)
+	})
+	return append(healthy, cooling...)
+}
+
+// ReportSuccess 记录镜像请求成功
+func (p *TGMirrorPool) ReportSuccess(m *TGMirror) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	m.consecutiveFailures = 0
+	m.cooldownUntil = time.Time{}
+	m.lastSuccess = time.Now()
+	m.totalSuccess++
+}
+
+// ReportFailure 记录镜像请求失败，连续失败时冷却时间指数增长
+func (p *TGMirrorPool) ReportFailure(m *TGMirror, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	m.consecutiveFailures++
+	m.totalFailure++
+	if err != nil {
+		m.lastError = err.Error()
+	}
+
+	factor := 1 << uint(m.consecutiveFailures-1)
+	if factor > maxTGMirrorCooldownFactor {
+		factor = maxTGMirrorCooldownFactor
+	}
+	m.cooldownUntil = time.Now().Add(p.cooldown * time.Duration(factor))
+}
+
+// Status 获取所有镜像的健康状态
+func (p *TGMirrorPool) Status() []TGMirrorStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	status := make([]TGMirrorStatus, 0, len(p.mirrors))
+	for _, m := range p.mirrors {
+		status = append(status, TGMirrorStatus{
+			BaseURL:             m.BaseURL,
+			Proxy:               m.Proxy,
+			Healthy:             !now.Before(m.cooldownUntil),
+			ConsecutiveFailures: m.consecutiveFailures,
+			CooldownUntil:       m.cooldownUntil,
+			LastError:           m.lastError,
+			LastSuccess:         m.lastSuccess,
+			TotalSuccess:        m.totalSuccess,
+			TotalFailure:        m.totalFailure,
+		})
+	}
+	return status
+}
+
+// FetchChannelPage 获取频道预览页HTML，失败时自动切换到下一个镜像
+// 每个镜像的单次请求超时为attemptTimeout，ctx用于控制整体截止时间
+func (p *TGMirrorPool) FetchChannelPage(ctx context.Context, channel string, keyword string, nextPageParam string, attemptTimeout time.Duration) (string, error) {
+	var lastErr error
+	for _, mirror := range p.Candidates() {
+		if ctx.Err() != nil {
+			break
+		}
+
+		body, err := fetchFromMirror(ctx, mirror, channel, keyword, nextPageParam, attemptTimeout)
+		if err == nil {
+			p.ReportSuccess(mirror)
+			return body, nil
+		}
+
+		// 调用方取消或整体超时不计入镜像失败
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		p.ReportFailure(mirror, err)
+		lastErr = err
+	}
+
+	if lastErr == nil {
+		lastErr = ctx.Err()
+	}
+	return "", fmt.Errorf("所有TG预览镜像均不可用: %w", lastErr)
+}
+
+// fetchFromMirror 从单个镜像获取频道预览页
+func fetchFromMirror(ctx context.Context, mirror *TGMirror, channel string, keyword string, nextPageParam string, timeout time.Duration) (string, error) {
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(attemptCtx, "GET", mirror.BuildSearchURL(channel, keyword, nextPageParam), nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := mirror.Client().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP状态码: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	// 被拦截或镜像异常时返回的页面不包含TG预览页结构
+	html := string(body)
+	if !strings.Contains(html, "tgme_") {
+		return "", fmt.Errorf("响应不是TG预览页")
+	}
+	return html, nil
+}
@@ -17,9 +17,22 @@ import {
     Radio
 } from 'lucide-react';
 import { useAuthStore } from '@/stores/authStore';
-import type { SystemInfoResponse } from '@/types/api';
+import type { PluginStatus, SystemInfoResponse } from '@/types/api';
 import { toast } from 'sonner';
 
+/**
+ * 插件状态展示配置
+ */
+const pluginStatusDisplay: Record<PluginStatus, { label: string; variant: 'success' | 'warning' | 'error' | 'outline'; dot: string }> = {
+    healthy: { label: '健康', variant: 'success', dot: 'bg-green-500' },
+    degraded: { label: '降级', variant: 'warning', dot: 'bg-amber-500' },
+    failing: { label: '故障', variant: 'error', dot: 'bg-red-500' },
+    idle: { label: '未调用', variant: 'outline', dot: 'bg-gray-400' },
+    disabled: { label: '已禁用', variant: 'outline', dot: 'bg-gray-400' },
+};
+
+const getPluginStatusDisplay = (status: PluginStatus) => pluginStatusDisplay[status] ?? pluginStatusDisplay.idle;
+
 /**
  * 系统监控视图组件
  * 
@@ -192,15 +205,12 @@ export const SystemInfoView: React.FC = () => {
                                             </TableCell>
                                             <TableCell>
                                                 <Badge
-                                                    variant={plugin.status === 'active' ? 'success' : 'outline'}
+                                                    variant={getPluginStatusDisplay(plugin.status).variant}
                                                     className="font-medium"
                                                 >
                                                     <div className="flex items-center gap-1.5">
-                                                        <div className={`w-1.5 h-1.5 rounded-full ${plugin.status === 'active'
-                                                                ? 'bg-green-500'
-                                                                : 'bg-gray-400'
-                                                            }`} />
-                                                        {plugin.status === 'active' ? '活跃' : '不活跃'}
+                                                        <div className={`w-1.5 h-1.5 rounded-full ${getPluginStatusDisplay(plugin.status).dot}`} />
+                                                        {getPluginStatusDisplay(plugin.status).label}
                                                     </div>
                                                 </Badge>
                                             </TableCell>
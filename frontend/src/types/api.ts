@@ -281,10 +281,16 @@ export interface CloudTypeConfig {
 export interface PluginInfo {
   name: string;
   priority: number;
-  status: 'active' | 'inactive' | 'error';
+  default_priority?: number;
+  status: PluginStatus;
   description: string;
 }
 
+/**
+ * 插件健康状态（根据调用统计得出）
+ */
+export type PluginStatus = 'idle' | 'healthy' | 'degraded' | 'failing' | 'disabled';
+
 /**
  * 系统统计信息
  */